@@ -4,15 +4,23 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/merdernoty/stool-guru-bot/internal/bot"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
 	"github.com/merdernoty/stool-guru-bot/internal/bot/services/gemini"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/reminders"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/retention"
 	"github.com/merdernoty/stool-guru-bot/internal/config"
 	"github.com/merdernoty/stool-guru-bot/internal/server"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+	"github.com/merdernoty/stool-guru-bot/internal/storage/blob"
+	"github.com/merdernoty/stool-guru-bot/internal/storage/encryption"
+	"github.com/merdernoty/stool-guru-bot/internal/storage/sqlite"
 )
 
 type App struct {
@@ -20,6 +28,9 @@ type App struct {
 	server        *server.Server
 	bot           *bot.StoolGuruBot
 	geminiService *gemini.GeminiService
+	storage       storage.Storage
+	retention     *retention.Job
+	reminders     *reminders.Scheduler
 }
 
 func New() (*App, error) {
@@ -30,36 +41,92 @@ func New() (*App, error) {
 
 	log.Printf("📋 Loaded config: %s", cfg.String())
 
-	
+	keyring, err := encryption.LoadKeyring(cfg.EncryptionKey, cfg.EncryptionKeyFile, cfg.EncryptionPreviousKeys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encryption keys: %w", err)
+	}
+
+	blobs, err := newBlobStore(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image storage: %w", err)
+	}
+
+	store, err := sqlite.Open(context.Background(), cfg.DatabasePath, sqlite.Options{
+		Keyring: keyring,
+		Blobs:   blobs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage: %w", err)
+	}
+
 	geminiService, err := gemini.NewGeminiService(cfg.GeminiAPIKey)
 	if err != nil {
+		store.Close()
 		return nil, fmt.Errorf("failed to create Gemini service: %w", err)
 	}
 
-	botInstance, err := bot.NewBot(cfg, geminiService)
+	callbackCodec := callback.NewCodec(cfg.CallbackSecret)
+
+	botInstance, err := bot.NewBot(cfg, geminiService, store, callbackCodec)
 	if err != nil {
+		store.Close()
 		return nil, fmt.Errorf("failed to create bot: %w", err)
 	}
 
-	botInstance.SetGlobalBot()
+	serverInstance := server.NewServer(cfg, botInstance, store)
+
+	retentionJob := retention.NewJob(store, retention.Policy{
+		PhotoDays:      cfg.PhotoRetentionDays,
+		AnalysisMonths: cfg.AnalysisRetentionMonths,
+	}, cfg.RetentionInterval)
 
-	serverInstance := server.NewServer(cfg, botInstance)
+	scheduler := reminders.NewScheduler(store, botInstance.Telegram(), callbackCodec)
 
 	return &App{
 		config:        cfg,
 		server:        serverInstance,
 		bot:           botInstance,
 		geminiService: geminiService,
+		storage:       store,
+		retention:     retentionJob,
+		reminders:     scheduler,
 	}, nil
 }
 
+// newBlobStore создает хранилище оригиналов фото по конфигурации
+func newBlobStore(cfg *config.Config) (blob.Store, error) {
+	if cfg.BlobBackend == "s3" {
+		log.Printf("🪣 Image storage: s3 %s/%s", cfg.S3Endpoint, cfg.S3Bucket)
+		return blob.NewS3Store(blob.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Bucket:    cfg.S3Bucket,
+			Region:    cfg.S3Region,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			Prefix:    cfg.S3Prefix,
+			PathStyle: cfg.S3PathStyle,
+		}, &http.Client{Timeout: cfg.Timeout})
+	}
+
+	log.Printf("🪣 Image storage: %s", cfg.BlobPath)
+	return blob.NewFSStore(cfg.BlobPath)
+}
+
 func (a *App) Start() error {
 	defer func() {
 		if err := a.geminiService.Close(); err != nil {
 			log.Printf("Error closing Gemini service: %v", err)
 		}
+		if err := a.storage.Close(); err != nil {
+			log.Printf("Error closing storage: %v", err)
+		}
 	}()
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go a.retention.Run(jobsCtx)
+	go a.reminders.Run(jobsCtx)
+
 	if a.config.Debug {
 		log.Println("🔄 Debug mode: using polling instead of webhook")
 		return a.bot.StartPolling()
@@ -89,6 +156,7 @@ func (a *App) Start() error {
 		return err
 	}
 
+	a.bot.Close()
 	log.Println("✅ Server stopped gracefully")
 	return nil
-}
\ No newline at end of file
+}
@@ -12,22 +12,32 @@ import (
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/conversation"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/dispatcher"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/flows"
 	"github.com/merdernoty/stool-guru-bot/internal/bot/handlers/callbacks"
 	"github.com/merdernoty/stool-guru-bot/internal/bot/handlers/commands"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/handlers/history"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/handlers/media"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
 	"github.com/merdernoty/stool-guru-bot/internal/bot/router"
 	"github.com/merdernoty/stool-guru-bot/internal/bot/services/gemini"
 	"github.com/merdernoty/stool-guru-bot/internal/config"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
 )
 
 type StoolGuruBot struct {
-	bot           *bot.Bot
-	config        *config.Config
-	router        *router.Router
-	ctx           context.Context
-	cancel        context.CancelFunc
+	bot     *bot.Bot
+	config  *config.Config
+	router  *router.Router
+	updates *dispatcher.Dispatcher
+	storage storage.Storage
+	ctx     context.Context
+	cancel  context.CancelFunc
 }
 
-func NewBot(cfg *config.Config, geminiService *gemini.GeminiService) (*StoolGuruBot, error) {
+func NewBot(cfg *config.Config, geminiService *gemini.GeminiService, store storage.Storage, callbackCodec *callback.Codec) (*StoolGuruBot, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	httpClient := &http.Client{
 		Timeout: cfg.Timeout,
@@ -40,16 +50,61 @@ func NewBot(cfg *config.Config, geminiService *gemini.GeminiService) (*StoolGuru
 
 	log.Printf("🔄 Creating bot with custom HTTP client (timeout: %v)", cfg.Timeout)
 
+	commandRegistry := commands.NewRegistry(cfg.AdminIDs)
+
+	conversations := conversation.NewManager(conversation.NewMemoryStorage(), cfg.ConversationTimeout, callbackCodec)
+	conversations.Register(flows.NewIBSFlow(store.Questionnaires()))
+	conversations.Register(flows.NewDiaryFlow(store.Diary(), store.Settings()))
+	conversations.Register(flows.NewMealFlow(store.Lifestyle()))
+	conversations.Register(flows.NewSleepFlow(store.Lifestyle()))
+	conversations.Register(flows.NewStressFlow(store.Lifestyle()))
+	conversations.Register(flows.NewMedicationFlow(store.Medications()))
+	conversations.Register(flows.NewOnboardingFlow(store.Profiles()))
+
+	photoHandler := media.NewPhotoHandler(geminiService, store, callbackCodec, cfg.Timeout)
+
+	// порядок команд здесь - порядок в меню Telegram и в справке
+	commandRegistry.Add(
+		commands.NewStartHandler(store, conversations, callbackCodec),
+		commands.NewHelpHandler(commandRegistry),
+		commands.NewLogHandler(conversations),
+		commands.NewFoodHandler(conversations),
+		commands.NewSleepHandler(conversations),
+		commands.NewStressHandler(conversations),
+		commands.NewTriggersHandler(store),
+		commands.NewMedsHandler(store, conversations, callbackCodec),
+		commands.NewProfileHandler(store, conversations, callbackCodec),
+		commands.NewSettingsHandler(store, callbackCodec),
+		history.NewHistoryHandler(store, callbackCodec),
+		commands.NewStatsHandler(store, callbackCodec),
+		commands.NewExportHandler(store, cfg.ExportSecret, cfg.ExportTokenTTL),
+		commands.NewReportHandler(store, geminiService, callbackCodec),
+		commands.NewPhotosHandler(store, callbackCodec),
+		commands.NewRemindHandler(store, conversations, callbackCodec),
+		commands.NewDeleteMeHandler(store, callbackCodec, conversations, photoHandler),
+		commands.NewCancelHandler(conversations),
+	)
+
+	callbackHandlers := callbacks.NewCallbackHandlers(conversations, store)
+
+	botRouter := router.NewRouter(conversations, commandRegistry, callbackCodec, photoHandler, callbackHandlers, defaultHandler(commandRegistry))
+	botRouter.RegisterHandlers()
+
+	// Обработчик выбирает роутер внутри очереди чата, а не библиотека до middleware:
+	// иначе выбор опирался бы на состояние диалога до обработки предыдущих обновлений.
+	// Синхронный ProcessUpdate ставит обновления в очереди в порядке их получения.
 	opts := []bot.Option{
-		bot.WithDefaultHandler(defaultHandler),
+		bot.WithDefaultHandler(botRouter.Handle),
+		bot.WithNotAsyncHandlers(),
 		bot.WithCheckInitTimeout(cfg.Timeout),
 		bot.WithHTTPClient(30*time.Second, httpClient),
 	}
 
 	if cfg.Debug {
-		opts = append(opts, bot.WithMiddlewares(debugMiddleware))
 		log.Println("🔍 Debug mode enabled")
 	}
+	updates := dispatcher.New(cfg.UpdateQueueSize)
+	opts = append(opts, bot.WithMiddlewares(buildMiddlewares(cfg, store, updates)...))
 
 	b, err := bot.New(cfg.TelegramToken, opts...)
 	if err != nil {
@@ -57,27 +112,22 @@ func NewBot(cfg *config.Config, geminiService *gemini.GeminiService) (*StoolGuru
 		return nil, fmt.Errorf("failed to create bot: %w", err)
 	}
 
-	startHandler := commands.NewStartHandler()
-	helpHandler := commands.NewHelpHandler()
-	callbackHandlers := callbacks.NewCallbackHandlers()
-
-
-	botRouter := router.NewRouter(
-		startHandler,
-		helpHandler,
-
-		callbackHandlers,
-	)
-
 	stoolBot := &StoolGuruBot{
-		bot:    b,
-		config: cfg,
-		router: botRouter,
-		ctx:    ctx,
-		cancel: cancel,
+		bot:     b,
+		config:  cfg,
+		router:  botRouter,
+		updates: updates,
+		storage: store,
+		ctx:     ctx,
+		cancel:  cancel,
 	}
 
-	stoolBot.router.RegisterHandlers(stoolBot.bot)
+	syncCtx, cancelSync := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancelSync()
+	if err := commandRegistry.Sync(syncCtx, b); err != nil {
+		// без меню бот работает, команды по-прежнему можно ввести вручную
+		log.Printf("Error syncing command menu: %v", err)
+	}
 
 	log.Printf("✅ Bot initialized successfully")
 	return stoolBot, nil
@@ -96,6 +146,7 @@ func (sb *StoolGuruBot) StartPolling() error {
 
 	log.Println("✅ Bot started! Попробуйте отправить /start в Telegram")
 	sb.bot.Start(sb.ctx)
+	sb.Close()
 	log.Println("✅ Bot stopped gracefully")
 	return nil
 }
@@ -105,95 +156,69 @@ func (sb *StoolGuruBot) SetWebhook() error {
 		return fmt.Errorf("webhook URL is required")
 	}
 
-	webhookURL := sb.config.WebhookURL + "/bot"
+	webhookURL := sb.config.WebhookURL + sb.config.WebhookPath
 
 	ctxWithTimeout, cancel := context.WithTimeout(sb.ctx, sb.config.Timeout)
 	defer cancel()
 
+	// Telegram будет присылать секрет в заголовке каждого запроса, см. server.handleWebhook
 	_, err := sb.bot.SetWebhook(ctxWithTimeout, &bot.SetWebhookParams{
-		URL: webhookURL,
+		URL:         webhookURL,
+		SecretToken: sb.config.WebhookSecret,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to set webhook: %w", err)
 	}
 
-	log.Printf("📡 Webhook set to: %s", webhookURL)
+	// путь вебхука - тоже секрет, в лог пишем только адрес сервера
+	log.Printf("📡 Webhook set to: %s", sb.config.WebhookURL)
 	return nil
 }
 
-func (sb *StoolGuruBot) ProcessWebhookUpdate(update *models.Update) error {
-	sb.bot.ProcessUpdate(sb.ctx, update)
-	return nil
+// Close дожидается обновлений, которые уже стоят в очередях чатов
+func (sb *StoolGuruBot) Close() {
+	sb.updates.Close()
 }
 
-func (sb *StoolGuruBot) SetGlobalBot() {
-	// TODO: Убрать когда перенесем обработку фото в отдельный хэндлер
-	globalStoolBot = sb
+// UpdateStats возвращает состояние очередей обновлений
+func (sb *StoolGuruBot) UpdateStats() dispatcher.Stats {
+	return sb.updates.Stats()
 }
 
+// Telegram возвращает клиент Telegram для фоновых задач, которые сами пишут пользователям
+func (sb *StoolGuruBot) Telegram() *bot.Bot {
+	return sb.bot
+}
 
-func debugMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
-	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
-		if update.Message != nil {
-			log.Printf("🔍 Message from @%s: %s",
-				update.Message.From.Username,
-				update.Message.Text)
-		}
-		if update.CallbackQuery != nil {
-			log.Printf("🔍 Callback from @%s: %s",
-				update.CallbackQuery.From.Username,
-				update.CallbackQuery.Data)
-		}
-		next(ctx, b, update)
-	}
+func (sb *StoolGuruBot) ProcessWebhookUpdate(update *models.Update) error {
+	sb.bot.ProcessUpdate(sb.ctx, update)
+	return nil
 }
 
-func defaultHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
-	if update.Message != nil && len(update.Message.Photo) > 0 {
-		log.Printf("📸 Photo received from @%s", update.Message.From.Username)
-		
-		// TODO: Перенести в отдельный хэндлер фотографий
-		if globalStoolBot != nil {
-			globalStoolBot.handlePhoto(ctx, b, update)
+// defaultHandler отвечает на неизвестные команды списком тех, что есть
+func defaultHandler(registry *commands.Registry) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		if update.Message == nil || update.Message.Text == "" {
+			return
 		}
-		return
-	}
-
-	if update.Message != nil && update.Message.Text != "" {
 		log.Printf("📨 Unhandled message: %s", update.Message.Text)
 
-		response := `🤔 Не понимаю эту команду.
-
-**Доступные команды:**
-• /start - главное меню
-• /help - справка
-• /test - тест функций
-
-📸 Или просто отправьте фото для анализа!`
+		var userID int64
+		if update.Message.From != nil {
+			userID = update.Message.From.ID
+		}
+		locale := i18n.FromContext(ctx)
+		response := i18n.T(locale, "default.unknown", nil) + "\n" +
+			registry.Summary(locale, userID, update.Message.Chat.Type) + "\n\n" +
+			i18n.T(locale, "default.hint", nil)
 
 		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID:    update.Message.Chat.ID,
 			Text:      response,
-			ParseMode: models.ParseModeMarkdown,
+			ParseMode: models.ParseModeHTML,
 		})
 		if err != nil {
 			log.Printf("Error in default handler: %v", err)
 		}
 	}
 }
-
-// TODO: Временная глобальная переменная, убрать после рефакторинга фото хэндлера
-var globalStoolBot *StoolGuruBot
-
-func (sb *StoolGuruBot) handlePhoto(ctx context.Context, b *bot.Bot, update *models.Update) {
-	// TODO: Вынести в handlers/media/photo.go
-	log.Println("📸 Photo received for analysis - using legacy handler")
-	
-	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.Message.Chat.ID,
-		Text:   "📸 Фото получено! Обработка фотографий будет реализована в следующей итерации рефакторинга.",
-	})
-	if err != nil {
-		log.Printf("Error sending photo response: %v", err)
-	}
-}
\ No newline at end of file
@@ -0,0 +1,180 @@
+package callback
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+)
+
+// MaxLength - лимит Telegram на данные кнопки, в байтах
+const MaxLength = 64
+
+const (
+	// signatureBytes - сколько байт HMAC хранится в кнопке: 6 байт дают 8 символов base64
+	signatureBytes = 6
+	separator      = ":"
+	signatureMark  = "~"
+)
+
+var (
+	ErrMalformed = errors.New("malformed callback data")
+	ErrSignature = errors.New("invalid callback signature")
+	ErrVersion   = errors.New("outdated callback version")
+)
+
+// Payload - проверенные данные кнопки: <prefix><version>:<action>[:<arg>...]~<signature>
+type Payload struct {
+	Prefix  string
+	Version int
+	Action  string
+	Args    []string
+}
+
+// String возвращает i-й аргумент или пустую строку, если его нет
+func (p Payload) String(i int) string {
+	if i < 0 || i >= len(p.Args) {
+		return ""
+	}
+	return p.Args[i]
+}
+
+// Int возвращает i-й аргумент как число, -1 - если его нет или это не число
+func (p Payload) Int(i int) int {
+	value, err := strconv.Atoi(p.String(i))
+	if err != nil {
+		return -1
+	}
+	return value
+}
+
+// Int64 возвращает i-й аргумент как ID, -1 - если его нет или это не число
+func (p Payload) Int64(i int) int64 {
+	value, err := strconv.ParseInt(p.String(i), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return value
+}
+
+// Codec подписывает данные кнопок, чтобы в колбэк нельзя было подставить чужой ID
+type Codec struct {
+	key []byte
+}
+
+// NewCodec выводит ключ подписи из секрета, поэтому секретом может быть и токен бота
+func NewCodec(secret string) *Codec {
+	key := sha256.Sum256([]byte("callback:" + secret))
+	return &Codec{key: key[:]}
+}
+
+// Encode собирает подписанные данные кнопки. Аргументы с ":" или "~" и данные
+// длиннее MaxLength - ошибка программы: Telegram такую кнопку не примет.
+func (c *Codec) Encode(prefix string, version int, action string, args ...any) string {
+	fields := []string{strconv.Itoa(version), action}
+	for _, arg := range args {
+		fields = append(fields, fmt.Sprint(arg))
+	}
+	for _, field := range fields {
+		if strings.ContainsAny(field, separator+signatureMark) {
+			panic(fmt.Sprintf("callback %s%s: invalid field %q", prefix, action, field))
+		}
+	}
+
+	body := prefix + strings.Join(fields, separator)
+	data := body + signatureMark + c.sign(body)
+	if len(data) > MaxLength {
+		panic(fmt.Sprintf("callback %q is longer than %d bytes", data, MaxLength))
+	}
+	return data
+}
+
+// Decode проверяет подпись и разбирает данные кнопки с префиксом prefix
+func (c *Codec) Decode(prefix, data string) (Payload, error) {
+	i := strings.LastIndex(data, signatureMark)
+	if i < 0 || !strings.HasPrefix(data, prefix) {
+		return Payload{}, ErrMalformed
+	}
+	body, signature := data[:i], data[i+len(signatureMark):]
+	if !hmac.Equal([]byte(signature), []byte(c.sign(body))) {
+		return Payload{}, ErrSignature
+	}
+
+	fields := strings.Split(strings.TrimPrefix(body, prefix), separator)
+	if len(fields) < 2 {
+		return Payload{}, ErrMalformed
+	}
+	version, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Payload{}, ErrMalformed
+	}
+
+	return Payload{
+		Prefix:  prefix,
+		Version: version,
+		Action:  fields[1],
+		Args:    fields[2:],
+	}, nil
+}
+
+func (c *Codec) sign(body string) string {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:signatureBytes])
+}
+
+// verify разбирает данные кнопки маршрута route и проверяет их версию
+func (c *Codec) verify(route Route, data string) (Payload, error) {
+	payload, err := c.Decode(route.Prefix, data)
+	if err != nil {
+		return Payload{}, err
+	}
+	if payload.Version != route.Version {
+		return Payload{}, ErrVersion
+	}
+	return payload, nil
+}
+
+// Handler - обработчик кнопки, получающий уже проверенные данные
+type Handler func(ctx context.Context, b *bot.Bot, update *models.Update, payload Payload)
+
+// Route - обработчик кнопок с префиксом Prefix. Кнопки другой версии
+// (например, в старых сообщениях после смены формата) не доходят до Handle.
+type Route struct {
+	Prefix  string
+	Version int
+	Handle  Handler
+}
+
+// HandlerFunc превращает маршрут в обработчик бота: проверяет подпись и версию
+// и на поддельную или устаревшую кнопку отвечает, что она больше не работает
+func (c *Codec) HandlerFunc(route Route) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		query := update.CallbackQuery
+
+		payload, err := c.verify(route, query.Data)
+		if err != nil {
+			log.Printf("⚠️ Rejected callback %q from %d: %v", query.Data, query.From.ID, err)
+			_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+				CallbackQueryID: query.ID,
+				Text:            i18n.T(i18n.FromContext(ctx), "callback.expired", nil),
+				ShowAlert:       true,
+			})
+			if err != nil {
+				log.Printf("Error answering rejected callback: %v", err)
+			}
+			return
+		}
+
+		route.Handle(ctx, b, update, payload)
+	}
+}
@@ -0,0 +1,112 @@
+package callback
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testPrefix = "med:"
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	c := NewCodec("secret")
+
+	data := c.Encode(testPrefix, 2, "take", int64(1234567890123), "on")
+	payload, err := c.Decode(testPrefix, data)
+	if err != nil {
+		t.Fatalf("Decode(%q) error: %v", data, err)
+	}
+
+	if payload.Prefix != testPrefix || payload.Version != 2 || payload.Action != "take" {
+		t.Errorf("payload = %+v, want prefix %q, version 2, action take", payload, testPrefix)
+	}
+	if payload.Int64(0) != 1234567890123 || payload.String(1) != "on" {
+		t.Errorf("args = %v, want [1234567890123 on]", payload.Args)
+	}
+	if payload.Int(1) != -1 || payload.String(5) != "" || payload.Int64(5) != -1 {
+		t.Error("missing or non-numeric args must read as empty and -1")
+	}
+}
+
+func TestDecodeRejectsTamperedData(t *testing.T) {
+	c := NewCodec("secret")
+	data := c.Encode(testPrefix, 1, "del", 42)
+	body, signature, _ := strings.Cut(data, signatureMark)
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "changed id", data: strings.Replace(body, "42", "43", 1) + signatureMark + signature},
+		{name: "changed signature", data: body + signatureMark + strings.Repeat("A", len(signature))},
+		{name: "empty signature", data: body + signatureMark},
+		{name: "other secret", data: NewCodec("other").Encode(testPrefix, 1, "del", 42)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.Decode(testPrefix, tt.data); !errors.Is(err, ErrSignature) {
+				t.Errorf("Decode(%q) error = %v, want %v", tt.data, err, ErrSignature)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsMalformedData(t *testing.T) {
+	c := NewCodec("secret")
+
+	for _, data := range []string{
+		"med:1:del:42",
+		"del:42~abcdefgh",
+		c.Encode("rem:", 1, "del", 42),
+	} {
+		if _, err := c.Decode(testPrefix, data); !errors.Is(err, ErrMalformed) {
+			t.Errorf("Decode(%q) error = %v, want %v", data, err, ErrMalformed)
+		}
+	}
+}
+
+func TestVerifyRejectsOtherVersion(t *testing.T) {
+	c := NewCodec("secret")
+
+	// подпись кнопки из старого сообщения верна, но формат данных уже другой
+	data := c.Encode(testPrefix, 1, "del", 42)
+	if _, err := c.verify(Route{Prefix: testPrefix, Version: 2}, data); !errors.Is(err, ErrVersion) {
+		t.Errorf("verify error = %v, want %v", err, ErrVersion)
+	}
+
+	payload, err := c.verify(Route{Prefix: testPrefix, Version: 1}, data)
+	if err != nil || payload.Int64(0) != 42 {
+		t.Errorf("verify = %+v, %v; want id 42 and no error", payload, err)
+	}
+}
+
+func TestEncodeFitsTelegramLimit(t *testing.T) {
+	c := NewCodec("secret")
+
+	data := c.Encode("hist:", 1, "fp", 999, "240501-240515", 7, int64(9223372036854775807))
+	if len(data) > MaxLength {
+		t.Fatalf("history button is %d bytes, limit is %d", len(data), MaxLength)
+	}
+
+	assertPanics(t, "data longer than MaxLength", func() {
+		c.Encode(testPrefix, 1, "del", strings.Repeat("9", MaxLength))
+	})
+}
+
+func TestEncodePanicsOnSeparators(t *testing.T) {
+	c := NewCodec("secret")
+
+	assertPanics(t, "arg with separator", func() { c.Encode(testPrefix, 1, "del", "1:2") })
+	assertPanics(t, "arg with signature mark", func() { c.Encode(testPrefix, 1, "del", "1~2") })
+	assertPanics(t, "action with separator", func() { c.Encode(testPrefix, 1, "a:b") })
+}
+
+func assertPanics(t *testing.T, name string, f func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s: Encode did not panic", name)
+		}
+	}()
+	f()
+}
@@ -0,0 +1,92 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+)
+
+// InputKind - тип ввода, который ожидает шаг диалога
+type InputKind int
+
+const (
+	InputText InputKind = 1 << iota
+	InputPhoto
+	InputCallback
+)
+
+func (k InputKind) Has(kind InputKind) bool {
+	return k&kind != 0
+}
+
+// End - имя шага, означающее завершение диалога
+const End = "__end__"
+
+const (
+	// CallbackPrefix - префикс данных кнопок, принадлежащих диалогам
+	CallbackPrefix = "conv:"
+	// CallbackVersion - версия формата кнопок диалогов
+	CallbackVersion = 1
+)
+
+// ErrInvalidInput возвращается из Step.Handle, когда ответ не подходит.
+// Пользователь увидит Step.Retry, а диалог останется на том же шаге.
+var ErrInvalidInput = errors.New("invalid input")
+
+// Input - ответ пользователя на шаге диалога
+type Input struct {
+	Kind     InputKind
+	Text     string
+	Callback string
+	Photo    []models.PhotoSize
+	Update   *models.Update
+}
+
+// Step - шаг диалога
+type Step struct {
+	Name string
+
+	// Expect - какие типы ввода принимает шаг
+	Expect InputKind
+
+	// Enter задает вопрос пользователю при входе на шаг
+	Enter func(ctx context.Context, s *Session) error
+
+	// Handle обрабатывает ответ и возвращает имя следующего шага или End
+	Handle func(ctx context.Context, s *Session, in Input) (string, error)
+
+	// Retry - ключ подсказки при неподходящем ответе в каталоге сообщений
+	Retry string
+}
+
+// Flow - декларативное описание многошагового диалога
+type Flow struct {
+	Name  string
+	Start string
+	Steps []Step
+
+	// Timeout - сколько ждать ответа на каждом шаге, 0 - значение менеджера
+	Timeout time.Duration
+
+	// OnComplete вызывается после перехода в End
+	OnComplete func(ctx context.Context, s *Session) error
+
+	steps map[string]*Step
+}
+
+func (f *Flow) step(name string) *Step {
+	if f.steps == nil {
+		f.steps = make(map[string]*Step, len(f.Steps))
+		for i := range f.Steps {
+			f.steps[f.Steps[i].Name] = &f.Steps[i]
+		}
+	}
+	return f.steps[name]
+}
+
+// Keyboard собирает inline-клавиатуру из рядов кнопок
+func Keyboard(rows ...[]models.InlineKeyboardButton) *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
@@ -0,0 +1,329 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+)
+
+// Manager ведет диалоги: хранит состояние чатов и перенаправляет им ответы пользователей
+type Manager struct {
+	storage        Storage
+	defaultTimeout time.Duration
+	codec          *callback.Codec
+	flows          map[string]*Flow
+}
+
+func NewManager(storage Storage, defaultTimeout time.Duration, codec *callback.Codec) *Manager {
+	return &Manager{
+		storage:        storage,
+		defaultTimeout: defaultTimeout,
+		codec:          codec,
+		flows:          make(map[string]*Flow),
+	}
+}
+
+// Register добавляет описание диалога. Регистрировать нужно до запуска бота.
+func (m *Manager) Register(flow *Flow) {
+	if flow.step(flow.Start) == nil {
+		panic(fmt.Sprintf("conversation %q: unknown start step %q", flow.Name, flow.Start))
+	}
+	m.flows[flow.Name] = flow
+	log.Printf("🗂 Registered conversation: %s", flow.Name)
+}
+
+// Start запускает диалог в чате, заменяя текущий, если он был
+func (m *Manager) Start(ctx context.Context, b *bot.Bot, chatID, userID int64, flowName string, data map[string]string) error {
+	flow, ok := m.flows[flowName]
+	if !ok {
+		return fmt.Errorf("unknown conversation %q", flowName)
+	}
+
+	state := &State{
+		Flow:   flow.Name,
+		Step:   flow.Start,
+		UserID: userID,
+		Data:   copyData(data),
+	}
+
+	session := &Session{Bot: b, ChatID: chatID, UserID: userID, State: state, Locale: i18n.FromContext(ctx), codec: m.codec}
+	return m.enter(ctx, flow, session, flow.Start)
+}
+
+// Cancel прерывает диалог пользователя userID в чате. Возвращает false,
+// если диалога не было или его начал другой участник чата.
+func (m *Manager) Cancel(ctx context.Context, chatID, userID int64) (bool, error) {
+	state, err := m.storage.Get(ctx, chatID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load conversation state: %w", err)
+	}
+	if state == nil || !state.OwnedBy(userID) {
+		return false, nil
+	}
+
+	if err := m.storage.Delete(ctx, chatID); err != nil {
+		return false, fmt.Errorf("failed to delete conversation state: %w", err)
+	}
+	return !state.Expired(time.Now()), nil
+}
+
+// ForgetUser завершает диалоги пользователя во всех чатах: их данные
+// (ответы, которые еще не сохранены) не должны пережить удаление аккаунта
+func (m *Manager) ForgetUser(ctx context.Context, userID int64) error {
+	if err := m.storage.DeleteUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete conversations: %w", err)
+	}
+	return nil
+}
+
+// Match сообщает, должно ли сообщение попасть в активный диалог.
+// Кнопки диалогов приходят через GetCallbackRoutes.
+func (m *Manager) Match(update *models.Update) bool {
+	msg := update.Message
+	if msg == nil || strings.HasPrefix(msg.Text, "/") {
+		return false
+	}
+	if msg.Text == "" && len(msg.Photo) == 0 {
+		return false
+	}
+
+	ctx := context.Background()
+	state, err := m.storage.Get(ctx, msg.Chat.ID)
+	if err != nil {
+		log.Printf("Error loading conversation state: %v", err)
+		return false
+	}
+	if state == nil {
+		return false
+	}
+	// в группе сообщения других участников не считаются ответами на чужой диалог
+	if msg.From == nil || !state.OwnedBy(msg.From.ID) {
+		return false
+	}
+
+	if state.Expired(time.Now()) {
+		if err := m.storage.Delete(ctx, msg.Chat.ID); err != nil {
+			log.Printf("Error deleting expired conversation: %v", err)
+		}
+		return false
+	}
+
+	return true
+}
+
+func (m *Manager) GetCallbackRoutes() []callback.Route {
+	return []callback.Route{
+		{Prefix: CallbackPrefix, Version: CallbackVersion, Handle: m.handleCallback},
+	}
+}
+
+// Handle передает ответ пользователя текущему шагу диалога
+func (m *Manager) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID, userID, in := parseInput(update)
+	m.handle(ctx, b, update, chatID, userID, in)
+}
+
+// handleCallback передает шагу значение нажатой кнопки в том виде, в каком его задал Session.Button
+func (m *Manager) handleCallback(ctx context.Context, b *bot.Bot, update *models.Update, payload callback.Payload) {
+	chatID, userID, in := parseInput(update)
+	in.Callback = callbackValue(payload)
+	m.handle(ctx, b, update, chatID, userID, in)
+}
+
+// callbackValue собирает значение кнопки обратно из подписанных данных
+func callbackValue(payload callback.Payload) string {
+	return strings.Join(append([]string{payload.Action}, payload.Args...), ":")
+}
+
+func (m *Manager) handle(ctx context.Context, b *bot.Bot, update *models.Update, chatID, userID int64, in Input) {
+	locale := i18n.FromContext(ctx)
+
+	// notice - всплывающий ответ на нажатие кнопки, пусто - просто убрать часики
+	notice := ""
+	if update.CallbackQuery != nil {
+		defer func() { answerCallback(ctx, b, update.CallbackQuery.ID, notice) }()
+	}
+	if chatID == 0 {
+		return
+	}
+
+	state, err := m.storage.Get(ctx, chatID)
+	if err != nil {
+		log.Printf("Error loading conversation state: %v", err)
+		return
+	}
+	if state == nil || state.Expired(time.Now()) {
+		if state != nil {
+			if err := m.storage.Delete(ctx, chatID); err != nil {
+				log.Printf("Error deleting expired conversation: %v", err)
+			}
+		}
+		sendText(ctx, b, chatID, i18n.T(locale, "conversation.expired", nil))
+		return
+	}
+
+	// отвечать на шаги, в том числе кнопками, может только тот, кто начал диалог
+	if !state.OwnedBy(userID) {
+		notice = i18n.T(locale, "conversation.not_owner", nil)
+		return
+	}
+
+	flow, ok := m.flows[state.Flow]
+	if !ok {
+		log.Printf("Conversation %q is not registered, dropping state", state.Flow)
+		m.drop(ctx, chatID)
+		return
+	}
+	step := flow.step(state.Step)
+	if step == nil {
+		log.Printf("Conversation %q has no step %q, dropping state", state.Flow, state.Step)
+		m.drop(ctx, chatID)
+		return
+	}
+
+	session := &Session{Bot: b, ChatID: chatID, UserID: userID, State: state, Locale: locale, codec: m.codec}
+	if update.CallbackQuery != nil && update.CallbackQuery.Message.Message != nil {
+		session.editable = update.CallbackQuery.Message.Message.ID == state.MessageID
+	}
+
+	if !step.Expect.Has(in.Kind) {
+		m.retry(ctx, b, chatID, step)
+		return
+	}
+
+	next, err := step.Handle(ctx, session, in)
+	if errors.Is(err, ErrInvalidInput) {
+		m.retry(ctx, b, chatID, step)
+		return
+	}
+	if err != nil {
+		log.Printf("Error in conversation %s/%s: %v", state.Flow, state.Step, err)
+		m.drop(ctx, chatID)
+		sendText(ctx, b, chatID, i18n.T(locale, "conversation.failed", nil))
+		return
+	}
+
+	if err := m.enter(ctx, flow, session, next); err != nil {
+		log.Printf("Error entering conversation step %s/%s: %v", state.Flow, next, err)
+		m.drop(ctx, chatID)
+		sendText(ctx, b, chatID, i18n.T(locale, "conversation.failed", nil))
+	}
+}
+
+// enter переводит диалог на шаг next и сохраняет состояние
+func (m *Manager) enter(ctx context.Context, flow *Flow, session *Session, next string) error {
+	if next == End {
+		var err error
+		if flow.OnComplete != nil {
+			err = flow.OnComplete(ctx, session)
+		}
+		if delErr := m.storage.Delete(ctx, session.ChatID); delErr != nil {
+			log.Printf("Error deleting finished conversation: %v", delErr)
+		}
+		return err
+	}
+
+	step := flow.step(next)
+	if step == nil {
+		return fmt.Errorf("unknown step %q", next)
+	}
+
+	session.State.Step = next
+	if step.Enter != nil {
+		if err := step.Enter(ctx, session); err != nil {
+			return err
+		}
+	}
+
+	timeout := flow.Timeout
+	if timeout == 0 {
+		timeout = m.defaultTimeout
+	}
+
+	now := time.Now()
+	session.State.UpdatedAt = now
+	session.State.ExpiresAt = now.Add(timeout)
+
+	return m.storage.Set(ctx, session.ChatID, session.State)
+}
+
+func (m *Manager) retry(ctx context.Context, b *bot.Bot, chatID int64, step *Step) {
+	key := step.Retry
+	if key == "" {
+		key = "conversation.retry"
+	}
+	text := i18n.T(i18n.FromContext(ctx), key, nil)
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		log.Printf("Error sending conversation retry: %v", err)
+	}
+}
+
+func (m *Manager) drop(ctx context.Context, chatID int64) {
+	if err := m.storage.Delete(ctx, chatID); err != nil {
+		log.Printf("Error deleting conversation state: %v", err)
+	}
+}
+
+func parseInput(update *models.Update) (chatID, userID int64, in Input) {
+	in.Update = update
+
+	if query := update.CallbackQuery; query != nil {
+		in.Kind = InputCallback
+		userID = query.From.ID
+		if query.Message.Message != nil {
+			chatID = query.Message.Message.Chat.ID
+		}
+		return chatID, userID, in
+	}
+
+	msg := update.Message
+	chatID = msg.Chat.ID
+	if msg.From != nil {
+		userID = msg.From.ID
+	}
+
+	if len(msg.Photo) > 0 {
+		in.Kind = InputPhoto
+		in.Photo = msg.Photo
+		in.Text = msg.Caption
+		return chatID, userID, in
+	}
+
+	in.Kind = InputText
+	in.Text = strings.TrimSpace(msg.Text)
+	return chatID, userID, in
+}
+
+func answerCallback(ctx context.Context, b *bot.Bot, queryID, text string) {
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: queryID,
+		Text:            text,
+	})
+	if err != nil {
+		log.Printf("Error answering conversation callback: %v", err)
+	}
+}
+
+func sendText(ctx context.Context, b *bot.Bot, chatID int64, text string) {
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   text,
+	})
+	if err != nil {
+		log.Printf("Error sending conversation message: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package conversation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+)
+
+const groupChat = -100123
+
+func newTestManager(t *testing.T, owner int64) *Manager {
+	t.Helper()
+
+	storage := NewMemoryStorage()
+	err := storage.Set(context.Background(), groupChat, &State{
+		Flow:      "diary",
+		Step:      "bristol",
+		UserID:    owner,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewManager(storage, time.Hour, callback.NewCodec("test"))
+}
+
+func groupMessage(userID int64, text string) *models.Update {
+	return &models.Update{Message: &models.Message{
+		Chat: models.Chat{ID: groupChat, Type: models.ChatTypeSupergroup},
+		From: &models.User{ID: userID},
+		Text: text,
+	}}
+}
+
+func TestMatchOnlyOwnerMessages(t *testing.T) {
+	m := newTestManager(t, 1)
+
+	if !m.Match(groupMessage(1, "4")) {
+		t.Error("owner's answer does not reach the conversation")
+	}
+	if m.Match(groupMessage(2, "4")) {
+		t.Error("another member's message is taken as an answer")
+	}
+	if m.Match(&models.Update{Message: &models.Message{Chat: models.Chat{ID: groupChat}, Text: "4"}}) {
+		t.Error("message without a sender is taken as an answer")
+	}
+}
+
+func TestCancelOnlyByOwner(t *testing.T) {
+	m := newTestManager(t, 1)
+	ctx := context.Background()
+
+	if cancelled, err := m.Cancel(ctx, groupChat, 2); err != nil || cancelled {
+		t.Fatalf("Cancel by another member = %v, %v; want false, nil", cancelled, err)
+	}
+	if cancelled, err := m.Cancel(ctx, groupChat, 1); err != nil || !cancelled {
+		t.Fatalf("Cancel by owner = %v, %v; want true, nil", cancelled, err)
+	}
+}
+
+func TestOwnedBy(t *testing.T) {
+	if !(&State{}).OwnedBy(5) {
+		t.Error("state without an owner must accept anyone")
+	}
+	if (&State{UserID: 1}).OwnedBy(5) {
+		t.Error("state accepts another user")
+	}
+}
+
+func TestForgetUser(t *testing.T) {
+	m := newTestManager(t, 1)
+	ctx := context.Background()
+
+	// тот же пользователь в личном чате и другой пользователь в своем
+	for chatID, userID := range map[int64]int64{1: 1, 2: 2} {
+		if err := m.storage.Set(ctx, chatID, &State{Flow: "diary", Step: "bristol", UserID: userID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := m.ForgetUser(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	for chatID, wantKept := range map[int64]bool{groupChat: false, 1: false, 2: true} {
+		state, err := m.storage.Get(ctx, chatID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (state != nil) != wantKept {
+			t.Errorf("chat %d: state kept = %v, want %v", chatID, state != nil, wantKept)
+		}
+	}
+}
+
+func TestButtonValueRoundTrip(t *testing.T) {
+	codec := callback.NewCodec("test")
+	s := &Session{codec: codec}
+
+	for _, value := range []string{"onboarding_diet:vegan", "meal:tag:dairy", "note:skip", "done"} {
+		payload, err := codec.Decode(CallbackPrefix, s.Button("text", value).CallbackData)
+		if err != nil {
+			t.Fatalf("button %q: %v", value, err)
+		}
+		if got := callbackValue(payload); got != value {
+			t.Errorf("button value = %q, want %q", got, value)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+)
+
+// Session - доступ шагов диалога к состоянию и чату
+type Session struct {
+	Bot    *bot.Bot
+	ChatID int64
+	UserID int64
+	State  *State
+
+	// Locale - язык, на котором диалог отвечает пользователю
+	Locale string
+
+	codec *callback.Codec
+
+	// editable - можно ли отредактировать последнее сообщение диалога вместо отправки нового
+	editable bool
+}
+
+func (s *Session) Get(key string) string {
+	return s.State.Data[key]
+}
+
+func (s *Session) Set(key, value string) {
+	if s.State.Data == nil {
+		s.State.Data = make(map[string]string)
+	}
+	s.State.Data[key] = value
+}
+
+// T возвращает сообщение каталога на языке пользователя
+func (s *Session) T(key string, params i18n.Params) string {
+	return i18n.T(s.Locale, key, params)
+}
+
+// N возвращает сообщение каталога в форме для числа n
+func (s *Session) N(key string, n int, params i18n.Params) string {
+	return i18n.N(s.Locale, key, n, params)
+}
+
+// Button создает подписанную кнопку, ответ которой попадет в текущий диалог.
+// Значение вида "step:answer" придет в Input.Callback без изменений.
+func (s *Session) Button(text, value string) models.InlineKeyboardButton {
+	fields := strings.Split(value, ":")
+	args := make([]any, 0, len(fields)-1)
+	for _, field := range fields[1:] {
+		args = append(args, field)
+	}
+	return models.InlineKeyboardButton{
+		Text:         text,
+		CallbackData: s.codec.Encode(CallbackPrefix, CallbackVersion, fields[0], args...),
+	}
+}
+
+// Send показывает сообщение шага. Если пользователь ответил кнопкой,
+// предыдущее сообщение диалога редактируется, иначе отправляется новое.
+func (s *Session) Send(ctx context.Context, text string, markup *models.InlineKeyboardMarkup) error {
+	if s.editable && s.State.MessageID != 0 {
+		params := &bot.EditMessageTextParams{
+			ChatID:    s.ChatID,
+			MessageID: s.State.MessageID,
+			Text:      text,
+			ParseMode: models.ParseModeHTML,
+		}
+		if markup != nil {
+			params.ReplyMarkup = markup
+		}
+		if _, err := s.Bot.EditMessageText(ctx, params); err == nil {
+			return nil
+		}
+	}
+
+	params := &bot.SendMessageParams{
+		ChatID:    s.ChatID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	}
+	if markup != nil {
+		params.ReplyMarkup = markup
+	}
+
+	msg, err := s.Bot.SendMessage(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to send conversation message: %w", err)
+	}
+
+	s.State.MessageID = msg.ID
+	s.editable = false
+	return nil
+}
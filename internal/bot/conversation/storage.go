@@ -0,0 +1,109 @@
+package conversation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// State - состояние диалога в конкретном чате
+type State struct {
+	Flow      string            `json:"flow"`
+	Step      string            `json:"step"`
+	UserID    int64             `json:"user_id"`
+	MessageID int               `json:"message_id,omitempty"`
+	Data      map[string]string `json:"data,omitempty"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// Expired сообщает, истекло ли время ожидания ответа
+func (s *State) Expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && now.After(s.ExpiresAt)
+}
+
+// OwnedBy сообщает, может ли пользователь отвечать в диалоге. У состояний,
+// сохраненных без автора, владельца нет, и отвечать может любой.
+func (s *State) OwnedBy(userID int64) bool {
+	return s.UserID == 0 || s.UserID == userID
+}
+
+// Storage - хранилище состояний диалогов
+type Storage interface {
+	// Get возвращает состояние чата или nil, если диалога нет
+	Get(ctx context.Context, chatID int64) (*State, error)
+	Set(ctx context.Context, chatID int64, state *State) error
+	Delete(ctx context.Context, chatID int64) error
+	// DeleteUser удаляет диалоги пользователя во всех чатах
+	DeleteUser(ctx context.Context, userID int64) error
+}
+
+// MemoryStorage хранит состояния в памяти процесса
+type MemoryStorage struct {
+	mu     sync.RWMutex
+	states map[int64]State
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		states: make(map[int64]State),
+	}
+}
+
+func (s *MemoryStorage) Get(_ context.Context, chatID int64) (*State, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state, ok := s.states[chatID]
+	if !ok {
+		return nil, nil
+	}
+
+	state.Data = copyData(state.Data)
+	return &state, nil
+}
+
+func (s *MemoryStorage) Set(_ context.Context, chatID int64, state *State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, st := range s.states {
+		if st.Expired(now) {
+			delete(s.states, id)
+		}
+	}
+
+	stored := *state
+	stored.Data = copyData(state.Data)
+	s.states[chatID] = stored
+	return nil
+}
+
+func (s *MemoryStorage) Delete(_ context.Context, chatID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.states, chatID)
+	return nil
+}
+
+func (s *MemoryStorage) DeleteUser(_ context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for chatID, state := range s.states {
+		if state.UserID == userID {
+			delete(s.states, chatID)
+		}
+	}
+	return nil
+}
+
+func copyData(data map[string]string) map[string]string {
+	result := make(map[string]string, len(data))
+	for k, v := range data {
+		result[k] = v
+	}
+	return result
+}
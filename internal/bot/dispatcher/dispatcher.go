@@ -0,0 +1,139 @@
+package dispatcher
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Stats - состояние очередей для /metrics
+type Stats struct {
+	// Chats - чаты, у которых сейчас есть очередь
+	Chats int `json:"chats"`
+	// Pending - обновления, ожидающие обработки во всех очередях
+	Pending int `json:"pending"`
+	// MaxDepth - длина самой длинной очереди сейчас
+	MaxDepth int `json:"max_depth"`
+	// Processed и Dropped - счетчики с момента запуска
+	Processed uint64 `json:"processed"`
+	Dropped   uint64 `json:"dropped"`
+}
+
+// Dispatcher выполняет задачи одного чата строго по очереди, а задачи
+// разных чатов - параллельно. Очередь чата ограничена: если чат присылает
+// обновления быстрее, чем они обрабатываются, лишние отбрасываются.
+type Dispatcher struct {
+	buffer int
+
+	mu     sync.Mutex
+	queues map[int64]chan func()
+	closed bool
+	wg     sync.WaitGroup
+
+	processed atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// New создает диспетчер, buffer - сколько задач может ждать в очереди одного чата
+func New(buffer int) *Dispatcher {
+	return &Dispatcher{
+		buffer: buffer,
+		queues: make(map[int64]chan func()),
+	}
+}
+
+// Dispatch ставит задачу в очередь чата key. false - очередь переполнена
+// или диспетчер остановлен, задача не будет выполнена.
+func (d *Dispatcher) Dispatch(key int64, job func()) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		d.dropped.Add(1)
+		return false
+	}
+
+	queue, ok := d.queues[key]
+	if !ok {
+		queue = make(chan func(), d.buffer)
+		d.queues[key] = queue
+		d.wg.Add(1)
+		go d.run(key, queue)
+	}
+
+	select {
+	case queue <- job:
+		return true
+	default:
+		d.dropped.Add(1)
+		return false
+	}
+}
+
+// Go выполняет задачу сразу, вне очередей: для обновлений без чата и автора
+// порядок не важен, а общая очередь сделала бы их зависимыми друг от друга.
+// false - диспетчер остановлен.
+func (d *Dispatcher) Go(job func()) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		d.dropped.Add(1)
+		return false
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		job()
+		d.processed.Add(1)
+	}()
+	return true
+}
+
+// run выполняет задачи чата, пока очередь не опустеет, и удаляет ее.
+// Проверка и удаление идут под тем же мьютексом, что и Dispatch,
+// поэтому задача не может попасть в уже брошенную очередь.
+func (d *Dispatcher) run(key int64, queue chan func()) {
+	defer d.wg.Done()
+
+	for {
+		d.mu.Lock()
+		select {
+		case job := <-queue:
+			d.mu.Unlock()
+			job()
+			d.processed.Add(1)
+		default:
+			delete(d.queues, key)
+			d.mu.Unlock()
+			return
+		}
+	}
+}
+
+// Stats возвращает текущие длины очередей и счетчики
+func (d *Dispatcher) Stats() Stats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := Stats{
+		Chats:     len(d.queues),
+		Processed: d.processed.Load(),
+		Dropped:   d.dropped.Load(),
+	}
+	for _, queue := range d.queues {
+		depth := len(queue)
+		stats.Pending += depth
+		stats.MaxDepth = max(stats.MaxDepth, depth)
+	}
+	return stats
+}
+
+// Close перестает принимать задачи и ждет, пока выполнятся уже принятые
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+
+	d.wg.Wait()
+}
@@ -0,0 +1,138 @@
+package dispatcher
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDispatchKeepsChatOrder(t *testing.T) {
+	const n = 200
+	d := New(n)
+
+	var mu sync.Mutex
+	var got []int
+	for i := 0; i < n; i++ {
+		i := i
+		ok := d.Dispatch(42, func() {
+			time.Sleep(time.Duration(rand.Intn(50)) * time.Microsecond)
+			mu.Lock()
+			got = append(got, i)
+			mu.Unlock()
+		})
+		if !ok {
+			t.Fatalf("job %d was dropped", i)
+		}
+	}
+	d.Close()
+
+	if len(got) != n {
+		t.Fatalf("completed %d jobs, want %d", len(got), n)
+	}
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("job %d completed at position %d", v, i)
+		}
+	}
+}
+
+func TestDispatchRunsChatsInParallel(t *testing.T) {
+	d := New(1)
+	defer d.Close()
+
+	// задача первого чата ждет задачу второго: при общей очереди это взаимная блокировка
+	second := make(chan struct{})
+	done := make(chan struct{})
+	d.Dispatch(1, func() {
+		<-second
+		close(done)
+	})
+	d.Dispatch(2, func() { close(second) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("chats are not processed in parallel")
+	}
+}
+
+func TestDispatchDropsWhenQueueIsFull(t *testing.T) {
+	d := New(2)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	d.Dispatch(1, func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	// первая задача выполняется, в очереди помещаются еще две
+	for i := 0; i < 2; i++ {
+		if !d.Dispatch(1, func() {}) {
+			t.Fatalf("job %d dropped before the queue is full", i)
+		}
+	}
+	if d.Dispatch(1, func() {}) {
+		t.Fatal("job accepted into a full queue")
+	}
+	// очередь другого чата не затронута
+	if !d.Dispatch(2, func() {}) {
+		t.Fatal("job for another chat dropped")
+	}
+
+	stats := d.Stats()
+	if stats.Dropped != 1 || stats.Pending < 2 || stats.MaxDepth != 2 {
+		t.Errorf("stats = %+v, want dropped 1, pending >= 2, max depth 2", stats)
+	}
+
+	close(release)
+	d.Close()
+
+	if stats := d.Stats(); stats.Processed != 4 || stats.Chats != 0 {
+		t.Errorf("stats after close = %+v, want 4 processed and no chats", stats)
+	}
+}
+
+func TestCloseWaitsAndRejects(t *testing.T) {
+	d := New(4)
+
+	var finished bool
+	d.Dispatch(1, func() {
+		time.Sleep(10 * time.Millisecond)
+		finished = true
+	})
+	d.Close()
+
+	if !finished {
+		t.Error("Close returned before the accepted job finished")
+	}
+	if d.Dispatch(1, func() {}) || d.Go(func() {}) {
+		t.Error("job accepted after Close")
+	}
+}
+
+func TestGoRunsOutsideQueues(t *testing.T) {
+	d := New(1)
+
+	// задачи без чата не ждут друг друга
+	first := make(chan struct{})
+	done := make(chan struct{})
+	d.Go(func() {
+		<-first
+		close(done)
+	})
+	d.Go(func() { close(first) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("jobs outside queues block each other")
+	}
+	d.Close()
+
+	if stats := d.Stats(); stats.Processed != 2 {
+		t.Errorf("processed = %d, want 2", stats.Processed)
+	}
+}
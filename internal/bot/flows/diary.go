@@ -0,0 +1,263 @@
+package flows
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/conversation"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/bristol"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// DiaryFlowName - имя диалога добавления записи в дневник
+const DiaryFlowName = "diary_log"
+
+const (
+	diaryStepBristol    = "bristol"
+	diaryStepTime       = "time"
+	diaryStepEarlier    = "earlier"
+	diaryStepCustomTime = "custom_time"
+	diaryStepUrgency    = "urgency"
+	diaryStepPain       = "pain"
+	diaryStepNote       = "note"
+
+	diaryKeyOccurredAt = "occurred_at"
+)
+
+// maxNoteLength - ограничение длины заметки в символах
+const maxNoteLength = 1000
+
+// urgencyLevels - число вариантов срочности позыва, сохраняется индекс варианта
+const urgencyLevels = 3
+
+// UrgencyLabel возвращает подпись срочности позыва на языке locale
+func UrgencyLabel(locale string, urgency int) string {
+	return i18n.T(locale, "diary.urgency."+strconv.Itoa(urgency), nil)
+}
+
+// NewDiaryFlow описывает диалог /log: тип стула, время, срочность, боль и заметка
+func NewDiaryFlow(diary storage.DiaryRepository, settings storage.SettingsRepository) *conversation.Flow {
+	return &conversation.Flow{
+		Name:  DiaryFlowName,
+		Start: diaryStepBristol,
+		Steps: []conversation.Step{
+			{
+				Name:   diaryStepBristol,
+				Expect: conversation.InputCallback,
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					var rows [][]models.InlineKeyboardButton
+					for _, t := range bristol.Types {
+						rows = append(rows, []models.InlineKeyboardButton{
+							s.Button(bristol.Title(s.Locale, t.Number), diaryStepBristol+":"+strconv.Itoa(t.Number)),
+						})
+					}
+					return s.Send(ctx, s.T("diary.ask_bristol", nil), conversation.Keyboard(rows...))
+				},
+				Handle: func(_ context.Context, s *conversation.Session, in conversation.Input) (string, error) {
+					number, ok := callbackInt(in, diaryStepBristol)
+					if !ok || !bristol.Valid(number) {
+						return "", conversation.ErrInvalidInput
+					}
+					s.Set(diaryStepBristol, strconv.Itoa(number))
+					return diaryStepTime, nil
+				},
+			},
+			{
+				Name:   diaryStepTime,
+				Expect: conversation.InputCallback,
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					return s.Send(ctx, s.T("diary.ask_time", nil), conversation.Keyboard(
+						[]models.InlineKeyboardButton{s.Button(s.T("diary.time.now", nil), diaryStepTime+":now")},
+						[]models.InlineKeyboardButton{s.Button(s.T("diary.time.earlier", nil), diaryStepTime+":earlier")},
+						[]models.InlineKeyboardButton{s.Button(s.T("diary.time.custom", nil), diaryStepTime+":custom")},
+					))
+				},
+				Handle: func(_ context.Context, s *conversation.Session, in conversation.Input) (string, error) {
+					switch in.Callback {
+					case diaryStepTime + ":now":
+						s.Set(diaryKeyOccurredAt, strconv.FormatInt(time.Now().Unix(), 10))
+						return diaryStepUrgency, nil
+					case diaryStepTime + ":earlier":
+						return diaryStepEarlier, nil
+					case diaryStepTime + ":custom":
+						return diaryStepCustomTime, nil
+					default:
+						return "", conversation.ErrInvalidInput
+					}
+				},
+			},
+			{
+				Name:   diaryStepEarlier,
+				Expect: conversation.InputCallback,
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					var row []models.InlineKeyboardButton
+					for _, hours := range []int{1, 2, 4, 6} {
+						row = append(row, s.Button(s.N("diary.hours_ago", hours, nil), diaryStepEarlier+":"+strconv.Itoa(hours)))
+					}
+					return s.Send(ctx, s.T("diary.ask_earlier", nil), conversation.Keyboard(row))
+				},
+				Handle: func(_ context.Context, s *conversation.Session, in conversation.Input) (string, error) {
+					hours, ok := callbackInt(in, diaryStepEarlier)
+					if !ok || hours < 1 || hours > 24 {
+						return "", conversation.ErrInvalidInput
+					}
+					occurredAt := time.Now().Add(-time.Duration(hours) * time.Hour)
+					s.Set(diaryKeyOccurredAt, strconv.FormatInt(occurredAt.Unix(), 10))
+					return diaryStepUrgency, nil
+				},
+			},
+			{
+				Name:   diaryStepCustomTime,
+				Expect: conversation.InputText,
+				Retry:  "diary.retry_time",
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					return s.Send(ctx, s.T("diary.ask_custom_time", nil), nil)
+				},
+				Handle: func(ctx context.Context, s *conversation.Session, in conversation.Input) (string, error) {
+					userSettings, err := settings.Get(ctx, s.UserID)
+					if err != nil {
+						return "", err
+					}
+
+					occurredAt, err := ParseLocalTime(in.Text, time.Now(), userSettings.Location())
+					if err != nil {
+						return "", conversation.ErrInvalidInput
+					}
+					s.Set(diaryKeyOccurredAt, strconv.FormatInt(occurredAt.Unix(), 10))
+					return diaryStepUrgency, nil
+				},
+			},
+			{
+				Name:   diaryStepUrgency,
+				Expect: conversation.InputCallback,
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					var rows [][]models.InlineKeyboardButton
+					for value := 0; value < urgencyLevels; value++ {
+						rows = append(rows, []models.InlineKeyboardButton{
+							s.Button(UrgencyLabel(s.Locale, value), diaryStepUrgency+":"+strconv.Itoa(value)),
+						})
+					}
+					return s.Send(ctx, s.T("diary.ask_urgency", nil), conversation.Keyboard(rows...))
+				},
+				Handle: func(_ context.Context, s *conversation.Session, in conversation.Input) (string, error) {
+					value, ok := callbackInt(in, diaryStepUrgency)
+					if !ok || value < 0 || value >= urgencyLevels {
+						return "", conversation.ErrInvalidInput
+					}
+					s.Set(diaryStepUrgency, strconv.Itoa(value))
+					return diaryStepPain, nil
+				},
+			},
+			{
+				Name:   diaryStepPain,
+				Expect: conversation.InputCallback,
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					return s.Send(ctx, s.T("diary.ask_pain", nil), scaleKeyboard(s, diaryStepPain, 10, 1))
+				},
+				Handle: scaleStep(diaryStepPain, 10, diaryStepNote),
+			},
+			{
+				Name:   diaryStepNote,
+				Expect: conversation.InputText | conversation.InputCallback,
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					return s.Send(ctx, s.T("diary.ask_note", nil), conversation.Keyboard(
+						[]models.InlineKeyboardButton{s.Button(s.T("diary.skip", nil), diaryStepNote+":skip")},
+					))
+				},
+				Handle: func(_ context.Context, s *conversation.Session, in conversation.Input) (string, error) {
+					if in.Kind == conversation.InputCallback {
+						if in.Callback != diaryStepNote+":skip" {
+							return "", conversation.ErrInvalidInput
+						}
+						return conversation.End, nil
+					}
+
+					note := []rune(strings.TrimSpace(in.Text))
+					if len(note) > maxNoteLength {
+						note = note[:maxNoteLength]
+					}
+					s.Set(diaryStepNote, string(note))
+					return conversation.End, nil
+				},
+			},
+		},
+		OnComplete: func(ctx context.Context, s *conversation.Session) error {
+			occurredAt, _ := strconv.ParseInt(s.Get(diaryKeyOccurredAt), 10, 64)
+
+			entry := &storage.DiaryEntry{
+				UserID:      s.UserID,
+				OccurredAt:  time.Unix(occurredAt, 0).UTC(),
+				BristolType: intValue(s, diaryStepBristol),
+				Urgency:     intValue(s, diaryStepUrgency),
+				Pain:        intValue(s, diaryStepPain),
+				Note:        s.Get(diaryStepNote),
+			}
+			if err := diary.Create(ctx, entry); err != nil {
+				return fmt.Errorf("failed to save diary entry: %w", err)
+			}
+
+			userSettings, err := settings.Get(ctx, s.UserID)
+			if err != nil {
+				return fmt.Errorf("failed to load settings: %w", err)
+			}
+
+			return s.Send(ctx, s.T("diary.added", nil)+"\n\n"+FormatDiaryEntry(s.Locale, entry, userSettings.Location()), nil)
+		},
+	}
+}
+
+// FormatDiaryEntry описывает запись дневника в HTML-разметке Telegram на языке locale
+func FormatDiaryEntry(locale string, entry *storage.DiaryEntry, loc *time.Location) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "🕒 %s\n", entry.OccurredAt.In(loc).Format("02.01.2006 15:04"))
+	fmt.Fprintf(&sb, "%s\n", bristol.Title(locale, entry.BristolType))
+	if entry.Urgency >= 0 && entry.Urgency < urgencyLevels {
+		sb.WriteString(i18n.T(locale, "diary.urgency", i18n.Params{"value": UrgencyLabel(locale, entry.Urgency)}) + "\n")
+	}
+	sb.WriteString(i18n.T(locale, "diary.pain", i18n.Params{"pain": entry.Pain}) + "\n")
+	if entry.Note != "" {
+		fmt.Fprintf(&sb, "📝 %s\n", html.EscapeString(entry.Note))
+	}
+
+	return sb.String()
+}
+
+// ParseLocalTime разбирает "ЧЧ:ММ" (сегодня) или "ДД.ММ ЧЧ:ММ" в часовом поясе loc.
+// Время из будущего не принимается.
+func ParseLocalTime(text string, now time.Time, loc *time.Location) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	localNow := now.In(loc)
+
+	var parsed time.Time
+	if t, err := time.ParseInLocation("15:04", text, loc); err == nil {
+		parsed = time.Date(localNow.Year(), localNow.Month(), localNow.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+	} else if t, err := time.ParseInLocation("02.01 15:04", text, loc); err == nil {
+		parsed = time.Date(localNow.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+	} else {
+		return time.Time{}, fmt.Errorf("unsupported time format: %q", text)
+	}
+
+	if parsed.After(now) {
+		return time.Time{}, fmt.Errorf("time %s is in the future", parsed)
+	}
+	return parsed, nil
+}
+
+func callbackInt(in conversation.Input, prefix string) (int, bool) {
+	raw, ok := strings.CutPrefix(in.Callback, prefix+":")
+	if !ok {
+		return 0, false
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
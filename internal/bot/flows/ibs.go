@@ -0,0 +1,238 @@
+package flows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/conversation"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/ibs"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// IBSFlowName - имя диалога опросника IBS-SSS
+const IBSFlowName = "ibs_sss"
+
+const (
+	ibsStepHasPain        = "has_pain"
+	ibsStepPainSeverity   = "pain_severity"
+	ibsStepPainDays       = "pain_days"
+	ibsStepHasDistension  = "has_distension"
+	ibsStepDistension     = "distension"
+	ibsStepDissatisfied   = "dissatisfaction"
+	ibsStepLifeInterfered = "interference"
+)
+
+// NewIBSFlow описывает опросник IBS-SSS: пять пунктов, по 0-100 баллов каждый
+func NewIBSFlow(repo storage.QuestionnaireRepository) *conversation.Flow {
+	return &conversation.Flow{
+		Name:  IBSFlowName,
+		Start: ibsStepHasPain,
+		Steps: []conversation.Step{
+			{
+				Name:   ibsStepHasPain,
+				Expect: conversation.InputCallback,
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					return s.Send(ctx, s.T("ibs.ask_has_pain", nil), yesNoKeyboard(s, ibsStepHasPain))
+				},
+				Handle: yesNoStep(ibsStepHasPain, ibsStepPainSeverity, ibsStepHasDistension, func(s *conversation.Session) {
+					s.Set(ibsStepPainSeverity, "0")
+					s.Set(ibsStepPainDays, "0")
+				}),
+			},
+			{
+				Name:   ibsStepPainSeverity,
+				Expect: conversation.InputCallback,
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					return s.Send(ctx, s.T("ibs.ask_pain_severity", nil)+s.T("ibs.scale_hint", nil), scaleKeyboard(s, ibsStepPainSeverity, 100, 10))
+				},
+				Handle: scaleStep(ibsStepPainSeverity, 100, ibsStepPainDays),
+			},
+			{
+				Name:   ibsStepPainDays,
+				Expect: conversation.InputCallback,
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					return s.Send(ctx, s.T("ibs.ask_pain_days", nil), scaleKeyboard(s, ibsStepPainDays, 10, 1))
+				},
+				Handle: scaleStep(ibsStepPainDays, 10, ibsStepHasDistension),
+			},
+			{
+				Name:   ibsStepHasDistension,
+				Expect: conversation.InputCallback,
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					return s.Send(ctx, s.T("ibs.ask_has_distension", nil), yesNoKeyboard(s, ibsStepHasDistension))
+				},
+				Handle: yesNoStep(ibsStepHasDistension, ibsStepDistension, ibsStepDissatisfied, func(s *conversation.Session) {
+					s.Set(ibsStepDistension, "0")
+				}),
+			},
+			{
+				Name:   ibsStepDistension,
+				Expect: conversation.InputCallback,
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					return s.Send(ctx, s.T("ibs.ask_distension", nil)+s.T("ibs.scale_hint", nil), scaleKeyboard(s, ibsStepDistension, 100, 10))
+				},
+				Handle: scaleStep(ibsStepDistension, 100, ibsStepDissatisfied),
+			},
+			{
+				Name:   ibsStepDissatisfied,
+				Expect: conversation.InputCallback,
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					return s.Send(ctx, s.T("ibs.ask_dissatisfaction", nil)+s.T("ibs.scale_hint", nil), scaleKeyboard(s, ibsStepDissatisfied, 100, 10))
+				},
+				Handle: scaleStep(ibsStepDissatisfied, 100, ibsStepLifeInterfered),
+			},
+			{
+				Name:   ibsStepLifeInterfered,
+				Expect: conversation.InputCallback,
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					return s.Send(ctx, s.T("ibs.ask_interference", nil)+s.T("ibs.scale_hint", nil), scaleKeyboard(s, ibsStepLifeInterfered, 100, 10))
+				},
+				Handle: scaleStep(ibsStepLifeInterfered, 100, conversation.End),
+			},
+		},
+		OnComplete: func(ctx context.Context, s *conversation.Session) error {
+			answers := ibs.Answers{
+				PainSeverity:         intValue(s, ibsStepPainSeverity),
+				PainDays:             intValue(s, ibsStepPainDays),
+				DistensionSeverity:   intValue(s, ibsStepDistension),
+				BowelDissatisfaction: intValue(s, ibsStepDissatisfied),
+				LifeInterference:     intValue(s, ibsStepLifeInterfered),
+			}
+
+			result, err := ibs.Score(answers)
+			if err != nil {
+				return fmt.Errorf("failed to score questionnaire: %w", err)
+			}
+			result.CreatedAt = time.Now()
+
+			previous, err := repo.Recent(ctx, s.UserID, ibs.Kind, 1)
+			if err != nil {
+				return fmt.Errorf("failed to load previous results: %w", err)
+			}
+
+			rawAnswers, err := json.Marshal(result.Answers)
+			if err != nil {
+				return fmt.Errorf("failed to encode answers: %w", err)
+			}
+
+			err = repo.Create(ctx, &storage.QuestionnaireResult{
+				UserID:    s.UserID,
+				Kind:      ibs.Kind,
+				Score:     result.Score,
+				Band:      string(result.Band),
+				Answers:   string(rawAnswers),
+				CreatedAt: result.CreatedAt,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to save questionnaire result: %w", err)
+			}
+
+			return s.Send(ctx, formatIBSResult(s, result, previous), nil)
+		},
+	}
+}
+
+func formatIBSResult(s *conversation.Session, result ibs.Result, previous []storage.QuestionnaireResult) string {
+	var sb strings.Builder
+
+	sb.WriteString(s.T("ibs.result.title", nil) + "\n\n")
+	sb.WriteString(s.T("ibs.result.score", i18n.Params{"score": result.Score, "max": ibs.MaxScore}) + "\n")
+	sb.WriteString(s.T("ibs.result.band", i18n.Params{"band": result.Band.Label(s.Locale)}) + "\n\n")
+
+	a := result.Answers
+	sb.WriteString(s.T("ibs.result.pain", i18n.Params{"value": a.PainSeverity}) + "\n")
+	sb.WriteString(s.N("ibs.result.pain_days", a.PainDays*10, i18n.Params{"days": a.PainDays}) + "\n")
+	sb.WriteString(s.T("ibs.result.distension", i18n.Params{"value": a.DistensionSeverity}) + "\n")
+	sb.WriteString(s.T("ibs.result.dissatisfaction", i18n.Params{"value": a.BowelDissatisfaction}) + "\n")
+	sb.WriteString(s.T("ibs.result.interference", i18n.Params{"value": a.LifeInterference}) + "\n")
+
+	if len(previous) > 0 {
+		prev := previous[0]
+		diff := result.Score - prev.Score
+		trend := s.T("ibs.trend.same", nil)
+		switch {
+		case diff < 0:
+			trend = s.N("ibs.trend.better", -diff, nil)
+		case diff > 0:
+			trend = s.N("ibs.trend.worse", diff, nil)
+		}
+		sb.WriteString("\n" + s.T("ibs.result.previous", i18n.Params{
+			"date":  prev.CreatedAt.Format("02.01.2006"),
+			"score": prev.Score,
+			"trend": trend,
+		}) + "\n")
+	}
+
+	sb.WriteString("\n" + s.T("ibs.result.note", nil))
+	return sb.String()
+}
+
+func yesNoKeyboard(s *conversation.Session, step string) *models.InlineKeyboardMarkup {
+	return conversation.Keyboard([]models.InlineKeyboardButton{
+		s.Button(s.T("ibs.yes", nil), step+":yes"),
+		s.Button(s.T("ibs.no", nil), step+":no"),
+	})
+}
+
+// yesNoStep переходит на yesStep при ответе "да", иначе вызывает onNo и переходит на noStep
+func yesNoStep(step, yesStep, noStep string, onNo func(s *conversation.Session)) func(context.Context, *conversation.Session, conversation.Input) (string, error) {
+	return func(_ context.Context, s *conversation.Session, in conversation.Input) (string, error) {
+		switch in.Callback {
+		case step + ":yes":
+			return yesStep, nil
+		case step + ":no":
+			onNo(s)
+			return noStep, nil
+		default:
+			return "", conversation.ErrInvalidInput
+		}
+	}
+}
+
+// scaleKeyboard рисует шкалу-"слайдер" от 0 до max с шагом step, по 6 кнопок в ряду
+func scaleKeyboard(s *conversation.Session, stepName string, max, step int) *models.InlineKeyboardMarkup {
+	var rows [][]models.InlineKeyboardButton
+	var row []models.InlineKeyboardButton
+
+	for value := 0; value <= max; value += step {
+		row = append(row, s.Button(strconv.Itoa(value), stepName+":"+strconv.Itoa(value)))
+		if len(row) == 6 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	return conversation.Keyboard(rows...)
+}
+
+// scaleStep сохраняет выбранное значение шкалы и переходит на next
+func scaleStep(stepName string, max int, next string) func(context.Context, *conversation.Session, conversation.Input) (string, error) {
+	return func(_ context.Context, s *conversation.Session, in conversation.Input) (string, error) {
+		raw, ok := strings.CutPrefix(in.Callback, stepName+":")
+		if !ok {
+			return "", conversation.ErrInvalidInput
+		}
+
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 || value > max {
+			return "", conversation.ErrInvalidInput
+		}
+
+		s.Set(stepName, strconv.Itoa(value))
+		return next, nil
+	}
+}
+
+func intValue(s *conversation.Session, key string) int {
+	value, _ := strconv.Atoi(s.Get(key))
+	return value
+}
@@ -0,0 +1,203 @@
+package flows
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/conversation"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/lifestyle"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// Имена диалогов записи еды, сна и стресса
+const (
+	MealFlowName   = "meal_log"
+	SleepFlowName  = "sleep_log"
+	StressFlowName = "stress_log"
+)
+
+const (
+	mealStep    = "meal"
+	mealKeyTags = "tags"
+	sleepStep   = "sleep"
+	stressStep  = "stress"
+
+	maxSleepHours = 12
+)
+
+// NewMealFlow описывает диалог /food: метки кнопками и/или свободное описание еды
+func NewMealFlow(repo storage.LifestyleRepository) *conversation.Flow {
+	return &conversation.Flow{
+		Name:  MealFlowName,
+		Start: mealStep,
+		Steps: []conversation.Step{
+			{
+				Name:   mealStep,
+				Expect: conversation.InputCallback | conversation.InputText,
+				Retry:  "meal.retry",
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					selected := mealTags(s)
+
+					var rows [][]models.InlineKeyboardButton
+					var row []models.InlineKeyboardButton
+					for _, t := range lifestyle.Tags {
+						label := t.Label(s.Locale)
+						if slices.Contains(selected, t.Key) {
+							label = "✅ " + label
+						}
+						row = append(row, s.Button(label, mealStep+":tag:"+t.Key))
+						if len(row) == 2 {
+							rows = append(rows, row)
+							row = nil
+						}
+					}
+					if len(row) > 0 {
+						rows = append(rows, row)
+					}
+					rows = append(rows, []models.InlineKeyboardButton{s.Button(s.T("meal.done", nil), mealStep+":done")})
+
+					return s.Send(ctx, s.T("meal.ask", nil), conversation.Keyboard(rows...))
+				},
+				Handle: func(_ context.Context, s *conversation.Session, in conversation.Input) (string, error) {
+					if in.Kind == conversation.InputText {
+						note := []rune(in.Text)
+						if len(note) > maxNoteLength {
+							note = note[:maxNoteLength]
+						}
+						s.Set(mealStep, string(note))
+						setMealTags(s, lifestyle.MergeTags(mealTags(s), lifestyle.DetectTags(in.Text)))
+						return conversation.End, nil
+					}
+
+					if in.Callback == mealStep+":done" {
+						if len(mealTags(s)) == 0 {
+							return "", conversation.ErrInvalidInput
+						}
+						return conversation.End, nil
+					}
+
+					key, ok := strings.CutPrefix(in.Callback, mealStep+":tag:")
+					if !ok || !lifestyle.ValidTag(key) {
+						return "", conversation.ErrInvalidInput
+					}
+
+					tags := mealTags(s)
+					if i := slices.Index(tags, key); i >= 0 {
+						tags = slices.Delete(tags, i, i+1)
+					} else {
+						tags = lifestyle.MergeTags(tags, []string{key})
+					}
+					setMealTags(s, tags)
+					return mealStep, nil
+				},
+			},
+		},
+		OnComplete: func(ctx context.Context, s *conversation.Session) error {
+			entry := &storage.LifestyleEntry{
+				UserID:     s.UserID,
+				Kind:       storage.LifestyleMeal,
+				OccurredAt: time.Now().UTC(),
+				Tags:       mealTags(s),
+				Note:       s.Get(mealStep),
+			}
+			if err := repo.Create(ctx, entry); err != nil {
+				return fmt.Errorf("failed to save meal: %w", err)
+			}
+
+			var sb strings.Builder
+			sb.WriteString(s.T("meal.saved", nil) + "\n\n")
+			if len(entry.Tags) > 0 {
+				labels := make([]string, len(entry.Tags))
+				for i, tag := range entry.Tags {
+					labels[i] = lifestyle.TagLabel(s.Locale, tag)
+				}
+				fmt.Fprintf(&sb, "%s\n", strings.Join(labels, ", "))
+			}
+			if entry.Note != "" {
+				fmt.Fprintf(&sb, "📝 %s\n", html.EscapeString(entry.Note))
+			}
+			sb.WriteString("\n" + s.T("meal.triggers_hint", nil))
+			return s.Send(ctx, sb.String(), nil)
+		},
+	}
+}
+
+// NewSleepFlow описывает диалог /sleep: сколько часов длился сон
+func NewSleepFlow(repo storage.LifestyleRepository) *conversation.Flow {
+	return &conversation.Flow{
+		Name:  SleepFlowName,
+		Start: sleepStep,
+		Steps: []conversation.Step{
+			{
+				Name:   sleepStep,
+				Expect: conversation.InputCallback,
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					return s.Send(ctx, s.T("sleep.ask", nil), scaleKeyboard(s, sleepStep, maxSleepHours, 1))
+				},
+				Handle: scaleStep(sleepStep, maxSleepHours, conversation.End),
+			},
+		},
+		OnComplete: func(ctx context.Context, s *conversation.Session) error {
+			hours := intValue(s, sleepStep)
+			err := repo.Create(ctx, &storage.LifestyleEntry{
+				UserID:     s.UserID,
+				Kind:       storage.LifestyleSleep,
+				OccurredAt: time.Now().UTC(),
+				Value:      hours,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to save sleep: %w", err)
+			}
+			return s.Send(ctx, s.N("sleep.saved", hours, nil), nil)
+		},
+	}
+}
+
+// NewStressFlow описывает диалог /stress: уровень стресса за день от 0 до 10
+func NewStressFlow(repo storage.LifestyleRepository) *conversation.Flow {
+	return &conversation.Flow{
+		Name:  StressFlowName,
+		Start: stressStep,
+		Steps: []conversation.Step{
+			{
+				Name:   stressStep,
+				Expect: conversation.InputCallback,
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					return s.Send(ctx, s.T("stress.ask", nil), scaleKeyboard(s, stressStep, 10, 1))
+				},
+				Handle: scaleStep(stressStep, 10, conversation.End),
+			},
+		},
+		OnComplete: func(ctx context.Context, s *conversation.Session) error {
+			level := intValue(s, stressStep)
+			err := repo.Create(ctx, &storage.LifestyleEntry{
+				UserID:     s.UserID,
+				Kind:       storage.LifestyleStress,
+				OccurredAt: time.Now().UTC(),
+				Value:      level,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to save stress level: %w", err)
+			}
+			return s.Send(ctx, s.T("stress.saved", i18n.Params{"level": level}), nil)
+		},
+	}
+}
+
+func mealTags(s *conversation.Session) []string {
+	raw := s.Get(mealKeyTags)
+	if raw == "" {
+		return nil
+	}
+	return strings.Split(raw, ",")
+}
+
+func setMealTags(s *conversation.Session, tags []string) {
+	s.Set(mealKeyTags, strings.Join(tags, ","))
+}
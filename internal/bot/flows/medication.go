@@ -0,0 +1,98 @@
+package flows
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/conversation"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/medications"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// MedicationFlowName - имя диалога начала курса лекарства
+const MedicationFlowName = "medication_start"
+
+const (
+	medicationStepName     = "name"
+	medicationStepCategory = "category"
+)
+
+// NewMedicationFlow описывает начало курса: название препарата и его группа
+func NewMedicationFlow(repo storage.MedicationRepository) *conversation.Flow {
+	return &conversation.Flow{
+		Name:  MedicationFlowName,
+		Start: medicationStepName,
+		Steps: []conversation.Step{
+			{
+				Name:   medicationStepName,
+				Expect: conversation.InputText,
+				Retry:  "medication.retry_name",
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					return s.Send(ctx, s.T("medication.ask_name", nil), nil)
+				},
+				Handle: func(_ context.Context, s *conversation.Session, in conversation.Input) (string, error) {
+					name := medications.NormalizeName(in.Text)
+					if name == "" {
+						return "", conversation.ErrInvalidInput
+					}
+					s.Set(medicationStepName, name)
+					s.Set(medicationStepCategory, medications.Detect(name))
+					return medicationStepCategory, nil
+				},
+			},
+			{
+				Name:   medicationStepCategory,
+				Expect: conversation.InputCallback,
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					detected := s.Get(medicationStepCategory)
+
+					var rows [][]models.InlineKeyboardButton
+					for _, c := range medications.Categories {
+						label := c.Label(s.Locale)
+						if c.Key == detected {
+							label = "✅ " + label
+						}
+						rows = append(rows, []models.InlineKeyboardButton{
+							s.Button(label, medicationStepCategory+":"+c.Key),
+						})
+					}
+
+					text := s.T("medication.ask_category", i18n.Params{"name": html.EscapeString(s.Get(medicationStepName))})
+					if detected != medications.OtherCategory {
+						text += "\n\n" + s.T("medication.detected", i18n.Params{"category": medications.Label(s.Locale, detected)})
+					}
+					return s.Send(ctx, text, conversation.Keyboard(rows...))
+				},
+				Handle: func(_ context.Context, s *conversation.Session, in conversation.Input) (string, error) {
+					key, ok := strings.CutPrefix(in.Callback, medicationStepCategory+":")
+					if !ok || medications.Find(key) == nil {
+						return "", conversation.ErrInvalidInput
+					}
+					s.Set(medicationStepCategory, key)
+					return conversation.End, nil
+				},
+			},
+		},
+		OnComplete: func(ctx context.Context, s *conversation.Session) error {
+			medication := &storage.Medication{
+				UserID:    s.UserID,
+				Name:      s.Get(medicationStepName),
+				Category:  s.Get(medicationStepCategory),
+				StartedAt: time.Now().UTC(),
+			}
+			if err := repo.Create(ctx, medication); err != nil {
+				return fmt.Errorf("failed to save medication: %w", err)
+			}
+
+			return s.Send(ctx, s.T("medication.started", i18n.Params{
+				"name":     html.EscapeString(medication.Name),
+				"category": medications.Label(s.Locale, medication.Category),
+			}), nil)
+		},
+	}
+}
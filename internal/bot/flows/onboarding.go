@@ -0,0 +1,182 @@
+package flows
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/conversation"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/profile"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// OnboardingFlowName - имя диалога анкеты, запускается при первом /start и из /profile
+const OnboardingFlowName = "onboarding"
+
+const (
+	onboardingStepAge        = "age"
+	onboardingStepSex        = "sex"
+	onboardingStepConditions = "conditions"
+	onboardingStepDiet       = "diet"
+	onboardingStepDetail     = "detail"
+)
+
+// NewOnboardingFlow описывает анкету: возраст, пол, известные диагнозы, питание
+// и желаемая подробность ответов
+func NewOnboardingFlow(repo storage.ProfileRepository) *conversation.Flow {
+	return &conversation.Flow{
+		Name:  OnboardingFlowName,
+		Start: onboardingStepAge,
+		Steps: []conversation.Step{
+			optionStep(onboardingStepAge, onboardingStepSex, profile.AgeRanges,
+				"onboarding.ask_age"),
+			optionStep(onboardingStepSex, onboardingStepConditions, profile.Sexes,
+				"onboarding.ask_sex"),
+			{
+				Name:   onboardingStepConditions,
+				Expect: conversation.InputCallback,
+				Retry:  "onboarding.retry_conditions",
+				Enter: func(ctx context.Context, s *conversation.Session) error {
+					selected := onboardingConditions(s)
+
+					var rows [][]models.InlineKeyboardButton
+					for _, o := range profile.Conditions {
+						label := o.Label(s.Locale)
+						if slices.Contains(selected, o.Key) {
+							label = "✅ " + label
+						}
+						rows = append(rows, []models.InlineKeyboardButton{
+							s.Button(label, onboardingStepConditions+":"+o.Key),
+						})
+					}
+					done := s.Button(s.T("onboarding.no_conditions", nil), onboardingStepConditions+":"+profile.ConditionNone)
+					if len(selected) > 0 {
+						done = s.Button(s.T("onboarding.done", nil), onboardingStepConditions+":done")
+					}
+					rows = append(rows, []models.InlineKeyboardButton{
+						done,
+						s.Button(s.T("profile.option.skip", nil), onboardingStepConditions+":"+profile.Skip),
+					})
+
+					return s.Send(ctx, s.T("onboarding.ask_conditions", nil), conversation.Keyboard(rows...))
+				},
+				Handle: func(_ context.Context, s *conversation.Session, in conversation.Input) (string, error) {
+					key, ok := strings.CutPrefix(in.Callback, onboardingStepConditions+":")
+					if !ok {
+						return "", conversation.ErrInvalidInput
+					}
+
+					switch key {
+					case "done":
+						return onboardingStepDiet, nil
+					case profile.ConditionNone:
+						setOnboardingConditions(s, []string{profile.ConditionNone})
+						return onboardingStepDiet, nil
+					case profile.Skip:
+						setOnboardingConditions(s, nil)
+						return onboardingStepDiet, nil
+					}
+
+					if profile.Find(profile.Conditions, key) == nil {
+						return "", conversation.ErrInvalidInput
+					}
+					selected := onboardingConditions(s)
+					if i := slices.Index(selected, key); i >= 0 {
+						selected = slices.Delete(selected, i, i+1)
+					} else {
+						selected = append(selected, key)
+					}
+					setOnboardingConditions(s, selected)
+					return onboardingStepConditions, nil
+				},
+			},
+			optionStep(onboardingStepDiet, onboardingStepDetail, append(slices.Clone(profile.Diets), profile.Option{Key: profile.Skip}),
+				"onboarding.ask_diet"),
+			optionStep(onboardingStepDetail, conversation.End, profile.DetailLevels,
+				"onboarding.ask_detail"),
+		},
+		OnComplete: func(ctx context.Context, s *conversation.Session) error {
+			p, err := repo.Get(ctx, s.UserID)
+			if err != nil {
+				p = &storage.Profile{UserID: s.UserID}
+			}
+			p.AgeRange = s.Get(onboardingStepAge)
+			p.Sex = s.Get(onboardingStepSex)
+			p.Conditions = onboardingConditions(s)
+			p.Diet = s.Get(onboardingStepDiet)
+			p.DetailLevel = s.Get(onboardingStepDetail)
+			if err := repo.Save(ctx, p); err != nil {
+				return fmt.Errorf("failed to save profile: %w", err)
+			}
+
+			return s.Send(ctx, s.T("onboarding.saved", nil)+"\n\n"+FormatProfile(s.Locale, p)+
+				"\n"+s.T("onboarding.next", nil), nil)
+		},
+	}
+}
+
+// FormatProfile описывает анкету для пользователя на языке locale
+func FormatProfile(locale string, p *storage.Profile) string {
+	fields := []struct{ key, value string }{
+		{"profile.field.age", profile.Label(locale, profile.AgeRanges, p.AgeRange)},
+		{"profile.field.sex", profile.Label(locale, profile.Sexes, p.Sex)},
+		{"profile.field.conditions", profile.ConditionsLabel(locale, p.Conditions)},
+		{"profile.field.diet", profile.Label(locale, profile.Diets, p.Diet)},
+		{"profile.field.detail", profile.Label(locale, profile.DetailLevels, p.DetailLevel)},
+	}
+
+	var sb strings.Builder
+	for _, f := range fields {
+		sb.WriteString(i18n.T(locale, f.key, i18n.Params{"value": f.value}) + "\n")
+	}
+	return sb.String()
+}
+
+// optionStep - шаг анкеты с выбором одного варианта кнопками, question - ключ вопроса в каталоге
+func optionStep(name, next string, options []profile.Option, question string) conversation.Step {
+	return conversation.Step{
+		Name:   name,
+		Expect: conversation.InputCallback,
+		Enter: func(ctx context.Context, s *conversation.Session) error {
+			var rows [][]models.InlineKeyboardButton
+			var row []models.InlineKeyboardButton
+			for _, o := range options {
+				row = append(row, s.Button(o.Label(s.Locale), name+":"+o.Key))
+				if len(row) == 2 {
+					rows = append(rows, row)
+					row = nil
+				}
+			}
+			if len(row) > 0 {
+				rows = append(rows, row)
+			}
+			return s.Send(ctx, s.T(question, nil), conversation.Keyboard(rows...))
+		},
+		Handle: func(_ context.Context, s *conversation.Session, in conversation.Input) (string, error) {
+			key, ok := strings.CutPrefix(in.Callback, name+":")
+			if !ok || profile.Find(options, key) == nil {
+				return "", conversation.ErrInvalidInput
+			}
+			if key == profile.Skip {
+				key = ""
+			}
+			s.Set(name, key)
+			return next, nil
+		},
+	}
+}
+
+func onboardingConditions(s *conversation.Session) []string {
+	raw := s.Get(onboardingStepConditions)
+	if raw == "" {
+		return nil
+	}
+	return strings.Split(raw, ",")
+}
+
+func setOnboardingConditions(s *conversation.Session, conditions []string) {
+	s.Set(onboardingStepConditions, strings.Join(conditions, ","))
+}
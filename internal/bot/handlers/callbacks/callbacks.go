@@ -6,12 +6,35 @@ import (
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/conversation"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/flows"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
 )
 
-type CallbackHandlers struct{}
+// Кнопки меню /start: menu:<version>:<action>
+const (
+	MenuPrefix  = "menu:"
+	MenuVersion = 1
+	MenuTest    = "test"
+	MenuAnalyze = "analyze"
+)
+
+type CallbackHandlers struct {
+	conversations *conversation.Manager
+	analyses      storage.AnalysisRepository
+	diary         storage.DiaryRepository
+	settings      storage.SettingsRepository
+}
 
-func NewCallbackHandlers() *CallbackHandlers {
-	return &CallbackHandlers{}
+func NewCallbackHandlers(conversations *conversation.Manager, store storage.Storage) *CallbackHandlers {
+	return &CallbackHandlers{
+		conversations: conversations,
+		analyses:      store.Analyses(),
+		diary:         store.Diary(),
+		settings:      store.Settings(),
+	}
 }
 
 func (h *CallbackHandlers) HandleTestCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
@@ -19,7 +42,7 @@ func (h *CallbackHandlers) HandleTestCallback(ctx context.Context, b *bot.Bot, u
 
 	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
 		CallbackQueryID: update.CallbackQuery.ID,
-		Text:            "✅ Используйте команду /test для полного тестирования!",
+		Text:            i18n.T(i18n.FromContext(ctx), "callback.test", nil),
 		ShowAlert:       true,
 	})
 	if err != nil {
@@ -27,78 +50,46 @@ func (h *CallbackHandlers) HandleTestCallback(ctx context.Context, b *bot.Bot, u
 	}
 }
 
-func (h *CallbackHandlers) HandleHelpCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
-	log.Printf("❓ Help callback received from @%s", update.CallbackQuery.From.Username)
-
-	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
-		CallbackQueryID: update.CallbackQuery.ID,
-		Text:            "📋 Команды: /start /help /test /analyze",
-		ShowAlert:       false,
-	})
-	if err != nil {
-		log.Printf("Error answering help callback: %v", err)
-	}
-}
-
 func (h *CallbackHandlers) HandleAnalyzeCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
 	log.Printf("📊 Analyze callback received from @%s", update.CallbackQuery.From.Username)
 
 	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
 		CallbackQueryID: update.CallbackQuery.ID,
-		Text:            "📊 Выберите ваше состояние ниже",
-		ShowAlert:       false,
 	})
 	if err != nil {
 		log.Printf("Error answering analyze callback: %v", err)
 	}
-}
-
-func (h *CallbackHandlers) HandleAnalyzeGoodCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
-	log.Printf("🟢 Analyze good callback from @%s", update.CallbackQuery.From.Username)
 
-	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
-		CallbackQueryID: update.CallbackQuery.ID,
-		Text:            "🟢 Отлично! Продолжайте в том же духе! Пейте воду, ешьте клетчатку, двигайтесь!",
-		ShowAlert:       true,
-	})
-	if err != nil {
-		log.Printf("Error answering good callback: %v", err)
+	if update.CallbackQuery.Message.Message == nil {
+		return
 	}
-}
 
-func (h *CallbackHandlers) HandleAnalyzeNormalCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
-	log.Printf("🟡 Analyze normal callback from @%s", update.CallbackQuery.From.Username)
-
-	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
-		CallbackQueryID: update.CallbackQuery.ID,
-		Text:            "🟡 Нормально! Советы: больше пробиотиков, овощей, прогулки после еды",
-		ShowAlert:       true,
-	})
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	err = h.conversations.Start(ctx, b, chatID, update.CallbackQuery.From.ID, flows.IBSFlowName, nil)
 	if err != nil {
-		log.Printf("Error answering normal callback: %v", err)
+		log.Printf("Error starting IBS questionnaire: %v", err)
 	}
 }
 
-func (h *CallbackHandlers) HandleAnalyzeBadCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
-	log.Printf("🔴 Analyze bad callback from @%s", update.CallbackQuery.From.Username)
-
-	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
-		CallbackQueryID: update.CallbackQuery.ID,
-		Text:            "🔴 При серьезных симптомах обратитесь к врачу! Пейте воду, избегайте острого",
-		ShowAlert:       true,
-	})
-	if err != nil {
-		log.Printf("Error answering bad callback: %v", err)
+// handleMenu передает нажатие кнопки меню /start ее обработчику
+func (h *CallbackHandlers) handleMenu(ctx context.Context, b *bot.Bot, update *models.Update, payload callback.Payload) {
+	switch payload.Action {
+	case MenuTest:
+		h.HandleTestCallback(ctx, b, update)
+	case MenuAnalyze:
+		h.HandleAnalyzeCallback(ctx, b, update)
+	default:
+		_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+		if err != nil {
+			log.Printf("Error answering menu callback: %v", err)
+		}
 	}
 }
 
-func (h *CallbackHandlers) GetCallbackPatterns() map[string]func(context.Context, *bot.Bot, *models.Update) {
-	return map[string]func(context.Context, *bot.Bot, *models.Update){
-		"test":           h.HandleTestCallback,
-		"help":           h.HandleHelpCallback,
-		"analyze":        h.HandleAnalyzeCallback,
-		"analyze_good":   h.HandleAnalyzeGoodCallback,
-		"analyze_normal": h.HandleAnalyzeNormalCallback,
-		"analyze_bad":    h.HandleAnalyzeBadCallback,
+// GetCallbackRoutes возвращает обработчики кнопок с подписанными данными
+func (h *CallbackHandlers) GetCallbackRoutes() []callback.Route {
+	return []callback.Route{
+		{Prefix: MenuPrefix, Version: MenuVersion, Handle: h.handleMenu},
+		{Prefix: DiarySavePrefix, Version: DiarySaveVersion, Handle: h.HandleDiarySaveCallback},
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,119 @@
+package callbacks
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/flows"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// Кнопка "Сохранить в дневник" под анализом фото: diary_save:<version>:save:<analysisID>
+const (
+	DiarySavePrefix  = "diary_save:"
+	DiarySaveVersion = 1
+	DiarySaveAction  = "save"
+)
+
+// HandleDiarySaveCallback сохраняет результат анализа фото в дневник одним нажатием
+func (h *CallbackHandlers) HandleDiarySaveCallback(ctx context.Context, b *bot.Bot, update *models.Update, payload callback.Payload) {
+	query := update.CallbackQuery
+	log.Printf("📓 Diary save callback from @%s", query.From.Username)
+
+	locale := i18n.FromContext(ctx)
+	answer := func(key string) {
+		_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+			CallbackQueryID: query.ID,
+			Text:            i18n.T(locale, key, nil),
+		})
+		if err != nil {
+			log.Printf("Error answering diary save callback: %v", err)
+		}
+	}
+
+	analysisID := payload.Int64(0)
+	if payload.Action != DiarySaveAction || analysisID <= 0 {
+		answer("diary_save.invalid")
+		return
+	}
+
+	analysis, err := h.analyses.Get(ctx, query.From.ID, analysisID)
+	if errors.Is(err, storage.ErrNotFound) {
+		answer("diary_save.not_found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading analysis %d: %v", analysisID, err)
+		answer("diary_save.failed")
+		return
+	}
+
+	// Повторное нажатие или повтор обновления от Telegram не должны дублировать запись.
+	// Обновления одного чата обрабатываются по очереди, поэтому проверки достаточно.
+	saved, err := h.diary.Count(ctx, query.From.ID, storage.DiaryFilter{AnalysisID: analysis.ID})
+	if err != nil {
+		log.Printf("Error checking diary entries for analysis %d: %v", analysisID, err)
+		answer("diary_save.failed")
+		return
+	}
+	if saved > 0 {
+		answer("diary_save.already")
+		removeDiarySaveButton(ctx, b, query)
+		return
+	}
+
+	entry := &storage.DiaryEntry{
+		UserID:      query.From.ID,
+		OccurredAt:  analysis.CreatedAt,
+		BristolType: analysis.BristolType,
+		Note:        analysis.UserContext,
+		AnalysisID:  analysis.ID,
+	}
+	if err := h.diary.Create(ctx, entry); err != nil {
+		log.Printf("Error saving diary entry from analysis %d: %v", analysisID, err)
+		answer("diary_save.failed")
+		return
+	}
+
+	answer("diary_save.saved")
+
+	if query.Message.Message == nil {
+		return
+	}
+	removeDiarySaveButton(ctx, b, query)
+
+	userSettings, err := h.settings.Get(ctx, query.From.ID)
+	if err != nil {
+		log.Printf("Error loading settings: %v", err)
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    query.Message.Message.Chat.ID,
+		Text:      i18n.T(locale, "diary_save.added", nil) + "\n\n" + flows.FormatDiaryEntry(locale, entry, userSettings.Location()),
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		log.Printf("Error sending diary entry: %v", err)
+	}
+}
+
+// removeDiarySaveButton убирает кнопку сохранения из сообщения с анализом
+func removeDiarySaveButton(ctx context.Context, b *bot.Bot, query *models.CallbackQuery) {
+	if query.Message.Message == nil {
+		return
+	}
+
+	_, err := b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:    query.Message.Message.Chat.ID,
+		MessageID: query.Message.Message.ID,
+	})
+	if err != nil {
+		log.Printf("Error removing diary save button: %v", err)
+	}
+}
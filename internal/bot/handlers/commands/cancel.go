@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"context"
+	"log"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/conversation"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+)
+
+type CancelHandler struct {
+	BaseHandler
+	conversations *conversation.Manager
+}
+
+func NewCancelHandler(conversations *conversation.Manager) *CancelHandler {
+	return &CancelHandler{
+		BaseHandler:   NewBaseHandler(Command{Name: "cancel", Scope: ScopeAll}),
+		conversations: conversations,
+	}
+}
+
+func (h *CancelHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	log.Printf("🚫 Cancel command received from %s", update.Message.From.Username)
+
+	cancelled, err := h.conversations.Cancel(ctx, update.Message.Chat.ID, update.Message.From.ID)
+	if err != nil {
+		log.Printf("Error cancelling conversation: %v", err)
+		sendErrorMessage(ctx, b, update.Message.Chat.ID, "error.cancel")
+		return
+	}
+
+	key := "cancel.nothing"
+	if cancelled {
+		key = "cancel.done"
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   i18n.T(i18n.FromContext(ctx), key, nil),
+	})
+	if err != nil {
+		log.Printf("Error sending cancel message: %v", err)
+	}
+}
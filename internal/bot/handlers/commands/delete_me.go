@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"context"
+	"log"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+const (
+	deleteMeCallbackPrefix  = "delete_me:"
+	deleteMeCallbackVersion = 1
+)
+
+// UserDataHolder - часть бота, которая держит данные пользователя в памяти, а не в базе:
+// активные диалоги, фото в ожидании анализа
+type UserDataHolder interface {
+	ForgetUser(ctx context.Context, userID int64) error
+}
+
+type DeleteMeHandler struct {
+	BaseHandler
+	store   storage.Storage
+	codec   *callback.Codec
+	holders []UserDataHolder
+}
+
+func NewDeleteMeHandler(store storage.Storage, codec *callback.Codec, holders ...UserDataHolder) *DeleteMeHandler {
+	return &DeleteMeHandler{
+		BaseHandler: NewBaseHandler(Command{Name: "delete_me"}),
+		store:       store,
+		codec:       codec,
+		holders:     holders,
+	}
+}
+
+func (h *DeleteMeHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	log.Printf("🗑 Delete me command received from %s", update.Message.From.Username)
+
+	locale := i18n.FromContext(ctx)
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    update.Message.Chat.ID,
+		Text:      i18n.T(locale, "delete_me.confirm", nil),
+		ParseMode: models.ParseModeHTML,
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{
+				{{Text: i18n.T(locale, "delete_me.button_confirm", nil), CallbackData: h.codec.Encode(deleteMeCallbackPrefix, deleteMeCallbackVersion, "confirm")}},
+				{{Text: i18n.T(locale, "delete_me.button_cancel", nil), CallbackData: h.codec.Encode(deleteMeCallbackPrefix, deleteMeCallbackVersion, "cancel")}},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("Error sending delete confirmation: %v", err)
+	}
+}
+
+// HandleConfirm удаляет данные пользователя после подтверждения
+func (h *DeleteMeHandler) HandleConfirm(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.CallbackQuery
+
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: query.ID})
+	if err != nil {
+		log.Printf("Error answering delete callback: %v", err)
+	}
+	if query.Message.Message == nil {
+		return
+	}
+	msg := query.Message.Message
+
+	// сначала данные в памяти: незавершенный диалог не должен ничего записать после удаления
+	for _, holder := range h.holders {
+		if err := holder.ForgetUser(ctx, query.From.ID); err != nil {
+			log.Printf("Error forgetting user data before erasure: %v", err)
+		}
+	}
+
+	erasure, err := h.store.DeleteUserData(ctx, query.From.ID)
+	if err != nil {
+		log.Printf("Error deleting user data: %v", err)
+		sendErrorMessage(ctx, b, msg.Chat.ID, "error.delete")
+		return
+	}
+
+	log.Printf("🗑 User %d data erased", query.From.ID)
+
+	h.editMessage(ctx, b, msg, i18n.T(i18n.FromContext(ctx), "delete_me.done", i18n.Params{
+		"diary":          erasure.DiaryEntries,
+		"analyses":       erasure.Analyses,
+		"questionnaires": erasure.Questionnaires,
+		"lifestyle":      erasure.Lifestyle,
+		"medications":    erasure.Medications,
+	}))
+}
+
+func (h *DeleteMeHandler) HandleCancel(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.CallbackQuery
+
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: query.ID})
+	if err != nil {
+		log.Printf("Error answering delete callback: %v", err)
+	}
+	if query.Message.Message == nil {
+		return
+	}
+
+	h.editMessage(ctx, b, query.Message.Message, i18n.T(i18n.FromContext(ctx), "delete_me.cancelled", nil))
+}
+
+// HandleCallback передает нажатие кнопки подтверждения ее обработчику
+func (h *DeleteMeHandler) HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update, payload callback.Payload) {
+	switch payload.Action {
+	case "confirm":
+		h.HandleConfirm(ctx, b, update)
+	case "cancel":
+		h.HandleCancel(ctx, b, update)
+	default:
+		answer(ctx, b, update.CallbackQuery.ID, "")
+	}
+}
+
+func (h *DeleteMeHandler) GetCallbackRoutes() []callback.Route {
+	return []callback.Route{
+		{Prefix: deleteMeCallbackPrefix, Version: deleteMeCallbackVersion, Handle: h.HandleCallback},
+	}
+}
+
+func (h *DeleteMeHandler) editMessage(ctx context.Context, b *bot.Bot, msg *models.Message, text string) {
+	_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    msg.Chat.ID,
+		MessageID: msg.ID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		log.Printf("Error editing delete message: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/export"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+type ExportHandler struct {
+	BaseHandler
+	store    storage.Storage
+	secret   string
+	tokenTTL time.Duration
+}
+
+// NewExportHandler создает обработчик /export. Если secret пустой,
+// токен для HTTP-выгрузки не выдается.
+func NewExportHandler(store storage.Storage, secret string, tokenTTL time.Duration) *ExportHandler {
+	return &ExportHandler{
+		BaseHandler: NewBaseHandler(Command{Name: "export"}),
+		store:       store,
+		secret:      secret,
+		tokenTTL:    tokenTTL,
+	}
+}
+
+func (h *ExportHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	userID := update.Message.From.ID
+	log.Printf("📦 Export command received from %s", update.Message.From.Username)
+
+	_, err := b.SendChatAction(ctx, &bot.SendChatActionParams{
+		ChatID: chatID,
+		Action: models.ChatActionUploadDocument,
+	})
+	if err != nil {
+		log.Printf("Error sending chat action: %v", err)
+	}
+
+	data, err := export.Collect(ctx, h.store, userID)
+	if err != nil {
+		log.Printf("Error collecting export: %v", err)
+		sendErrorMessage(ctx, b, chatID, "error.export_collect")
+		return
+	}
+
+	csvData, err := export.CSV(data)
+	if err != nil {
+		log.Printf("Error building CSV export: %v", err)
+		sendErrorMessage(ctx, b, chatID, "error.export_csv")
+		return
+	}
+
+	jsonData, err := export.JSON(data)
+	if err != nil {
+		log.Printf("Error building JSON export: %v", err)
+		sendErrorMessage(ctx, b, chatID, "error.export_json")
+		return
+	}
+
+	name := "stool-guru-export-" + data.ExportedAt.Format("2006-01-02")
+	locale := i18n.FromContext(ctx)
+	summary := i18n.T(locale, "export.summary", i18n.Params{
+		"diary":          i18n.N(locale, "export.diary_entries", len(data.DiaryEntries), nil),
+		"analyses":       i18n.N(locale, "export.analyses", len(data.Analyses), nil),
+		"questionnaires": i18n.N(locale, "export.questionnaires", len(data.Questionnaires), nil),
+	})
+
+	files := []struct {
+		filename string
+		content  []byte
+		caption  string
+	}{
+		{filename: name + ".csv", content: csvData, caption: summary},
+		{filename: name + ".json", content: jsonData},
+	}
+
+	for _, f := range files {
+		_, err := b.SendDocument(ctx, &bot.SendDocumentParams{
+			ChatID:   chatID,
+			Document: &models.InputFileUpload{Filename: f.filename, Data: bytes.NewReader(f.content)},
+			Caption:  f.caption,
+		})
+		if err != nil {
+			log.Printf("Error sending export %s: %v", f.filename, err)
+			sendErrorMessage(ctx, b, chatID, "error.export_send")
+			return
+		}
+	}
+
+	if h.secret == "" {
+		return
+	}
+
+	expiresAt := time.Now().Add(h.tokenTTL)
+	text := i18n.T(locale, "export.token", i18n.Params{
+		"token":   export.NewToken(h.secret, userID, expiresAt),
+		"expires": expiresAt.UTC().Format("02.01.2006 15:04"),
+	})
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		log.Printf("Error sending export token: %v", err)
+	}
+}
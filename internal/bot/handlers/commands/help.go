@@ -3,45 +3,104 @@ package commands
 import (
 	"context"
 	"log"
+	"strings"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
 )
 
+const (
+	helpCallbackPrefix  = "help:"
+	helpCallbackVersion = 1
+)
+
+// HelpHandler собирает справку из зарегистрированных команд
 type HelpHandler struct {
 	BaseHandler
+	registry *Registry
 }
 
-func NewHelpHandler() *HelpHandler {
+func NewHelpHandler(registry *Registry) *HelpHandler {
 	return &HelpHandler{
-		BaseHandler: NewBaseHandler("/help", bot.MatchTypeExact),
+		BaseHandler: NewBaseHandler(Command{Name: "help", Scope: ScopeAll}),
+		registry:    registry,
 	}
 }
 
 func (h *HelpHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
 	log.Printf("❓ Help command received from %s", update.Message.From.Username)
 
-	text := `🆘 <b>Как пользоваться ботом:</b>
+	locale := i18n.FromContext(ctx)
+	userID, chatType := update.Message.From.ID, update.Message.Chat.Type
 
-📸 <b>Отправьте фото</b> - бот автоматически проанализирует изображение
+	text := h.Text(locale, userID, chatType)
+	if fields := strings.Fields(update.Message.Text); len(fields) > 1 {
+		text = h.commandText(locale, fields[1], userID, chatType)
+	}
 
-📋 <b>Команды:</b>
-/start • Главное меню
-/help • Эта справка  
-/test • Тест функций
-/analyze • Ручной анализ
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    update.Message.Chat.ID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		log.Printf("Error sending help message: %v", err)
+		sendErrorMessage(ctx, b, update.Message.Chat.ID, "error.help")
+	}
+}
 
-🔬 Бот использует современный ИИ для анализа и дает рекомендации как опытный врач!
+// HandleCallback присылает справку по кнопке из /start
+func (h *HelpHandler) HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update, _ callback.Payload) {
+	query := update.CallbackQuery
+	log.Printf("❓ Help callback received from @%s", query.From.Username)
+	answer(ctx, b, query.ID, "")
 
-💡 <b>Совет:</b> Для лучшего анализа убедитесь, что фото четкое и хорошо освещенное.`
+	msg := query.Message.Message
+	if msg == nil {
+		return
+	}
 
 	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID:    update.Message.Chat.ID,
-		Text:      text,
+		ChatID:    msg.Chat.ID,
+		Text:      h.Text(i18n.FromContext(ctx), query.From.ID, msg.Chat.Type),
 		ParseMode: models.ParseModeHTML,
 	})
 	if err != nil {
 		log.Printf("Error sending help message: %v", err)
-		sendErrorMessage(ctx, b, update.Message.Chat.ID, "Ошибка отправки справки")
 	}
-}
\ No newline at end of file
+}
+
+func (h *HelpHandler) GetCallbackRoutes() []callback.Route {
+	return []callback.Route{
+		{Prefix: helpCallbackPrefix, Version: helpCallbackVersion, Handle: h.HandleCallback},
+	}
+}
+
+// Text - общая справка: команды, доступные пользователю в этом чате
+func (h *HelpHandler) Text(locale string, userID int64, chatType models.ChatType) string {
+	return i18n.T(locale, "help.header", nil) + "\n" +
+		h.registry.Summary(locale, userID, chatType) + "\n\n" +
+		i18n.T(locale, "help.footer", nil)
+}
+
+// commandText - подробная справка по одной команде для /help <команда>
+func (h *HelpHandler) commandText(locale, name string, userID int64, chatType models.ChatType) string {
+	command, ok := h.registry.Find(name)
+	if !ok || !h.registry.Allowed(command, userID, chatType) {
+		return i18n.T(locale, "help.unknown_command", nil)
+	}
+
+	text := i18n.T(locale, "help.command_title", i18n.Params{
+		"name":        command.Name,
+		"description": command.Description(locale),
+	}) + "\n\n" + i18n.T(locale, "help.details."+command.Name, nil)
+
+	if len(command.Aliases) > 0 {
+		text += "\n\n" + i18n.T(locale, "help.aliases", i18n.Params{
+			"aliases": "/" + strings.Join(command.Aliases, ", /"),
+		})
+	}
+	return text
+}
@@ -7,32 +7,32 @@ import (
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
 )
 
+// CommandHandler - обработчик команды, который сам описывает, как его вызывать
 type CommandHandler interface {
-	GetPattern() string
-	GetMatchType() bot.MatchType
+	Command() Command
 	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
 }
 
 type BaseHandler struct {
-	pattern   string
-	matchType bot.MatchType
+	command Command
 }
 
-func NewBaseHandler(pattern string, matchType bot.MatchType) BaseHandler {
+func NewBaseHandler(command Command) BaseHandler {
 	return BaseHandler{
-		pattern:   pattern,
-		matchType: matchType,
+		command: command,
 	}
 }
 
-func (h *BaseHandler) GetPattern() string {
-	return h.pattern
+func (h *BaseHandler) Command() Command {
+	return h.command
 }
 
-func (h *BaseHandler) GetMatchType() bot.MatchType {
-	return h.matchType
+// GetPattern возвращает команду в виде "/name" для логов
+func (h *BaseHandler) GetPattern() string {
+	return "/" + h.command.Name
 }
 
 
@@ -46,8 +46,10 @@ func escapeMarkdownV2(text string) string {
 	return result
 }
 
-func sendErrorMessage(ctx context.Context, b *bot.Bot, chatID int64, errorText string) {
-	text := "❌ " + errorText + "\n\nПопробуйте еще раз или обратитесь в поддержку."
+// sendErrorMessage сообщает об ошибке на языке пользователя, key - ключ описания в каталоге
+func sendErrorMessage(ctx context.Context, b *bot.Bot, chatID int64, key string) {
+	locale := i18n.FromContext(ctx)
+	text := i18n.T(locale, "error.template", i18n.Params{"text": i18n.T(locale, key, nil)})
 	
 	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID: chatID,
@@ -56,4 +58,30 @@ func sendErrorMessage(ctx context.Context, b *bot.Bot, chatID int64, errorText s
 	if err != nil {
 		// log.Printf("Error sending error message: %v", err)
 	}
-}
\ No newline at end of file
+}
+
+// MaxMessageLength - лимит Telegram на длину текста сообщения
+const MaxMessageLength = 4096
+
+// SplitMessage делит текст на части не длиннее limit символов, стараясь резать по строкам
+func SplitMessage(text string, limit int) []string {
+	runes := []rune(text)
+	var chunks []string
+
+	for len(runes) > limit {
+		cut := limit
+		for i := limit - 1; i > limit/2; i-- {
+			if runes[i] == '\n' {
+				cut = i + 1
+				break
+			}
+		}
+		chunks = append(chunks, string(runes[:cut]))
+		runes = runes[cut:]
+	}
+
+	if len(runes) > 0 {
+		chunks = append(chunks, string(runes))
+	}
+	return chunks
+}
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"context"
+	"log"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/conversation"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/flows"
+)
+
+// LifestyleHandler запускает диалог записи еды, сна или стресса
+type LifestyleHandler struct {
+	BaseHandler
+	conversations *conversation.Manager
+	flowName      string
+}
+
+func NewFoodHandler(conversations *conversation.Manager) *LifestyleHandler {
+	return newLifestyleHandler("food", flows.MealFlowName, conversations)
+}
+
+func NewSleepHandler(conversations *conversation.Manager) *LifestyleHandler {
+	return newLifestyleHandler("sleep", flows.SleepFlowName, conversations)
+}
+
+func NewStressHandler(conversations *conversation.Manager) *LifestyleHandler {
+	return newLifestyleHandler("stress", flows.StressFlowName, conversations)
+}
+
+func newLifestyleHandler(name, flowName string, conversations *conversation.Manager) *LifestyleHandler {
+	return &LifestyleHandler{
+		BaseHandler:   NewBaseHandler(Command{Name: name}),
+		conversations: conversations,
+		flowName:      flowName,
+	}
+}
+
+func (h *LifestyleHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	log.Printf("🍽 %s command received from %s", h.GetPattern(), update.Message.From.Username)
+
+	err := h.conversations.Start(ctx, b, update.Message.Chat.ID, update.Message.From.ID, h.flowName, nil)
+	if err != nil {
+		log.Printf("Error starting %s conversation: %v", h.flowName, err)
+		sendErrorMessage(ctx, b, update.Message.Chat.ID, "error.lifestyle_start")
+	}
+}
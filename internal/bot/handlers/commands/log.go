@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"context"
+	"log"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/conversation"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/flows"
+)
+
+type LogHandler struct {
+	BaseHandler
+	conversations *conversation.Manager
+}
+
+func NewLogHandler(conversations *conversation.Manager) *LogHandler {
+	return &LogHandler{
+		BaseHandler:   NewBaseHandler(Command{Name: "log", Aliases: []string{"diary"}}),
+		conversations: conversations,
+	}
+}
+
+func (h *LogHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	log.Printf("📓 Log command received from %s", update.Message.From.Username)
+
+	err := h.conversations.Start(ctx, b, update.Message.Chat.ID, update.Message.From.ID, flows.DiaryFlowName, nil)
+	if err != nil {
+		log.Printf("Error starting diary conversation: %v", err)
+		sendErrorMessage(ctx, b, update.Message.Chat.ID, "error.diary_start")
+	}
+}
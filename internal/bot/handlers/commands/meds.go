@@ -0,0 +1,228 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/conversation"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/flows"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/medications"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// finishedCoursesShown - сколько завершенных курсов показывать в /meds
+const finishedCoursesShown = 3
+
+// MedsHandler показывает курсы лекарств, отмечает прием и завершает курсы
+type MedsHandler struct {
+	BaseHandler
+	medications   storage.MedicationRepository
+	settings      storage.SettingsRepository
+	conversations *conversation.Manager
+	codec         *callback.Codec
+}
+
+func NewMedsHandler(store storage.Storage, conversations *conversation.Manager, codec *callback.Codec) *MedsHandler {
+	return &MedsHandler{
+		BaseHandler:   NewBaseHandler(Command{Name: "meds", Aliases: []string{"medications"}}),
+		medications:   store.Medications(),
+		settings:      store.Settings(),
+		conversations: conversations,
+		codec:         codec,
+	}
+}
+
+func (h *MedsHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	log.Printf("💊 Meds command received from %s", update.Message.From.Username)
+
+	text, keyboard, err := h.menu(ctx, update.Message.From.ID)
+	if err != nil {
+		log.Printf("Error building medications menu: %v", err)
+		sendErrorMessage(ctx, b, update.Message.Chat.ID, "error.meds_load")
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        text,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		log.Printf("Error sending medications menu: %v", err)
+	}
+}
+
+// HandleCallback обрабатывает кнопки /meds: начать курс, отметить прием, завершить курс
+func (h *MedsHandler) HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update, payload callback.Payload) {
+	locale := i18n.FromContext(ctx)
+	query := update.CallbackQuery
+	msg := query.Message.Message
+	if msg == nil {
+		answer(ctx, b, query.ID, "")
+		return
+	}
+
+	userID := query.From.ID
+	id := payload.Int64(0)
+
+	var notice string
+	var err error
+	switch payload.Action {
+	case "new":
+		answer(ctx, b, query.ID, "")
+		if err := h.conversations.Start(ctx, b, msg.Chat.ID, userID, flows.MedicationFlowName, nil); err != nil {
+			log.Printf("Error starting medication conversation: %v", err)
+			sendErrorMessage(ctx, b, msg.Chat.ID, "error.meds_start")
+		}
+		return
+	case "take":
+		notice, err = h.take(ctx, userID, id)
+	case "stop":
+		err = h.medications.Stop(ctx, userID, id, time.Now())
+		notice = i18n.T(locale, "meds.stopped", nil)
+	default:
+		answer(ctx, b, query.ID, "")
+		return
+	}
+
+	if err != nil {
+		log.Printf("Error updating medication: %v", err)
+		answer(ctx, b, query.ID, i18n.T(locale, "common.save_failed", nil))
+		return
+	}
+	answer(ctx, b, query.ID, notice)
+
+	text, keyboard, err := h.menu(ctx, userID)
+	if err != nil {
+		log.Printf("Error building medications menu: %v", err)
+		return
+	}
+	edit(ctx, b, msg, text, keyboard)
+}
+
+func (h *MedsHandler) GetCallbackRoutes() []callback.Route {
+	return []callback.Route{
+		{Prefix: medications.CallbackPrefix, Version: medications.CallbackVersion, Handle: h.HandleCallback},
+	}
+}
+
+// data собирает подписанные данные кнопки /meds
+func (h *MedsHandler) data(action string, args ...any) string {
+	return h.codec.Encode(medications.CallbackPrefix, medications.CallbackVersion, action, args...)
+}
+
+// take отмечает прием препарата за сегодняшний день по местному времени
+func (h *MedsHandler) take(ctx context.Context, userID, id int64) (string, error) {
+	medication, err := h.medications.Get(ctx, userID, id)
+	if err != nil {
+		return "", err
+	}
+	locale := i18n.FromContext(ctx)
+	if !medication.StoppedAt.IsZero() {
+		return i18n.T(locale, "meds.already_stopped", nil), nil
+	}
+
+	userSettings, err := h.settings.Get(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	created, err := h.medications.LogIntake(ctx, &storage.MedicationIntake{
+		UserID:       userID,
+		MedicationID: id,
+		Day:          time.Now().In(userSettings.Location()).Format(time.DateOnly),
+	})
+	if err != nil {
+		return "", err
+	}
+	if !created {
+		return i18n.T(locale, "meds.already_taken", nil), nil
+	}
+	return i18n.T(locale, "meds.taken", nil), nil
+}
+
+func (h *MedsHandler) menu(ctx context.Context, userID int64) (string, *models.InlineKeyboardMarkup, error) {
+	locale := i18n.FromContext(ctx)
+	userSettings, err := h.settings.Get(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+	loc := userSettings.Location()
+
+	list, err := h.medications.List(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now().In(loc)
+	today := now.Format(time.DateOnly)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	intakes, err := h.medications.Intakes(ctx, userID, startOfDay, startOfDay.AddDate(0, 0, 1))
+	if err != nil {
+		return "", nil, err
+	}
+	takenToday := make(map[int64]bool)
+	for _, intake := range intakes {
+		if intake.Day == today {
+			takenToday[intake.MedicationID] = true
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(locale, "meds.title", nil) + "\n\n")
+
+	var rows [][]models.InlineKeyboardButton
+	var finished []storage.Medication
+	active := 0
+	for _, m := range list {
+		if !m.StoppedAt.IsZero() {
+			finished = append(finished, m)
+			continue
+		}
+		active++
+
+		status := i18n.T(locale, "meds.status.pending", nil)
+		if takenToday[m.ID] {
+			status = i18n.T(locale, "meds.status.taken", nil)
+		}
+		sb.WriteString(i18n.T(locale, "meds.course", i18n.Params{
+			"name":     html.EscapeString(m.Name),
+			"category": medications.Label(locale, m.Category),
+			"from":     m.StartedAt.In(loc).Format("02.01"),
+			"status":   status,
+		}) + "\n")
+
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: i18n.T(locale, "meds.button.take", i18n.Params{"name": m.Name}), CallbackData: h.data("take", m.ID)},
+			{Text: i18n.T(locale, "meds.button.stop", nil), CallbackData: h.data("stop", m.ID)},
+		})
+	}
+	if active == 0 {
+		sb.WriteString(i18n.T(locale, "meds.none_active", nil) + "\n")
+	}
+
+	if len(finished) > 0 {
+		sb.WriteString("\n" + i18n.T(locale, "meds.finished", nil) + "\n")
+		for i, m := range finished {
+			if i == finishedCoursesShown {
+				break
+			}
+			fmt.Fprintf(&sb, "• %s: %s – %s\n", html.EscapeString(m.Name),
+				m.StartedAt.In(loc).Format("02.01"), m.StoppedAt.In(loc).Format("02.01"))
+		}
+	}
+
+	sb.WriteString("\n" + i18n.T(locale, "meds.hint", nil))
+	rows = append(rows, []models.InlineKeyboardButton{{Text: i18n.T(locale, "meds.button.new", nil), CallbackData: h.data("new")}})
+
+	return sb.String(), &models.InlineKeyboardMarkup{InlineKeyboard: rows}, nil
+}
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"context"
+	"log"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+const (
+	photosCallbackPrefix  = "photos:"
+	photosCallbackVersion = 1
+)
+
+// PhotosHandler включает и выключает хранение оригиналов фото
+type PhotosHandler struct {
+	BaseHandler
+	settings storage.SettingsRepository
+	codec    *callback.Codec
+	// encrypted - шифрует ли хранилище фото, от этого зависит текст о хранении
+	encrypted bool
+}
+
+func NewPhotosHandler(store storage.Storage, codec *callback.Codec) *PhotosHandler {
+	return &PhotosHandler{
+		BaseHandler: NewBaseHandler(Command{Name: "photos"}),
+		settings:    store.Settings(),
+		codec:       codec,
+		encrypted:   store.Encrypted(),
+	}
+}
+
+func (h *PhotosHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	log.Printf("📷 Photos command received from %s", update.Message.From.Username)
+	locale := i18n.FromContext(ctx)
+
+	userSettings, err := h.settings.Get(ctx, update.Message.From.ID)
+	if err != nil {
+		log.Printf("Error loading settings: %v", err)
+		sendErrorMessage(ctx, b, update.Message.Chat.ID, "error.settings_load")
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        h.text(locale, userSettings.KeepImages),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: h.keyboard(locale, userSettings.KeepImages),
+	})
+	if err != nil {
+		log.Printf("Error sending photos settings: %v", err)
+	}
+}
+
+// HandleCallback сохраняет выбор пользователя
+func (h *PhotosHandler) HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update, payload callback.Payload) {
+	locale := i18n.FromContext(ctx)
+	query := update.CallbackQuery
+
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: query.ID})
+	if err != nil {
+		log.Printf("Error answering photos callback: %v", err)
+	}
+	if query.Message.Message == nil {
+		return
+	}
+
+	var keep bool
+	switch payload.Action {
+	case "on":
+		keep = true
+	case "off":
+		keep = false
+	default:
+		return
+	}
+
+	userSettings, err := h.settings.Get(ctx, query.From.ID)
+	if err != nil {
+		log.Printf("Error loading settings: %v", err)
+		return
+	}
+	userSettings.KeepImages = keep
+	if err := h.settings.Save(ctx, userSettings); err != nil {
+		log.Printf("Error saving settings: %v", err)
+		sendErrorMessage(ctx, b, query.Message.Message.Chat.ID, "error.settings_save")
+		return
+	}
+
+	_, err = b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      query.Message.Message.Chat.ID,
+		MessageID:   query.Message.Message.ID,
+		Text:        h.text(locale, keep),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: h.keyboard(locale, keep),
+	})
+	if err != nil {
+		log.Printf("Error editing photos settings: %v", err)
+	}
+}
+
+func (h *PhotosHandler) GetCallbackRoutes() []callback.Route {
+	return []callback.Route{
+		{Prefix: photosCallbackPrefix, Version: photosCallbackVersion, Handle: h.HandleCallback},
+	}
+}
+
+func (h *PhotosHandler) text(locale string, keep bool) string {
+	status := i18n.T(locale, "photos.status.off", nil)
+	if keep {
+		status = i18n.T(locale, "photos.status.on", nil)
+	}
+	storageKey := "photos.storage_plain"
+	if h.encrypted {
+		storageKey = "photos.storage_encrypted"
+	}
+
+	return i18n.T(locale, "photos.title", nil) + "\n\n" +
+		i18n.T(locale, "photos.status", i18n.Params{"status": status}) + "\n\n" +
+		i18n.T(locale, storageKey, nil)
+}
+
+func (h *PhotosHandler) keyboard(locale string, keep bool) *models.InlineKeyboardMarkup {
+	button := models.InlineKeyboardButton{Text: i18n.T(locale, "photos.button.on", nil), CallbackData: h.codec.Encode(photosCallbackPrefix, photosCallbackVersion, "on")}
+	if keep {
+		button = models.InlineKeyboardButton{Text: i18n.T(locale, "photos.button.off", nil), CallbackData: h.codec.Encode(photosCallbackPrefix, photosCallbackVersion, "off")}
+	}
+	return &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{{button}}}
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/conversation"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/flows"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/profile"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// ProfileHandler показывает анкету и позволяет заполнить ее заново
+type ProfileHandler struct {
+	BaseHandler
+	profiles      storage.ProfileRepository
+	conversations *conversation.Manager
+	codec         *callback.Codec
+}
+
+func NewProfileHandler(store storage.Storage, conversations *conversation.Manager, codec *callback.Codec) *ProfileHandler {
+	return &ProfileHandler{
+		BaseHandler:   NewBaseHandler(Command{Name: "profile"}),
+		profiles:      store.Profiles(),
+		conversations: conversations,
+		codec:         codec,
+	}
+}
+
+func (h *ProfileHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	log.Printf("📋 Profile command received from %s", update.Message.From.Username)
+
+	p, err := h.profiles.Get(ctx, update.Message.From.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		if err := h.conversations.Start(ctx, b, update.Message.Chat.ID, update.Message.From.ID, flows.OnboardingFlowName, nil); err != nil {
+			log.Printf("Error starting onboarding conversation: %v", err)
+			sendErrorMessage(ctx, b, update.Message.Chat.ID, "error.profile_start")
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading profile: %v", err)
+		sendErrorMessage(ctx, b, update.Message.Chat.ID, "error.profile_load")
+		return
+	}
+
+	locale := i18n.FromContext(ctx)
+	text := i18n.T(locale, "profile.title", nil) + "\n\n" + flows.FormatProfile(locale, p) +
+		"\n" + i18n.T(locale, "profile.hint", nil)
+	keyboard := &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{{Text: i18n.T(locale, "profile.button.edit", nil), CallbackData: h.codec.Encode(profile.CallbackPrefix, profile.CallbackVersion, "edit")}},
+		},
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        text,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		log.Printf("Error sending profile: %v", err)
+	}
+}
+
+// HandleCallback запускает анкету заново по кнопке из /profile
+func (h *ProfileHandler) HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update, payload callback.Payload) {
+	query := update.CallbackQuery
+	answer(ctx, b, query.ID, "")
+
+	msg := query.Message.Message
+	if msg == nil || payload.Action != "edit" {
+		return
+	}
+	if err := h.conversations.Start(ctx, b, msg.Chat.ID, query.From.ID, flows.OnboardingFlowName, nil); err != nil {
+		log.Printf("Error starting onboarding conversation: %v", err)
+		sendErrorMessage(ctx, b, msg.Chat.ID, "error.profile_start")
+	}
+}
+
+func (h *ProfileHandler) GetCallbackRoutes() []callback.Route {
+	return []callback.Route{
+		{Prefix: profile.CallbackPrefix, Version: profile.CallbackVersion, Handle: h.HandleCallback},
+	}
+}
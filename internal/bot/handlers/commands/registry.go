@@ -0,0 +1,259 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+)
+
+// Visibility - кому доступна команда
+type Visibility int
+
+const (
+	VisibilityUser Visibility = iota
+	// VisibilityAdmin - только пользователям из ADMIN_IDS, остальным команда не видна и не отвечает
+	VisibilityAdmin
+)
+
+// Scope - в каких чатах работает команда и показывается в меню Telegram
+type Scope int
+
+const (
+	// ScopePrivate - только в личном чате с ботом: данные о здоровье не должны попадать в группы
+	ScopePrivate Scope = iota
+	ScopeAll
+	ScopeGroups
+)
+
+// Command - описание команды. Текст для меню Telegram на каждом языке
+// берется из каталога по ключу command.<name>.
+type Command struct {
+	Name       string
+	Aliases    []string
+	Visibility Visibility
+	Scope      Scope
+}
+
+// commandName - ограничения Telegram на имя команды
+var commandName = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// DescriptionKey возвращает ключ описания команды в каталоге
+func (c Command) DescriptionKey() string {
+	return "command." + c.Name
+}
+
+// Description возвращает описание команды на языке locale
+func (c Command) Description(locale string) string {
+	return i18n.T(locale, c.DescriptionKey(), nil)
+}
+
+// Names возвращает имя команды и ее синонимы
+func (c Command) Names() []string {
+	return append([]string{c.Name}, c.Aliases...)
+}
+
+// Matches сообщает, что текст - вызов команды: "/name", "/name@bot" или "/name аргументы"
+func (c Command) Matches(text string) bool {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return false
+	}
+	name, _, _ := strings.Cut(strings.TrimPrefix(fields[0], "/"), "@")
+	for _, n := range c.Names() {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
+
+// Available сообщает, работает ли команда в чате такого типа
+func (c Command) Available(chatType models.ChatType) bool {
+	private := chatType == models.ChatTypePrivate
+	switch c.Scope {
+	case ScopeAll:
+		return true
+	case ScopeGroups:
+		return !private
+	default:
+		return private
+	}
+}
+
+// commandMenu - список команд для одной области меню Telegram
+type commandMenu struct {
+	scope models.BotCommandScope
+	list  []models.BotCommand
+}
+
+// Registry - все команды бота. По нему регистрируются обработчики
+// и строится меню команд в Telegram.
+type Registry struct {
+	handlers []CommandHandler
+	names    map[string]bool
+	admins   map[int64]bool
+}
+
+func NewRegistry(adminIDs []int64) *Registry {
+	admins := make(map[int64]bool, len(adminIDs))
+	for _, id := range adminIDs {
+		admins[id] = true
+	}
+	return &Registry{
+		names:  make(map[string]bool),
+		admins: admins,
+	}
+}
+
+// Add добавляет команды в порядке меню. Некорректное или повторное имя - ошибка программы.
+func (r *Registry) Add(handlers ...CommandHandler) {
+	for _, h := range handlers {
+		for _, name := range h.Command().Names() {
+			if !commandName.MatchString(name) {
+				panic(fmt.Sprintf("command %q: invalid name", name))
+			}
+			if r.names[name] {
+				panic(fmt.Sprintf("command %q: already registered", name))
+			}
+			r.names[name] = true
+		}
+		r.handlers = append(r.handlers, h)
+	}
+}
+
+// Handlers возвращает обработчики в порядке добавления
+func (r *Registry) Handlers() []CommandHandler {
+	return r.handlers
+}
+
+// IsAdmin сообщает, есть ли пользователь в ADMIN_IDS
+func (r *Registry) IsAdmin(userID int64) bool {
+	return r.admins[userID]
+}
+
+// Allowed сообщает, может ли пользователь вызвать команду в чате такого типа
+func (r *Registry) Allowed(command Command, userID int64, chatType models.ChatType) bool {
+	if !command.Available(chatType) {
+		return false
+	}
+	return command.Visibility != VisibilityAdmin || r.IsAdmin(userID)
+}
+
+// MatchFunc возвращает условие вызова обработчика: текст команды, тип чата и права
+func (r *Registry) MatchFunc(h CommandHandler) bot.MatchFunc {
+	command := h.Command()
+	return func(update *models.Update) bool {
+		msg := update.Message
+		if msg == nil || msg.From == nil || !command.Matches(msg.Text) {
+			return false
+		}
+		return r.Allowed(command, msg.From.ID, msg.Chat.Type)
+	}
+}
+
+// Find ищет команду по имени или синониму, с "/" или без
+func (r *Registry) Find(name string) (Command, bool) {
+	name, _, _ = strings.Cut(strings.TrimPrefix(name, "/"), "@")
+	for _, h := range r.handlers {
+		for _, n := range h.Command().Names() {
+			if n == name {
+				return h.Command(), true
+			}
+		}
+	}
+	return Command{}, false
+}
+
+// Available возвращает команды, которые пользователь может вызвать в чате такого типа
+func (r *Registry) Available(userID int64, chatType models.ChatType) []Command {
+	var available []Command
+	for _, h := range r.handlers {
+		if command := h.Command(); r.Allowed(command, userID, chatType) {
+			available = append(available, command)
+		}
+	}
+	return available
+}
+
+// Summary - список доступных команд с описаниями, по одной в строке
+func (r *Registry) Summary(locale string, userID int64, chatType models.ChatType) string {
+	var lines []string
+	for _, command := range r.Available(userID, chatType) {
+		lines = append(lines, i18n.T(locale, "help.line", i18n.Params{
+			"name":        command.Name,
+			"description": command.Description(locale),
+		}))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Sync отправляет меню команд в Telegram: для каждого языка и типа чата,
+// а администраторам - отдельное меню с их командами
+func (r *Registry) Sync(ctx context.Context, b *bot.Bot) error {
+	hasAdmin := false
+	for _, h := range r.handlers {
+		if h.Command().Visibility == VisibilityAdmin {
+			hasAdmin = true
+		}
+	}
+
+	// пустой язык - меню по умолчанию для языков без своего каталога
+	for _, languageCode := range append([]string{""}, i18n.Locales()...) {
+		locale := languageCode
+		if locale == "" {
+			locale = i18n.DefaultLocale
+		}
+
+		menus := []commandMenu{
+			{&models.BotCommandScopeDefault{}, r.menu(locale, false, ScopeAll)},
+			{&models.BotCommandScopeAllPrivateChats{}, r.menu(locale, false, ScopeAll, ScopePrivate)},
+			{&models.BotCommandScopeAllGroupChats{}, r.menu(locale, false, ScopeAll, ScopeGroups)},
+		}
+		if hasAdmin {
+			for id := range r.admins {
+				menus = append(menus, commandMenu{&models.BotCommandScopeChat{ChatID: id}, r.menu(locale, true, ScopeAll, ScopePrivate)})
+			}
+		}
+
+		for _, menu := range menus {
+			_, err := b.SetMyCommands(ctx, &bot.SetMyCommandsParams{
+				Commands:     menu.list,
+				Scope:        menu.scope,
+				LanguageCode: languageCode,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to set commands for %q: %w", languageCode, err)
+			}
+		}
+	}
+
+	log.Printf("📜 Command menu synced: %d commands", len(r.handlers))
+	return nil
+}
+
+// menu собирает меню из команд с подходящей областью; синонимы в меню не попадают
+func (r *Registry) menu(locale string, admin bool, scopes ...Scope) []models.BotCommand {
+	list := []models.BotCommand{}
+	for _, h := range r.handlers {
+		command := h.Command()
+		if command.Visibility == VisibilityAdmin && !admin {
+			continue
+		}
+		for _, scope := range scopes {
+			if command.Scope == scope {
+				list = append(list, models.BotCommand{
+					Command:     command.Name,
+					Description: command.Description(locale),
+				})
+				break
+			}
+		}
+	}
+	return list
+}
@@ -0,0 +1,421 @@
+package commands
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/conversation"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/flows"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/preferences"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/reminders"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// maxReminders - сколько напоминаний может завести один пользователь
+const maxReminders = 5
+
+// quietPresets - варианты тихих часов, минуты от полуночи
+var quietPresets = [][2]int{
+	{22 * 60, 8 * 60},
+	{23 * 60, 7 * 60},
+	{0, 9 * 60},
+}
+
+// RemindHandler управляет напоминаниями и обрабатывает кнопки под ними
+type RemindHandler struct {
+	BaseHandler
+	reminders     storage.ReminderRepository
+	settings      storage.SettingsRepository
+	conversations *conversation.Manager
+	codec         *callback.Codec
+}
+
+func NewRemindHandler(store storage.Storage, conversations *conversation.Manager, codec *callback.Codec) *RemindHandler {
+	return &RemindHandler{
+		BaseHandler:   NewBaseHandler(Command{Name: "remind"}),
+		reminders:     store.Reminders(),
+		settings:      store.Settings(),
+		conversations: conversations,
+		codec:         codec,
+	}
+}
+
+func (h *RemindHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	log.Printf("⏰ Remind command received from %s", update.Message.From.Username)
+
+	text, keyboard, err := h.menu(ctx, update.Message.From.ID)
+	if err != nil {
+		log.Printf("Error building reminders menu: %v", err)
+		sendErrorMessage(ctx, b, update.Message.Chat.ID, "error.reminders_load")
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        text,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		log.Printf("Error sending reminders menu: %v", err)
+	}
+}
+
+// HandleCallback обрабатывает кнопки меню /remind и кнопки под самим напоминанием
+func (h *RemindHandler) HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update, payload callback.Payload) {
+	locale := i18n.FromContext(ctx)
+	query := update.CallbackQuery
+	msg := query.Message.Message
+	if msg == nil {
+		answer(ctx, b, query.ID, "")
+		return
+	}
+
+	userID := query.From.ID
+	var err error
+	notice := ""
+	switch payload.Action {
+	case "menu":
+	case "add":
+		answer(ctx, b, query.ID, "")
+		edit(ctx, b, msg, i18n.T(locale, "remind.add_menu", nil), h.addKeyboard(locale))
+		return
+	case "new":
+		notice, err = h.create(ctx, userID, msg.Chat.ID, payload.Int(0))
+	case "toggle":
+		err = h.toggle(ctx, userID, payload.Int64(0))
+	case "del":
+		err = h.reminders.Delete(ctx, userID, payload.Int64(0))
+	case "quiet":
+		answer(ctx, b, query.ID, "")
+		edit(ctx, b, msg, i18n.T(locale, "remind.quiet_menu", nil), h.quietKeyboard(locale))
+		return
+	case "q":
+		err = h.setQuiet(ctx, userID, payload.Int(0), payload.Int(1))
+	case "tz":
+		answer(ctx, b, query.ID, "")
+		edit(ctx, b, msg, i18n.T(locale, "settings.timezone_menu", nil), timezoneKeyboard(locale, h.data))
+		return
+	case "tzset":
+		err = h.setTimezone(ctx, userID, payload.Int(0))
+	case "log":
+		answer(ctx, b, query.ID, "")
+		h.removeKeyboard(ctx, b, msg)
+		if err := h.conversations.Start(ctx, b, msg.Chat.ID, userID, flows.DiaryFlowName, nil); err != nil {
+			log.Printf("Error starting diary conversation: %v", err)
+			sendErrorMessage(ctx, b, msg.Chat.ID, "error.diary_start")
+		}
+		return
+	case "snooze":
+		h.snooze(ctx, b, query, payload.Int64(0), payload.Int(1))
+		return
+	case "off":
+		h.disable(ctx, b, query, payload.Int64(0))
+		return
+	default:
+		answer(ctx, b, query.ID, "")
+		return
+	}
+
+	if err != nil {
+		log.Printf("Error updating reminders: %v", err)
+		answer(ctx, b, query.ID, i18n.T(locale, "common.save_failed", nil))
+		return
+	}
+	answer(ctx, b, query.ID, notice)
+
+	text, keyboard, err := h.menu(ctx, userID)
+	if err != nil {
+		log.Printf("Error building reminders menu: %v", err)
+		return
+	}
+	edit(ctx, b, msg, text, keyboard)
+}
+
+func (h *RemindHandler) GetCallbackRoutes() []callback.Route {
+	return []callback.Route{
+		{Prefix: reminders.CallbackPrefix, Version: reminders.CallbackVersion, Handle: h.HandleCallback},
+	}
+}
+
+// data собирает подписанные данные кнопки /remind
+func (h *RemindHandler) data(action string, args ...any) string {
+	return h.codec.Encode(reminders.CallbackPrefix, reminders.CallbackVersion, action, args...)
+}
+
+func (h *RemindHandler) menu(ctx context.Context, userID int64) (string, *models.InlineKeyboardMarkup, error) {
+	locale := i18n.FromContext(ctx)
+	userSettings, err := h.settings.Get(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+	list, err := h.reminders.List(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(locale, "remind.title", nil) + "\n\n")
+	sb.WriteString(i18n.T(locale, "settings.field.timezone", i18n.Params{"value": reminders.TimezoneLabel(locale, userSettings.Timezone)}) + "\n")
+	if userSettings.QuietFrom == userSettings.QuietTo {
+		sb.WriteString(i18n.T(locale, "remind.quiet_none", nil) + "\n\n")
+	} else {
+		sb.WriteString(i18n.T(locale, "remind.quiet_range", i18n.Params{
+			"from": reminders.FormatMinute(userSettings.QuietFrom),
+			"to":   reminders.FormatMinute(userSettings.QuietTo),
+		}) + "\n\n")
+	}
+
+	if len(list) == 0 {
+		sb.WriteString(i18n.T(locale, "remind.empty", nil))
+	} else {
+		sb.WriteString(i18n.T(locale, "remind.toggle_hint", nil))
+	}
+
+	var rows [][]models.InlineKeyboardButton
+	for _, r := range list {
+		icon := "🔔"
+		if !r.Enabled {
+			icon = "🔕"
+		}
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: icon + " " + reminders.FormatMinute(r.Minute), CallbackData: h.data("toggle", r.ID)},
+			{Text: "🗑", CallbackData: h.data("del", r.ID)},
+		})
+	}
+	if len(list) < maxReminders {
+		rows = append(rows, []models.InlineKeyboardButton{{Text: i18n.T(locale, "remind.button.add", nil), CallbackData: h.data("add")}})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{
+		{Text: i18n.T(locale, "remind.button.quiet", nil), CallbackData: h.data("quiet")},
+		{Text: i18n.T(locale, "settings.button.timezone", nil), CallbackData: h.data("tz")},
+	})
+	rows = append(rows, []models.InlineKeyboardButton{{Text: i18n.T(locale, "remind.button.settings", nil), CallbackData: h.codec.Encode(preferences.CallbackPrefix, preferences.CallbackVersion, "menu")}})
+
+	return sb.String(), &models.InlineKeyboardMarkup{InlineKeyboard: rows}, nil
+}
+
+func (h *RemindHandler) create(ctx context.Context, userID, chatID int64, minute int) (string, error) {
+	if !reminders.ValidMinute(minute) {
+		return "", nil
+	}
+
+	list, err := h.reminders.List(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	locale := i18n.FromContext(ctx)
+	if len(list) >= maxReminders {
+		return i18n.N(locale, "remind.limit", maxReminders, nil), nil
+	}
+	for _, r := range list {
+		if r.Minute == minute {
+			return i18n.T(locale, "remind.exists", nil), nil
+		}
+	}
+
+	userSettings, err := h.settings.Get(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	err = h.reminders.Create(ctx, &storage.Reminder{
+		UserID:  userID,
+		ChatID:  chatID,
+		Minute:  minute,
+		Enabled: true,
+		NextAt:  reminders.NextRun(minute, userSettings.Location(), time.Now()),
+	})
+	if err != nil {
+		return "", err
+	}
+	return i18n.T(locale, "remind.created", i18n.Params{"time": reminders.FormatMinute(minute)}), nil
+}
+
+func (h *RemindHandler) toggle(ctx context.Context, userID, id int64) error {
+	r, err := h.reminders.Get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	r.Enabled = !r.Enabled
+	if r.Enabled {
+		userSettings, err := h.settings.Get(ctx, userID)
+		if err != nil {
+			return err
+		}
+		r.NextAt = reminders.NextRun(r.Minute, userSettings.Location(), time.Now())
+	}
+	return h.reminders.Update(ctx, r)
+}
+
+func (h *RemindHandler) setQuiet(ctx context.Context, userID int64, from, to int) error {
+	if !reminders.ValidMinute(from) || !reminders.ValidMinute(to) {
+		return nil
+	}
+
+	userSettings, err := h.settings.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	userSettings.QuietFrom = from
+	userSettings.QuietTo = to
+	return h.settings.Save(ctx, userSettings)
+}
+
+// setTimezone меняет часовой пояс и пересчитывает время срабатывания напоминаний
+func (h *RemindHandler) setTimezone(ctx context.Context, userID int64, index int) error {
+	if index < 0 || index >= len(reminders.Timezones) {
+		return nil
+	}
+
+	userSettings, err := h.settings.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	userSettings.Timezone = reminders.Timezones[index].Name
+	if err := h.settings.Save(ctx, userSettings); err != nil {
+		return err
+	}
+	return reminders.Reschedule(ctx, h.reminders, userID, userSettings.Location(), time.Now())
+}
+
+func (h *RemindHandler) snooze(ctx context.Context, b *bot.Bot, query *models.CallbackQuery, id int64, minutes int) {
+	locale := i18n.FromContext(ctx)
+	valid := false
+	for _, option := range reminders.SnoozeOptions {
+		valid = valid || option == minutes
+	}
+
+	r, err := h.reminders.Get(ctx, query.From.ID, id)
+	if err != nil || !valid {
+		if err != nil {
+			log.Printf("Error loading reminder: %v", err)
+		}
+		answer(ctx, b, query.ID, i18n.T(locale, "remind.not_found", nil))
+		return
+	}
+
+	r.NextAt = time.Now().Add(time.Duration(minutes) * time.Minute)
+	r.Enabled = true
+	if err := h.reminders.Update(ctx, r); err != nil {
+		log.Printf("Error snoozing reminder: %v", err)
+		answer(ctx, b, query.ID, i18n.T(locale, "remind.snooze_failed", nil))
+		return
+	}
+
+	answer(ctx, b, query.ID, i18n.N(locale, "remind.snoozed", minutes/60, nil))
+	h.removeKeyboard(ctx, b, query.Message.Message)
+}
+
+func (h *RemindHandler) disable(ctx context.Context, b *bot.Bot, query *models.CallbackQuery, id int64) {
+	locale := i18n.FromContext(ctx)
+	r, err := h.reminders.Get(ctx, query.From.ID, id)
+	if err != nil {
+		log.Printf("Error loading reminder: %v", err)
+		answer(ctx, b, query.ID, i18n.T(locale, "remind.not_found", nil))
+		return
+	}
+
+	r.Enabled = false
+	if err := h.reminders.Update(ctx, r); err != nil {
+		log.Printf("Error disabling reminder: %v", err)
+		answer(ctx, b, query.ID, i18n.T(locale, "remind.disable_failed", nil))
+		return
+	}
+
+	answer(ctx, b, query.ID, i18n.T(locale, "remind.disabled", nil))
+	h.removeKeyboard(ctx, b, query.Message.Message)
+}
+
+func (h *RemindHandler) removeKeyboard(ctx context.Context, b *bot.Bot, msg *models.Message) {
+	_, err := b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:    msg.Chat.ID,
+		MessageID: msg.ID,
+	})
+	if err != nil {
+		log.Printf("Error removing reminder keyboard: %v", err)
+	}
+}
+
+func (h *RemindHandler) addKeyboard(locale string) *models.InlineKeyboardMarkup {
+	var rows [][]models.InlineKeyboardButton
+	var row []models.InlineKeyboardButton
+	for hour := 6; hour <= 23; hour++ {
+		row = append(row, models.InlineKeyboardButton{
+			Text:         reminders.FormatMinute(hour * 60),
+			CallbackData: h.data("new", hour*60),
+		})
+		if len(row) == 6 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{Text: i18n.T(locale, "common.back", nil), CallbackData: h.data("menu")}})
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+func (h *RemindHandler) quietKeyboard(locale string) *models.InlineKeyboardMarkup {
+	var rows [][]models.InlineKeyboardButton
+	for _, preset := range quietPresets {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         reminders.FormatMinute(preset[0]) + "–" + reminders.FormatMinute(preset[1]),
+			CallbackData: h.data("q", preset[0], preset[1]),
+		}})
+	}
+	rows = append(rows,
+		[]models.InlineKeyboardButton{{Text: i18n.T(locale, "remind.button.no_quiet", nil), CallbackData: h.data("q", 0, 0)}},
+		[]models.InlineKeyboardButton{{Text: i18n.T(locale, "common.back", nil), CallbackData: h.data("menu")}},
+	)
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// timezoneKeyboard - выбор часового пояса, data собирает данные кнопок для действий tzset и menu
+func timezoneKeyboard(locale string, data func(action string, args ...any) string) *models.InlineKeyboardMarkup {
+	var rows [][]models.InlineKeyboardButton
+	var row []models.InlineKeyboardButton
+	for i, tz := range reminders.Timezones {
+		row = append(row, models.InlineKeyboardButton{
+			Text:         tz.Label(locale),
+			CallbackData: data("tzset", i),
+		})
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{Text: i18n.T(locale, "common.back", nil), CallbackData: data("menu")}})
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+func answer(ctx context.Context, b *bot.Bot, queryID, text string) {
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: queryID,
+		Text:            text,
+	})
+	if err != nil {
+		log.Printf("Error answering callback: %v", err)
+	}
+}
+
+func edit(ctx context.Context, b *bot.Bot, msg *models.Message, text string, keyboard *models.InlineKeyboardMarkup) {
+	_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      msg.Chat.ID,
+		MessageID:   msg.ID,
+		Text:        text,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		log.Printf("Error editing message: %v", err)
+	}
+}
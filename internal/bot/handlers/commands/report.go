@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/gemini"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/report"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+const (
+	reportCallbackPrefix  = "report:"
+	reportCallbackVersion = 1
+)
+
+// reportSummaryTimeout - сколько ждать сводку от модели, прежде чем отправить отчет без нее
+const reportSummaryTimeout = 30 * time.Second
+
+type ReportHandler struct {
+	BaseHandler
+	store         storage.Storage
+	geminiService *gemini.GeminiService
+	codec         *callback.Codec
+}
+
+func NewReportHandler(store storage.Storage, geminiService *gemini.GeminiService, codec *callback.Codec) *ReportHandler {
+	return &ReportHandler{
+		BaseHandler:   NewBaseHandler(Command{Name: "report"}),
+		store:         store,
+		geminiService: geminiService,
+		codec:         codec,
+	}
+}
+
+func (h *ReportHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	log.Printf("🩺 Report command received from %s", update.Message.From.Username)
+	locale := i18n.FromContext(ctx)
+
+	var row []models.InlineKeyboardButton
+	for _, period := range statsPeriods {
+		row = append(row, models.InlineKeyboardButton{
+			Text:         i18n.N(locale, "common.days_short", period, nil),
+			CallbackData: h.codec.Encode(reportCallbackPrefix, reportCallbackVersion, "period", period),
+		})
+	}
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        i18n.T(locale, "report.menu", nil),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{row}},
+	})
+	if err != nil {
+		log.Printf("Error sending report menu: %v", err)
+	}
+}
+
+// HandleCallback формирует и отправляет отчет за выбранный период
+func (h *ReportHandler) HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update, payload callback.Payload) {
+	locale := i18n.FromContext(ctx)
+	query := update.CallbackQuery
+
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: query.ID,
+		Text:            i18n.T(locale, "report.preparing", nil),
+	})
+	if err != nil {
+		log.Printf("Error answering report callback: %v", err)
+	}
+
+	days := payload.Int(0)
+	if payload.Action != "period" || !validStatsPeriod(days) || query.Message.Message == nil {
+		return
+	}
+	chatID := query.Message.Message.Chat.ID
+
+	_, err = b.SendChatAction(ctx, &bot.SendChatActionParams{
+		ChatID: chatID,
+		Action: models.ChatActionUploadDocument,
+	})
+	if err != nil {
+		log.Printf("Error sending chat action: %v", err)
+	}
+
+	r, err := report.Collect(ctx, h.store, query.From.ID, days, time.Now(), locale)
+	if err != nil {
+		log.Printf("Error collecting report: %v", err)
+		sendErrorMessage(ctx, b, chatID, "error.report_collect")
+		return
+	}
+
+	if r.Summary.Total > 0 {
+		r.AISummary = h.summarize(ctx, r, locale)
+	}
+
+	pdf, err := report.RenderPDF(r, locale)
+	if err != nil {
+		log.Printf("Error rendering report: %v", err)
+		sendErrorMessage(ctx, b, chatID, "error.report_pdf")
+		return
+	}
+
+	caption := i18n.N(locale, "report.caption", days, nil)
+	if len(r.RedFlags) > 0 {
+		caption += "\n\n" + i18n.N(locale, "report.caption_red_flags", len(r.RedFlags), nil)
+	}
+
+	_, err = b.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID: chatID,
+		Document: &models.InputFileUpload{
+			Filename: "stool-guru-report-" + r.GeneratedAt.Format("2006-01-02") + ".pdf",
+			Data:     bytes.NewReader(pdf),
+		},
+		Caption: caption,
+	})
+	if err != nil {
+		log.Printf("Error sending report: %v", err)
+		sendErrorMessage(ctx, b, chatID, "error.report_send")
+	}
+}
+
+func (h *ReportHandler) GetCallbackRoutes() []callback.Route {
+	return []callback.Route{
+		{Prefix: reportCallbackPrefix, Version: reportCallbackVersion, Handle: h.HandleCallback},
+	}
+}
+
+// summarize просит модель описать данные. Ошибка не мешает отправить отчет без сводки.
+func (h *ReportHandler) summarize(ctx context.Context, r *report.Report, locale string) string {
+	ctx, cancel := context.WithTimeout(ctx, reportSummaryTimeout)
+	defer cancel()
+
+	result, err := h.geminiService.SendTextMessage(ctx, r.SummaryPrompt(locale))
+	if err != nil {
+		log.Printf("Error generating report summary: %v", err)
+		return ""
+	}
+	return result.Text
+}
@@ -0,0 +1,286 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/preferences"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/profile"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/reminders"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// SettingsHandler - меню /settings со всеми настройками пользователя.
+// Изменения сразу сохраняются, остальные обработчики читают настройки при каждом запросе.
+type SettingsHandler struct {
+	BaseHandler
+	settings  storage.SettingsRepository
+	profiles  storage.ProfileRepository
+	reminders storage.ReminderRepository
+	codec     *callback.Codec
+}
+
+func NewSettingsHandler(store storage.Storage, codec *callback.Codec) *SettingsHandler {
+	return &SettingsHandler{
+		BaseHandler: NewBaseHandler(Command{Name: "settings"}),
+		settings:    store.Settings(),
+		profiles:    store.Profiles(),
+		reminders:   store.Reminders(),
+		codec:       codec,
+	}
+}
+
+func (h *SettingsHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	log.Printf("⚙️ Settings command received from %s", update.Message.From.Username)
+
+	text, keyboard, err := h.menu(ctx, update.Message.From.ID)
+	if err != nil {
+		log.Printf("Error building settings menu: %v", err)
+		sendErrorMessage(ctx, b, update.Message.Chat.ID, "error.settings_load")
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        text,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		log.Printf("Error sending settings menu: %v", err)
+	}
+}
+
+// HandleCallback открывает подменю и сохраняет выбранные значения
+func (h *SettingsHandler) HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update, payload callback.Payload) {
+	locale := i18n.FromContext(ctx)
+	query := update.CallbackQuery
+	msg := query.Message.Message
+	if msg == nil {
+		answer(ctx, b, query.ID, "")
+		return
+	}
+
+	userID := query.From.ID
+	action := payload.Action
+	value := payload.String(0)
+
+	var err error
+	switch action {
+	case "menu":
+	case "lang":
+		answer(ctx, b, query.ID, "")
+		edit(ctx, b, msg, i18n.T(locale, "settings.language_menu", nil), h.choiceKeyboard(locale, "langset", preferences.Languages))
+		return
+	case "langset":
+		err = h.update(ctx, userID, func(s *storage.Settings) bool {
+			s.Language = value
+			return preferences.Valid(preferences.Languages, value)
+		})
+	case "tz":
+		answer(ctx, b, query.ID, "")
+		edit(ctx, b, msg, i18n.T(locale, "settings.timezone_menu", nil), timezoneKeyboard(locale, h.data))
+		return
+	case "tzset":
+		err = h.setTimezone(ctx, userID, payload.Int(0))
+	case "images":
+		err = h.update(ctx, userID, func(s *storage.Settings) bool {
+			s.KeepImages = !s.KeepImages
+			return true
+		})
+	case "incognito":
+		err = h.update(ctx, userID, func(s *storage.Settings) bool {
+			s.Incognito = !s.Incognito
+			return true
+		})
+	case "notify":
+		answer(ctx, b, query.ID, "")
+		edit(ctx, b, msg, i18n.T(locale, "settings.notify_menu", nil), h.choiceKeyboard(locale, "notifyset", preferences.NotificationModes))
+		return
+	case "notifyset":
+		err = h.update(ctx, userID, func(s *storage.Settings) bool {
+			s.Notifications = value
+			return preferences.Valid(preferences.NotificationModes, value)
+		})
+	case "detail":
+		answer(ctx, b, query.ID, "")
+		edit(ctx, b, msg, i18n.T(locale, "settings.detail_menu", nil), h.detailKeyboard(locale))
+		return
+	case "detailset":
+		err = h.setDetail(ctx, userID, value)
+	default:
+		answer(ctx, b, query.ID, "")
+		return
+	}
+
+	if err != nil {
+		log.Printf("Error updating settings: %v", err)
+		answer(ctx, b, query.ID, i18n.T(locale, "common.save_failed", nil))
+		return
+	}
+	if action == "menu" {
+		answer(ctx, b, query.ID, "")
+	} else {
+		answer(ctx, b, query.ID, i18n.T(locale, "common.saved", nil))
+	}
+
+	text, keyboard, err := h.menu(ctx, userID)
+	if err != nil {
+		log.Printf("Error building settings menu: %v", err)
+		return
+	}
+	edit(ctx, b, msg, text, keyboard)
+}
+
+func (h *SettingsHandler) GetCallbackRoutes() []callback.Route {
+	return []callback.Route{
+		{Prefix: preferences.CallbackPrefix, Version: preferences.CallbackVersion, Handle: h.HandleCallback},
+	}
+}
+
+func (h *SettingsHandler) menu(ctx context.Context, userID int64) (string, *models.InlineKeyboardMarkup, error) {
+	locale := i18n.FromContext(ctx)
+	userSettings, err := h.settings.Get(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	detail := profile.DetailStandard
+	userProfile, err := h.profiles.Get(ctx, userID)
+	if err == nil && userProfile.DetailLevel != "" {
+		detail = userProfile.DetailLevel
+	} else if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return "", nil, err
+	}
+
+	list, err := h.reminders.List(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+	active := 0
+	for _, r := range list {
+		if r.Enabled {
+			active++
+		}
+	}
+
+	fields := []struct {
+		key   string
+		value any
+	}{
+		{"settings.field.language", preferences.Label(locale, preferences.Languages, userSettings.Language)},
+		{"settings.field.timezone", reminders.TimezoneLabel(locale, userSettings.Timezone)},
+		{"settings.field.reminders", active},
+		{"settings.field.notifications", preferences.Label(locale, preferences.NotificationModes, userSettings.Notifications)},
+		{"settings.field.detail", profile.Label(locale, profile.DetailLevels, detail)},
+		{"settings.field.images", preferences.OnOff(locale, userSettings.KeepImages)},
+		{"settings.field.incognito", preferences.OnOff(locale, userSettings.Incognito)},
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(locale, "settings.title", nil) + "\n\n")
+	for _, f := range fields {
+		sb.WriteString(i18n.T(locale, f.key, i18n.Params{"value": f.value}) + "\n")
+	}
+	sb.WriteString("\n" + i18n.T(locale, "settings.incognito_hint", nil))
+
+	button := func(key, data string) models.InlineKeyboardButton {
+		return models.InlineKeyboardButton{Text: i18n.T(locale, key, nil), CallbackData: data}
+	}
+	keyboard := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{
+			button("settings.button.language", h.data("lang")),
+			button("settings.button.timezone", h.data("tz")),
+		},
+		{
+			button("settings.button.reminders", h.codec.Encode(reminders.CallbackPrefix, reminders.CallbackVersion, "menu")),
+			button("settings.button.notifications", h.data("notify")),
+		},
+		{
+			button("settings.button.detail", h.data("detail")),
+			button("settings.button.images", h.data("images")),
+		},
+		{
+			button("settings.button.incognito", h.data("incognito")),
+		},
+	}}
+	return sb.String(), keyboard, nil
+}
+
+// update меняет настройки через apply и сохраняет их. Если apply вернул false,
+// значение из кнопки неизвестно и ничего не сохраняется.
+func (h *SettingsHandler) update(ctx context.Context, userID int64, apply func(s *storage.Settings) bool) error {
+	userSettings, err := h.settings.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !apply(userSettings) {
+		return nil
+	}
+	return h.settings.Save(ctx, userSettings)
+}
+
+// setTimezone меняет часовой пояс и пересчитывает время срабатывания напоминаний
+func (h *SettingsHandler) setTimezone(ctx context.Context, userID int64, index int) error {
+	if index < 0 || index >= len(reminders.Timezones) {
+		return nil
+	}
+
+	userSettings, err := h.settings.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	userSettings.Timezone = reminders.Timezones[index].Name
+	if err := h.settings.Save(ctx, userSettings); err != nil {
+		return err
+	}
+	return reminders.Reschedule(ctx, h.reminders, userID, userSettings.Location(), time.Now())
+}
+
+// setDetail сохраняет подробность ответов в анкету, создавая ее при необходимости
+func (h *SettingsHandler) setDetail(ctx context.Context, userID int64, level string) error {
+	if profile.Find(profile.DetailLevels, level) == nil {
+		return nil
+	}
+
+	userProfile, err := h.profiles.Get(ctx, userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		userProfile = &storage.Profile{UserID: userID}
+	} else if err != nil {
+		return err
+	}
+	userProfile.DetailLevel = level
+	return h.profiles.Save(ctx, userProfile)
+}
+
+// data собирает подписанные данные кнопки /settings: set:1:<action>[:<arg>...]
+func (h *SettingsHandler) data(action string, args ...any) string {
+	return h.codec.Encode(preferences.CallbackPrefix, preferences.CallbackVersion, action, args...)
+}
+
+func (h *SettingsHandler) choiceKeyboard(locale, action string, choices []preferences.Choice) *models.InlineKeyboardMarkup {
+	var rows [][]models.InlineKeyboardButton
+	for _, c := range choices {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         c.Label(locale),
+			CallbackData: h.data(action, c.Key),
+		}})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{Text: i18n.T(locale, "common.back", nil), CallbackData: h.data("menu")}})
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+func (h *SettingsHandler) detailKeyboard(locale string) *models.InlineKeyboardMarkup {
+	choices := make([]preferences.Choice, len(profile.DetailLevels))
+	for i, o := range profile.DetailLevels {
+		choices[i] = preferences.Choice{Key: o.Key, Message: "profile.option." + o.Key}
+	}
+	return h.choiceKeyboard(locale, "detailset", choices)
+}
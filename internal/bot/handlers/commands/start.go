@@ -2,19 +2,32 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"log"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/conversation"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/flows"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/handlers/callbacks"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
 )
 
 type StartHandler struct {
 	BaseHandler
+	profiles      storage.ProfileRepository
+	conversations *conversation.Manager
+	codec         *callback.Codec
 }
 
-func NewStartHandler() *StartHandler {
+func NewStartHandler(store storage.Storage, conversations *conversation.Manager, codec *callback.Codec) *StartHandler {
 	return &StartHandler{
-		BaseHandler: NewBaseHandler("/start", bot.MatchTypeExact),
+		BaseHandler:   NewBaseHandler(Command{Name: "start", Scope: ScopeAll}),
+		profiles:      store.Profiles(),
+		conversations: conversations,
+		codec:         codec,
 	}
 }
 
@@ -23,28 +36,23 @@ func (h *StartHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Up
 	if update.Message.From.Username != "" {
 		username = "@" + update.Message.From.Username
 	}
-	
+
 	log.Printf("📋 Start command received from %s", username)
-	
+
+	locale := i18n.FromContext(ctx)
 	keyboard := &models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
 			{
-				{Text: "🧪 Тест", CallbackData: "test"},
-				{Text: "❓ Помощь", CallbackData: "help"},
+				{Text: i18n.T(locale, "start.button_test", nil), CallbackData: h.codec.Encode(callbacks.MenuPrefix, callbacks.MenuVersion, callbacks.MenuTest)},
+				{Text: i18n.T(locale, "start.button_help", nil), CallbackData: h.codec.Encode(helpCallbackPrefix, helpCallbackVersion, "show")},
 			},
 			{
-				{Text: "📊 Анализ", CallbackData: "analyze"},
+				{Text: i18n.T(locale, "start.button_analyze", nil), CallbackData: h.codec.Encode(callbacks.MenuPrefix, callbacks.MenuVersion, callbacks.MenuAnalyze)},
 			},
 		},
 	}
 
-	text := `🤖 <b>Stool Guru Bot запущен</b>
-
-Привет! Я готов помочь вам с анализом здоровья.
-
-📸 <b>Просто отправьте мне фото для анализа!</b>
-
-Или выберите действие в меню ниже:`
+	text := i18n.T(locale, "start.text", nil)
 
 	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID:      update.Message.Chat.ID,
@@ -54,6 +62,25 @@ func (h *StartHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Up
 	})
 	if err != nil {
 		log.Printf("Error sending start message: %v", err)
-		sendErrorMessage(ctx, b, update.Message.Chat.ID, "Ошибка отправки приветственного сообщения")
+		sendErrorMessage(ctx, b, update.Message.Chat.ID, "error.start")
+		return
 	}
-}
\ No newline at end of file
+
+	h.startOnboarding(ctx, b, update.Message.Chat.ID, update.Message.From.ID)
+}
+
+// startOnboarding запускает анкету, если пользователь еще ни разу ее не заполнял
+func (h *StartHandler) startOnboarding(ctx context.Context, b *bot.Bot, chatID, userID int64) {
+	_, err := h.profiles.Get(ctx, userID)
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("Error loading profile: %v", err)
+		return
+	}
+
+	if err := h.conversations.Start(ctx, b, chatID, userID, flows.OnboardingFlowName, nil); err != nil {
+		log.Printf("Error starting onboarding conversation: %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/stats"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+const (
+	statsCallbackPrefix  = "stats:"
+	statsCallbackVersion = 1
+)
+
+var statsPeriods = []int{7, 30, 90}
+
+type StatsHandler struct {
+	BaseHandler
+	diary    storage.DiaryRepository
+	settings storage.SettingsRepository
+	codec    *callback.Codec
+}
+
+func NewStatsHandler(store storage.Storage, codec *callback.Codec) *StatsHandler {
+	return &StatsHandler{
+		BaseHandler: NewBaseHandler(Command{Name: "stats"}),
+		diary:       store.Diary(),
+		settings:    store.Settings(),
+		codec:       codec,
+	}
+}
+
+func (h *StatsHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	log.Printf("📈 Stats command received from %s", update.Message.From.Username)
+
+	h.send(ctx, b, update.Message.Chat.ID, update.Message.From.ID, statsPeriods[1])
+}
+
+// HandleCallback пересчитывает статистику за выбранный период
+func (h *StatsHandler) HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update, payload callback.Payload) {
+	query := update.CallbackQuery
+
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: query.ID})
+	if err != nil {
+		log.Printf("Error answering stats callback: %v", err)
+	}
+
+	days := payload.Int(0)
+	if payload.Action != "period" || !validStatsPeriod(days) || query.Message.Message == nil {
+		return
+	}
+
+	h.send(ctx, b, query.Message.Message.Chat.ID, query.From.ID, days)
+}
+
+func (h *StatsHandler) GetCallbackRoutes() []callback.Route {
+	return []callback.Route{
+		{Prefix: statsCallbackPrefix, Version: statsCallbackVersion, Handle: h.HandleCallback},
+	}
+}
+
+func (h *StatsHandler) send(ctx context.Context, b *bot.Bot, chatID, userID int64, days int) {
+	locale := i18n.FromContext(ctx)
+	_, err := b.SendChatAction(ctx, &bot.SendChatActionParams{
+		ChatID: chatID,
+		Action: models.ChatActionUploadPhoto,
+	})
+	if err != nil {
+		log.Printf("Error sending chat action: %v", err)
+	}
+
+	summary, err := h.summary(ctx, userID, days)
+	if err != nil {
+		log.Printf("Error computing stats: %v", err)
+		sendErrorMessage(ctx, b, chatID, "error.stats")
+		return
+	}
+
+	if summary.Total > 0 {
+		rendered, err := stats.RenderCharts(summary, locale)
+		if err != nil {
+			log.Printf("Error rendering charts: %v", err)
+			sendErrorMessage(ctx, b, chatID, "error.charts")
+			return
+		}
+
+		media := make([]models.InputMedia, 0, len(rendered))
+		for _, chart := range rendered {
+			media = append(media, &models.InputMediaPhoto{
+				Media:           "attach://" + chart.Name,
+				MediaAttachment: bytes.NewReader(chart.PNG),
+			})
+		}
+
+		_, err = b.SendMediaGroup(ctx, &bot.SendMediaGroupParams{
+			ChatID: chatID,
+			Media:  media,
+		})
+		if err != nil {
+			log.Printf("Error sending charts: %v", err)
+		}
+	}
+
+	var row []models.InlineKeyboardButton
+	for _, period := range statsPeriods {
+		label := i18n.N(locale, "common.days_short", period, nil)
+		if period == days {
+			label = "• " + label + " •"
+		}
+		row = append(row, models.InlineKeyboardButton{Text: label, CallbackData: h.codec.Encode(statsCallbackPrefix, statsCallbackVersion, "period", period)})
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        formatStatsSummary(locale, summary, days),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{row}},
+	})
+	if err != nil {
+		log.Printf("Error sending stats summary: %v", err)
+	}
+}
+
+func (h *StatsHandler) summary(ctx context.Context, userID int64, days int) (stats.Summary, error) {
+	userSettings, err := h.settings.Get(ctx, userID)
+	if err != nil {
+		return stats.Summary{}, err
+	}
+
+	now := time.Now()
+	entries, err := h.diary.List(ctx, userID, storage.DiaryFilter{
+		From: now.AddDate(0, 0, -days-1),
+	})
+	if err != nil {
+		return stats.Summary{}, err
+	}
+
+	return stats.Compute(entries, days, now, userSettings.Location()), nil
+}
+
+func formatStatsSummary(locale string, s stats.Summary, days int) string {
+	var sb strings.Builder
+
+	sb.WriteString(i18n.N(locale, "stats.title", days, nil) + "\n\n")
+	if s.Total == 0 {
+		sb.WriteString(i18n.T(locale, "stats.empty", nil))
+		return sb.String()
+	}
+
+	sb.WriteString(i18n.N(locale, "stats.total", s.Total, nil) + "\n")
+	sb.WriteString(i18n.T(locale, "stats.avg", i18n.Params{"avg": fmt.Sprintf("%.1f", s.AvgPerDay)}) + "\n")
+	if s.Typed > 0 {
+		sb.WriteString(i18n.T(locale, "stats.normal_share", i18n.Params{"percent": fmt.Sprintf("%.0f", s.NormalShare*100)}) + "\n")
+	}
+	sb.WriteString(i18n.T(locale, "stats.avg_pain", i18n.Params{"pain": fmt.Sprintf("%.1f", s.AvgPain)}) + "\n")
+
+	sb.WriteString("\n" + i18n.T(locale, "stats.note", nil))
+	return sb.String()
+}
+
+func validStatsPeriod(days int) bool {
+	for _, period := range statsPeriods {
+		if period == days {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/lifestyle"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// triggersDays - за сколько дней сравниваются записи
+const triggersDays = 90
+
+type TriggersHandler struct {
+	BaseHandler
+	diary     storage.DiaryRepository
+	lifestyle storage.LifestyleRepository
+}
+
+func NewTriggersHandler(store storage.Storage) *TriggersHandler {
+	return &TriggersHandler{
+		BaseHandler: NewBaseHandler(Command{Name: "triggers"}),
+		diary:       store.Diary(),
+		lifestyle:   store.Lifestyle(),
+	}
+}
+
+func (h *TriggersHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	userID := update.Message.From.ID
+	log.Printf("🔎 Triggers command received from %s", update.Message.From.Username)
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -triggersDays)
+
+	entries, err := h.diary.List(ctx, userID, storage.DiaryFilter{From: from, To: to})
+	if err != nil {
+		log.Printf("Error loading diary for triggers: %v", err)
+		sendErrorMessage(ctx, b, chatID, "error.diary_load")
+		return
+	}
+
+	// факторы ищутся до записи дневника, поэтому берем записи образа жизни с запасом
+	lifestyleEntries, err := h.lifestyle.List(ctx, userID, from.Add(-48*time.Hour), to)
+	if err != nil {
+		log.Printf("Error loading lifestyle entries: %v", err)
+		sendErrorMessage(ctx, b, chatID, "error.lifestyle_load")
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      formatTriggers(i18n.FromContext(ctx), lifestyle.Analyze(entries, lifestyleEntries), len(entries), len(lifestyleEntries)),
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		log.Printf("Error sending triggers: %v", err)
+	}
+}
+
+func formatTriggers(locale string, insights []lifestyle.Insight, diaryCount, lifestyleCount int) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.N(locale, "triggers.title", triggersDays, nil) + "\n\n")
+
+	if len(insights) == 0 {
+		sb.WriteString(i18n.T(locale, "triggers.not_enough", i18n.Params{
+			"diary":     diaryCount,
+			"lifestyle": lifestyleCount,
+			"min":       lifestyle.MinSamples,
+		}))
+		return sb.String()
+	}
+
+	sb.WriteString(i18n.T(locale, "triggers.bad_day", nil) + "\n\n")
+
+	var likely, others []lifestyle.Insight
+	for _, insight := range insights {
+		if insight.Likely() {
+			likely = append(likely, insight)
+		} else {
+			others = append(others, insight)
+		}
+	}
+
+	if len(likely) == 0 {
+		sb.WriteString(i18n.T(locale, "triggers.none", nil) + "\n")
+	} else {
+		sb.WriteString(i18n.T(locale, "triggers.likely", nil) + "\n")
+		for _, insight := range likely {
+			fmt.Fprintf(&sb, "\n%s — %s\n", insight.Factor.Label(locale), evidenceLabel(locale, insight.Evidence()))
+			sb.WriteString(i18n.T(locale, "triggers.rates", i18n.Params{
+				"exposed_bad":    insight.ExposedBad,
+				"exposed":        insight.Exposed,
+				"exposed_rate":   fmt.Sprintf("%.0f", insight.ExposedRate()*100),
+				"unexposed_bad":  insight.UnexposedBad,
+				"unexposed":      insight.Unexposed,
+				"unexposed_rate": fmt.Sprintf("%.0f", insight.UnexposedRate()*100),
+			}) + "\n")
+			sb.WriteString(i18n.T(locale, "triggers.pain", i18n.Params{
+				"exposed":   fmt.Sprintf("%.1f", insight.AvgPainExposed),
+				"unexposed": fmt.Sprintf("%.1f", insight.AvgPainUnexposed),
+			}) + "\n")
+		}
+	}
+
+	if len(others) > 0 {
+		labels := make([]string, len(others))
+		for i, insight := range others {
+			labels[i] = fmt.Sprintf("%s (%d/%d)", insight.Factor.Label(locale), insight.Exposed, insight.Unexposed)
+		}
+		sb.WriteString("\n" + i18n.T(locale, "triggers.others", i18n.Params{"factors": strings.Join(labels, ", ")}) + "\n")
+	}
+
+	sb.WriteString("\n" + i18n.T(locale, "triggers.note", nil))
+	return sb.String()
+}
+
+func evidenceLabel(locale string, e lifestyle.Evidence) string {
+	switch e {
+	case lifestyle.EvidenceStrong:
+		return i18n.T(locale, "triggers.evidence.strong", nil)
+	case lifestyle.EvidenceModerate:
+		return i18n.T(locale, "triggers.evidence.moderate", nil)
+	default:
+		return i18n.T(locale, "triggers.evidence.weak", nil)
+	}
+}
@@ -0,0 +1,613 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/flows"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/handlers/commands"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/bristol"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+const (
+	callbackPrefix  = "hist:"
+	callbackVersion = 1
+	pageSize        = 5
+
+	// maxAnalysisLength - сколько символов анализа показывать в карточке записи,
+	// полный текст присылается отдельными сообщениями по кнопке
+	maxAnalysisLength = 2500
+	// maxCaptionLength - лимит Telegram на подпись к фото
+	maxCaptionLength = 1024
+
+	// uploadName - имя файла для отправки сохраненного оригинала
+	uploadName = "photo.jpg"
+
+	// rangeLayout - формат дат произвольного периода в данных кнопок: 240501-240515
+	rangeLayout = "060102"
+	// dateLayout - формат дат в аргументах /history и в подписи периода
+	dateLayout = "02.01.2006"
+)
+
+const (
+	actionList        = "l"
+	actionEntry       = "e"
+	actionPhoto       = "p"
+	actionPeriodMenu  = "fp"
+	actionBristolMenu = "ft"
+	actionRangeHelp   = "fr"
+	actionAnalysis    = "a"
+)
+
+// periods - фильтры по периоду, 0 дней - всё время. Кроме них период может
+// быть диапазоном дат из аргументов /history, см. parseRange.
+var periods = []struct {
+	code string
+	days int
+}{
+	{code: "a"},
+	{code: "7", days: 7},
+	{code: "30", days: 30},
+	{code: "90", days: 90},
+}
+
+// view - состояние экрана истории, которое передается в данных кнопок
+type view struct {
+	page    int
+	period  string
+	bristol int
+	entryID int64
+}
+
+// encode собирает подписанные данные кнопки: hist:1:<action>:<page>:<period>:<bristol>[:<entry>]
+func (h *HistoryHandler) encode(v view, action string) string {
+	args := []any{v.page, v.period, v.bristol}
+	if v.entryID != 0 {
+		args = append(args, v.entryID)
+	}
+	return h.codec.Encode(callbackPrefix, callbackVersion, action, args...)
+}
+
+func decode(payload callback.Payload) (view, error) {
+	v := view{
+		page:    payload.Int(0),
+		period:  payload.String(1),
+		bristol: payload.Int(2),
+	}
+	if v.page < 0 || v.bristol < 0 {
+		return view{}, fmt.Errorf("invalid history view %v", payload.Args)
+	}
+	if len(payload.Args) > 3 {
+		if v.entryID = payload.Int64(3); v.entryID <= 0 {
+			return view{}, fmt.Errorf("invalid entry id %q", payload.String(3))
+		}
+	}
+	return v, nil
+}
+
+// screen - содержимое единственного сообщения истории. Фото задается либо
+// file_id в Telegram (photo), либо сохраненным оригиналом (upload).
+type screen struct {
+	text     string
+	photo    string
+	upload   []byte
+	keyboard *models.InlineKeyboardMarkup
+}
+
+func (sc *screen) hasPhoto() bool {
+	return sc.photo != "" || len(sc.upload) > 0
+}
+
+type HistoryHandler struct {
+	commands.BaseHandler
+	diary    storage.DiaryRepository
+	analyses storage.AnalysisRepository
+	settings storage.SettingsRepository
+	codec    *callback.Codec
+}
+
+func NewHistoryHandler(store storage.Storage, codec *callback.Codec) *HistoryHandler {
+	return &HistoryHandler{
+		BaseHandler: commands.NewBaseHandler(commands.Command{Name: "history"}),
+		diary:       store.Diary(),
+		analyses:    store.Analyses(),
+		settings:    store.Settings(),
+		codec:       codec,
+	}
+}
+
+func (h *HistoryHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	log.Printf("📚 History command received from %s", update.Message.From.Username)
+	locale := i18n.FromContext(ctx)
+
+	v := view{period: "a"}
+	if fields := strings.Fields(update.Message.Text); len(fields) > 1 {
+		period, err := parseRange(fields[1:])
+		if err != nil {
+			log.Printf("Invalid history range %q: %v", fields[1:], err)
+			_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: update.Message.Chat.ID,
+				Text:   i18n.T(locale, "history.range_invalid", nil),
+			})
+			if err != nil {
+				log.Printf("Error sending history range error: %v", err)
+			}
+			return
+		}
+		v.period = period
+	}
+
+	sc, err := h.listScreen(ctx, update.Message.From.ID, v)
+	if err != nil {
+		log.Printf("Error building history: %v", err)
+		sc = &screen{text: i18n.T(locale, "history.load_failed", nil)}
+	}
+
+	params := &bot.SendMessageParams{
+		ChatID:    update.Message.Chat.ID,
+		Text:      sc.text,
+		ParseMode: models.ParseModeHTML,
+	}
+	if sc.keyboard != nil {
+		params.ReplyMarkup = sc.keyboard
+	}
+
+	if _, err := b.SendMessage(ctx, params); err != nil {
+		log.Printf("Error sending history: %v", err)
+	}
+}
+
+// HandleCallback перерисовывает сообщение истории по нажатой кнопке
+func (h *HistoryHandler) HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update, payload callback.Payload) {
+	locale := i18n.FromContext(ctx)
+	query := update.CallbackQuery
+
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: query.ID})
+	if err != nil {
+		log.Printf("Error answering history callback: %v", err)
+	}
+
+	msg := query.Message.Message
+	if msg == nil {
+		return
+	}
+
+	action := payload.Action
+	v, err := decode(payload)
+	if err != nil {
+		log.Printf("Error decoding history callback: %v", err)
+		return
+	}
+
+	if action == actionAnalysis {
+		if err := h.sendAnalysis(ctx, b, msg.Chat.ID, query.From.ID, v); err != nil {
+			log.Printf("Error sending full analysis: %v", err)
+		}
+		return
+	}
+
+	var sc *screen
+	switch action {
+	case actionList:
+		sc, err = h.listScreen(ctx, query.From.ID, v)
+	case actionEntry:
+		sc, err = h.entryScreen(ctx, query.From.ID, v)
+	case actionPhoto:
+		sc, err = h.photoScreen(ctx, query.From.ID, v)
+	case actionPeriodMenu:
+		sc = h.periodMenu(locale, v)
+	case actionBristolMenu:
+		sc = h.bristolMenu(locale, v)
+	case actionRangeHelp:
+		sc = &screen{text: i18n.T(locale, "history.range_help", nil), keyboard: h.backKeyboard(locale, v)}
+	default:
+		err = fmt.Errorf("unknown history action %q", action)
+	}
+	if err != nil {
+		log.Printf("Error building history screen: %v", err)
+		sc = &screen{text: i18n.T(locale, "history.entry_failed", nil), keyboard: h.backKeyboard(locale, v)}
+	}
+
+	show(ctx, b, msg, sc)
+}
+
+func (h *HistoryHandler) GetCallbackRoutes() []callback.Route {
+	return []callback.Route{
+		{Prefix: callbackPrefix, Version: callbackVersion, Handle: h.HandleCallback},
+	}
+}
+
+func (h *HistoryHandler) listScreen(ctx context.Context, userID int64, v view) (*screen, error) {
+	locale := i18n.FromContext(ctx)
+	userSettings, err := h.settings.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc := userSettings.Location()
+
+	filter := storage.DiaryFilter{BristolType: v.bristol}
+	filter.From, filter.To = periodBounds(v.period, loc, time.Now())
+
+	total, err := h.diary.Count(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := (total + pageSize - 1) / pageSize
+	if v.page >= pages && pages > 0 {
+		v.page = pages - 1
+	}
+
+	filter.Limit = pageSize
+	filter.Offset = v.page * pageSize
+	entries, err := h.diary.List(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(locale, "history.title", nil) + "\n\n")
+	sb.WriteString(i18n.T(locale, "history.period", i18n.Params{"value": periodLabel(locale, v.period)}) + "\n")
+	sb.WriteString(i18n.T(locale, "history.type", i18n.Params{"value": bristolFilterLabel(locale, v.bristol)}) + "\n")
+	sb.WriteString(i18n.N(locale, "history.total", total, nil) + "\n")
+	if total == 0 {
+		sb.WriteString("\n" + i18n.T(locale, "history.empty", nil))
+	} else {
+		sb.WriteString("\n" + i18n.T(locale, "history.choose", nil))
+	}
+
+	var rows [][]models.InlineKeyboardButton
+	for _, entry := range entries {
+		ev := v
+		ev.entryID = entry.ID
+		label := entry.OccurredAt.In(loc).Format("02.01 15:04") + " · " + bristol.Title(locale, entry.BristolType)
+		if entry.AnalysisID != 0 {
+			label += " 🔬"
+		}
+		rows = append(rows, []models.InlineKeyboardButton{{Text: label, CallbackData: h.encode(ev, actionEntry)}})
+	}
+
+	if pages > 1 {
+		var nav []models.InlineKeyboardButton
+		if v.page > 0 {
+			prev := v
+			prev.page--
+			nav = append(nav, models.InlineKeyboardButton{Text: "◀️", CallbackData: h.encode(prev, actionList)})
+		}
+		nav = append(nav, models.InlineKeyboardButton{Text: fmt.Sprintf("%d/%d", v.page+1, pages), CallbackData: h.encode(v, actionList)})
+		if v.page < pages-1 {
+			next := v
+			next.page++
+			nav = append(nav, models.InlineKeyboardButton{Text: "▶️", CallbackData: h.encode(next, actionList)})
+		}
+		rows = append(rows, nav)
+	}
+
+	rows = append(rows, []models.InlineKeyboardButton{
+		{Text: i18n.T(locale, "history.button.period", nil), CallbackData: h.encode(v, actionPeriodMenu)},
+		{Text: i18n.T(locale, "history.button.type", nil), CallbackData: h.encode(v, actionBristolMenu)},
+	})
+
+	return &screen{text: sb.String(), keyboard: &models.InlineKeyboardMarkup{InlineKeyboard: rows}}, nil
+}
+
+func (h *HistoryHandler) entryScreen(ctx context.Context, userID int64, v view) (*screen, error) {
+	locale := i18n.FromContext(ctx)
+	entry, analysis, err := h.loadEntry(ctx, userID, v.entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	userSettings, err := h.settings.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(locale, "history.entry_title", nil) + "\n\n")
+	sb.WriteString(flows.FormatDiaryEntry(locale, entry, userSettings.Location()))
+
+	if analysis != nil {
+		sb.WriteString("\n" + i18n.T(locale, "history.analysis", nil) + "\n")
+		sb.WriteString(html.EscapeString(truncate(analysis.Text, maxAnalysisLength)))
+	}
+
+	var rows [][]models.InlineKeyboardButton
+	if analysis != nil && len([]rune(analysis.Text)) > maxAnalysisLength {
+		rows = append(rows, []models.InlineKeyboardButton{{Text: i18n.T(locale, "history.button.full_analysis", nil), CallbackData: h.encode(v, actionAnalysis)}})
+	}
+	if analysis != nil && (analysis.FileID != "" || analysis.ImageHash != "") {
+		rows = append(rows, []models.InlineKeyboardButton{{Text: i18n.T(locale, "history.button.photo", nil), CallbackData: h.encode(v, actionPhoto)}})
+	}
+
+	keyboard := h.backKeyboard(locale, v)
+	keyboard.InlineKeyboard = append(rows, keyboard.InlineKeyboard...)
+	return &screen{text: sb.String(), keyboard: keyboard}, nil
+}
+
+// sendAnalysis присылает полный текст анализа записи отдельными сообщениями,
+// не трогая экран истории
+func (h *HistoryHandler) sendAnalysis(ctx context.Context, b *bot.Bot, chatID, userID int64, v view) error {
+	_, analysis, err := h.loadEntry(ctx, userID, v.entryID)
+	if err != nil {
+		return err
+	}
+	if analysis == nil {
+		return fmt.Errorf("entry %d has no analysis", v.entryID)
+	}
+
+	for _, chunk := range commands.SplitMessage(analysis.Text, commands.MaxMessageLength) {
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: chunk}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *HistoryHandler) photoScreen(ctx context.Context, userID int64, v view) (*screen, error) {
+	entry, analysis, err := h.loadEntry(ctx, userID, v.entryID)
+	if err != nil {
+		return nil, err
+	}
+	if analysis == nil || (analysis.FileID == "" && analysis.ImageHash == "") {
+		return h.entryScreen(ctx, userID, v)
+	}
+
+	sc := &screen{photo: analysis.FileID}
+	if analysis.ImageHash != "" {
+		image, err := h.analyses.Image(ctx, userID, analysis.ID)
+		if err != nil {
+			log.Printf("Error loading kept photo: %v", err)
+		} else {
+			sc.upload = image
+		}
+	}
+	if !sc.hasPhoto() {
+		return nil, fmt.Errorf("photo of analysis %d is unavailable", analysis.ID)
+	}
+
+	userSettings, err := h.settings.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	locale := i18n.FromContext(ctx)
+	caption := "📷 " + entry.OccurredAt.In(userSettings.Location()).Format("02.01.2006 15:04") + "\n" + bristol.Title(locale, entry.BristolType)
+
+	keyboard := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{{Text: i18n.T(locale, "history.button.to_entry", nil), CallbackData: h.encode(v, actionEntry)}},
+	}}
+
+	sc.text = truncate(caption, maxCaptionLength)
+	sc.keyboard = keyboard
+	return sc, nil
+}
+
+func (h *HistoryHandler) loadEntry(ctx context.Context, userID, entryID int64) (*storage.DiaryEntry, *storage.Analysis, error) {
+	entry, err := h.diary.Get(ctx, userID, entryID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if entry.AnalysisID == 0 {
+		return entry, nil, nil
+	}
+
+	analysis, err := h.analyses.Get(ctx, userID, entry.AnalysisID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return entry, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry, analysis, nil
+}
+
+func (h *HistoryHandler) periodMenu(locale string, v view) *screen {
+	var row []models.InlineKeyboardButton
+	for _, p := range periods {
+		pv := v
+		pv.period = p.code
+		pv.page = 0
+		row = append(row, models.InlineKeyboardButton{Text: periodLabel(locale, p.code), CallbackData: h.encode(pv, actionList)})
+	}
+
+	return &screen{
+		text: i18n.T(locale, "history.period_menu", nil),
+		keyboard: &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+			row,
+			{{Text: i18n.T(locale, "history.button.range", nil), CallbackData: h.encode(v, actionRangeHelp)}},
+		}},
+	}
+}
+
+func (h *HistoryHandler) bristolMenu(locale string, v view) *screen {
+	all := v
+	all.bristol = 0
+	all.page = 0
+	rows := [][]models.InlineKeyboardButton{
+		{{Text: i18n.T(locale, "history.button.all_types", nil), CallbackData: h.encode(all, actionList)}},
+	}
+
+	for _, t := range bristol.Types {
+		tv := v
+		tv.bristol = t.Number
+		tv.page = 0
+		rows = append(rows, []models.InlineKeyboardButton{{Text: bristol.Title(locale, t.Number), CallbackData: h.encode(tv, actionList)}})
+	}
+
+	return &screen{
+		text:     i18n.T(locale, "history.type_menu", nil),
+		keyboard: &models.InlineKeyboardMarkup{InlineKeyboard: rows},
+	}
+}
+
+func (h *HistoryHandler) backKeyboard(locale string, v view) *models.InlineKeyboardMarkup {
+	list := v
+	list.entryID = 0
+	return &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{{Text: i18n.T(locale, "history.button.to_list", nil), CallbackData: h.encode(list, actionList)}},
+	}}
+}
+
+// show заменяет содержимое сообщения истории. Telegram не умеет превращать
+// текстовое сообщение в фото и обратно, поэтому в этом случае сообщение пересоздается.
+func show(ctx context.Context, b *bot.Bot, msg *models.Message, sc *screen) {
+	isPhoto := len(msg.Photo) > 0
+
+	switch {
+	case !sc.hasPhoto() && !isPhoto:
+		_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      msg.Chat.ID,
+			MessageID:   msg.ID,
+			Text:        sc.text,
+			ParseMode:   models.ParseModeHTML,
+			ReplyMarkup: sc.keyboard,
+		})
+		if err != nil {
+			log.Printf("Error editing history message: %v", err)
+		}
+		return
+	case sc.hasPhoto() && isPhoto:
+		media := &models.InputMediaPhoto{Media: sc.photo, Caption: sc.text}
+		if len(sc.upload) > 0 {
+			media.Media = "attach://" + uploadName
+			media.MediaAttachment = bytes.NewReader(sc.upload)
+		}
+
+		_, err := b.EditMessageMedia(ctx, &bot.EditMessageMediaParams{
+			ChatID:      msg.Chat.ID,
+			MessageID:   msg.ID,
+			Media:       media,
+			ReplyMarkup: sc.keyboard,
+		})
+		if err != nil {
+			log.Printf("Error editing history photo: %v", err)
+		}
+		return
+	}
+
+	_, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: msg.Chat.ID, MessageID: msg.ID})
+	if err != nil {
+		log.Printf("Error deleting history message: %v", err)
+	}
+
+	if sc.hasPhoto() {
+		var photo models.InputFile = &models.InputFileString{Data: sc.photo}
+		if len(sc.upload) > 0 {
+			photo = &models.InputFileUpload{Filename: uploadName, Data: bytes.NewReader(sc.upload)}
+		}
+
+		_, err = b.SendPhoto(ctx, &bot.SendPhotoParams{
+			ChatID:      msg.Chat.ID,
+			Photo:       photo,
+			Caption:     sc.text,
+			ReplyMarkup: sc.keyboard,
+		})
+	} else {
+		_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      msg.Chat.ID,
+			Text:        sc.text,
+			ParseMode:   models.ParseModeHTML,
+			ReplyMarkup: sc.keyboard,
+		})
+	}
+	if err != nil {
+		log.Printf("Error sending history message: %v", err)
+	}
+}
+
+func periodLabel(locale, code string) string {
+	for _, p := range periods {
+		if p.code == code && p.days > 0 {
+			return i18n.N(locale, "history.period.days", p.days, nil)
+		}
+	}
+	if from, to, ok := rangeDates(code, time.UTC); ok {
+		return i18n.T(locale, "history.period.range", i18n.Params{"from": from.Format(dateLayout), "to": to.Format(dateLayout)})
+	}
+	return i18n.T(locale, "history.period.all", nil)
+}
+
+// periodBounds переводит код периода в границы фильтра [from, to),
+// нулевые границы означают отсутствие ограничения
+func periodBounds(code string, loc *time.Location, now time.Time) (from, to time.Time) {
+	for _, p := range periods {
+		if p.code == code && p.days > 0 {
+			return now.AddDate(0, 0, -p.days), time.Time{}
+		}
+	}
+	if first, last, ok := rangeDates(code, loc); ok {
+		return first, last.AddDate(0, 0, 1)
+	}
+	return time.Time{}, time.Time{}
+}
+
+// parseRange разбирает аргументы /history вида "01.05.2024 15.05.2024" или
+// "01.05.2024-15.05.2024" и возвращает код периода для данных кнопок
+func parseRange(args []string) (string, error) {
+	text := strings.Join(args, " ")
+	for _, dash := range []string{"-", "–", "—"} {
+		text = strings.ReplaceAll(text, dash, " ")
+	}
+
+	dates := strings.Fields(text)
+	if len(dates) != 2 {
+		return "", fmt.Errorf("expected two dates, got %d", len(dates))
+	}
+	from, err := time.Parse(dateLayout, dates[0])
+	if err != nil {
+		return "", err
+	}
+	to, err := time.Parse(dateLayout, dates[1])
+	if err != nil {
+		return "", err
+	}
+	if to.Before(from) {
+		from, to = to, from
+	}
+	return from.Format(rangeLayout) + "-" + to.Format(rangeLayout), nil
+}
+
+// rangeDates разбирает код диапазона дат и возвращает начало первого и последнего дня в loc
+func rangeDates(code string, loc *time.Location) (from, to time.Time, ok bool) {
+	first, last, found := strings.Cut(code, "-")
+	if !found {
+		return time.Time{}, time.Time{}, false
+	}
+	from, err := time.ParseInLocation(rangeLayout, first, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	to, err = time.ParseInLocation(rangeLayout, last, loc)
+	if err != nil || to.Before(from) {
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+func bristolFilterLabel(locale string, number int) string {
+	if number == 0 {
+		return i18n.T(locale, "history.all_types", nil)
+	}
+	return bristol.Title(locale, number)
+}
+
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
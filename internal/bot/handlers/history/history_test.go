@@ -0,0 +1,53 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"01.05.2024", "15.05.2024"}, want: "240501-240515"},
+		{args: []string{"01.05.2024-15.05.2024"}, want: "240501-240515"},
+		{args: []string{"01.05.2024", "–", "15.05.2024"}, want: "240501-240515"},
+		{args: []string{"15.05.2024", "01.05.2024"}, want: "240501-240515"},
+	}
+	for _, tt := range tests {
+		got, err := parseRange(tt.args)
+		if err != nil || got != tt.want {
+			t.Errorf("parseRange(%q) = %q, %v; want %q", tt.args, got, err, tt.want)
+		}
+	}
+
+	for _, args := range [][]string{{"01.05.2024"}, {"01.05", "15.05"}, {"вчера", "сегодня"}} {
+		if _, err := parseRange(args); err == nil {
+			t.Errorf("parseRange(%q) accepted an invalid period", args)
+		}
+	}
+}
+
+func TestPeriodBounds(t *testing.T) {
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, loc)
+
+	from, to := periodBounds("240501-240515", loc, now)
+	if want := time.Date(2024, 5, 1, 0, 0, 0, 0, loc); !from.Equal(want) {
+		t.Errorf("from = %v, want %v", from, want)
+	}
+	if want := time.Date(2024, 5, 16, 0, 0, 0, 0, loc); !to.Equal(want) {
+		t.Errorf("to = %v, want %v: the last day must be included", to, want)
+	}
+
+	from, to = periodBounds("7", loc, now)
+	if !from.Equal(now.AddDate(0, 0, -7)) || !to.IsZero() {
+		t.Errorf("periodBounds(7) = %v, %v", from, to)
+	}
+
+	from, to = periodBounds("a", loc, now)
+	if !from.IsZero() || !to.IsZero() {
+		t.Errorf("periodBounds(a) = %v, %v; want no bounds", from, to)
+	}
+}
@@ -0,0 +1,141 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/consent"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// consentRequired возвращает последнее согласие пользователя (nil, если его не было)
+// и сообщает, нужно ли запросить согласие. При ошибке хранилища согласие
+// запрашивается заново: анализ без подтвержденного согласия недопустим.
+func (h *PhotoHandler) consentRequired(ctx context.Context, userID int64) (*storage.Consent, bool) {
+	accepted, err := h.consents.Latest(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Error loading consent: %v", err)
+		}
+		return nil, true
+	}
+	return accepted, consent.Required(accepted)
+}
+
+// askConsent показывает условия: правила, обработку данных и предупреждение, что бот не врач
+func (h *PhotoHandler) askConsent(ctx context.Context, b *bot.Bot, chatID int64, accepted *storage.Consent) {
+	locale := i18n.FromContext(ctx)
+
+	title := "consent.title_first"
+	if accepted != nil {
+		title = "consent.title_updated"
+	}
+	// обещать шифрование можно, только если мастер-ключ действительно задан
+	storageKey := "consent.storage_plain"
+	if h.encrypted {
+		storageKey = "consent.storage_encrypted"
+	}
+	text := i18n.T(locale, title, nil) + "\n\n" +
+		i18n.T(locale, "consent.body", i18n.Params{
+			"version": consent.Version,
+			"storage": i18n.T(locale, storageKey, nil),
+		})
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+			{{Text: i18n.T(locale, "consent.accept", nil), CallbackData: h.codec.Encode(consent.CallbackPrefix, consent.CallbackVersion, consent.ActionAccept, consent.Version)}},
+			{{Text: i18n.T(locale, "consent.decline", nil), CallbackData: h.codec.Encode(consent.CallbackPrefix, consent.CallbackVersion, consent.ActionDecline)}},
+		}},
+	})
+	if err != nil {
+		log.Printf("Error sending consent screen: %v", err)
+	}
+}
+
+// handleConsent сохраняет ответ на экране согласия и продолжает обработку отложенного фото
+func (h *PhotoHandler) handleConsent(ctx context.Context, b *bot.Bot, update *models.Update, payload callback.Payload) {
+	query := update.CallbackQuery
+	msg := query.Message.Message
+	locale := i18n.FromContext(ctx)
+
+	action := payload.Action
+	if msg == nil || (action != consent.ActionAccept && action != consent.ActionDecline) {
+		h.answerConsent(ctx, b, query.ID, "")
+		return
+	}
+	// экран согласия показывается только в личном чате и относится к его владельцу:
+	// кнопки старых экранов из групп не трогают чужие фото
+	if msg.Chat.ID != query.From.ID {
+		h.answerConsent(ctx, b, query.ID, "")
+		return
+	}
+	chatID := msg.Chat.ID
+
+	if action == consent.ActionDecline {
+		log.Printf("📜 Consent declined by @%s", query.From.Username)
+		h.answerConsent(ctx, b, query.ID, "")
+		h.takePending(chatID, query.From.ID)
+		h.closeConsent(ctx, b, msg, i18n.T(locale, "consent.declined", nil))
+		return
+	}
+
+	// кнопка со старой версией условий: показываем актуальный текст
+	if payload.Int(0) != consent.Version {
+		h.answerConsent(ctx, b, query.ID, i18n.T(locale, "consent.outdated", nil))
+		accepted, _ := h.consentRequired(ctx, query.From.ID)
+		h.askConsent(ctx, b, chatID, accepted)
+		return
+	}
+
+	err := h.consents.Accept(ctx, &storage.Consent{UserID: query.From.ID, Version: consent.Version})
+	if err != nil {
+		log.Printf("Error saving consent: %v", err)
+		h.answerConsent(ctx, b, query.ID, i18n.T(locale, "consent.failed", nil))
+		return
+	}
+	log.Printf("📜 Consent v%d accepted by @%s", consent.Version, query.From.Username)
+	h.answerConsent(ctx, b, query.ID, "")
+	h.closeConsent(ctx, b, msg, i18n.T(locale, "consent.accepted", i18n.Params{"version": consent.Version}))
+
+	// продолжаем только с фото того, кто принял условия
+	photo, ok := h.takePending(chatID, query.From.ID)
+	if !ok {
+		return
+	}
+	if photo.caption != "" {
+		h.analyze(ctx, b, chatID, query.From.ID, photo.fileID, photo.caption)
+		return
+	}
+	h.setPending(chatID, query.From.ID, photo.fileID, "")
+	h.askClarify(ctx, b, chatID)
+}
+
+func (h *PhotoHandler) answerConsent(ctx context.Context, b *bot.Bot, queryID, text string) {
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: queryID,
+		Text:            text,
+	})
+	if err != nil {
+		log.Printf("Error answering consent callback: %v", err)
+	}
+}
+
+// closeConsent заменяет экран согласия итогом и убирает кнопки
+func (h *PhotoHandler) closeConsent(ctx context.Context, b *bot.Bot, msg *models.Message, text string) {
+	_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    msg.Chat.ID,
+		MessageID: msg.ID,
+		Text:      text,
+	})
+	if err != nil {
+		log.Printf("Error closing consent screen: %v", err)
+	}
+}
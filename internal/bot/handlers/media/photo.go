@@ -0,0 +1,412 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/handlers/callbacks"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/handlers/commands"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/bristol"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/consent"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/gemini"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/medications"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/profile"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/session"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// pendingTTL - сколько фото ждет ответа на уточняющий вопрос
+const pendingTTL = 15 * time.Minute
+
+const (
+	// clarifyPrefix - префикс кнопок уточняющего вопроса к фото
+	clarifyPrefix  = "clarify:"
+	clarifyVersion = 1
+)
+
+// clarifyOption - вариант ответа на уточняющий вопрос. Подпись кнопки - photo.clarify.<key>,
+// текст для модели - photo.clarify_context.<key>, если hasContext.
+type clarifyOption struct {
+	key        string
+	hasContext bool
+}
+
+var clarifyOptions = []clarifyOption{
+	{key: "diet", hasContext: true},
+	{key: "meds", hasContext: true},
+	{key: "symptoms", hasContext: true},
+	{key: "none"},
+}
+
+// context возвращает пояснение к фото для модели на языке пользователя
+func (o clarifyOption) context(locale string) string {
+	if !o.hasContext {
+		return ""
+	}
+	return i18n.T(locale, "photo.clarify_context."+o.key, nil)
+}
+
+// pendingKey - фото ждет ответа своего автора: в одном чате их может быть несколько
+type pendingKey struct {
+	chatID int64
+	userID int64
+}
+
+type pendingPhoto struct {
+	fileID   string
+	caption  string
+	received time.Time
+}
+
+type PhotoHandler struct {
+	gemini      *gemini.GeminiService
+	analyses    storage.AnalysisRepository
+	settings    storage.SettingsRepository
+	medications storage.MedicationRepository
+	profiles    storage.ProfileRepository
+	consents    storage.ConsentRepository
+	encrypted   bool
+	codec       *callback.Codec
+	httpClient  *http.Client
+
+	mu      sync.Mutex
+	pending map[pendingKey]pendingPhoto
+}
+
+func NewPhotoHandler(geminiService *gemini.GeminiService, store storage.Storage, codec *callback.Codec, timeout time.Duration) *PhotoHandler {
+	return &PhotoHandler{
+		gemini:      geminiService,
+		analyses:    store.Analyses(),
+		settings:    store.Settings(),
+		medications: store.Medications(),
+		profiles:    store.Profiles(),
+		consents:    store.Consents(),
+		encrypted:   store.Encrypted(),
+		codec:       codec,
+		httpClient:  &http.Client{Timeout: timeout},
+		pending:     make(map[pendingKey]pendingPhoto),
+	}
+}
+
+// Match проверяет, что в обновлении есть фото из личного чата:
+// медицинские снимки и их разбор не должны попадать в группы
+func (h *PhotoHandler) Match(update *models.Update) bool {
+	msg := update.Message
+	return msg != nil && msg.From != nil && msg.Chat.Type == models.ChatTypePrivate && len(msg.Photo) > 0
+}
+
+func (h *PhotoHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	msg := update.Message
+	log.Printf("📸 Photo received from @%s", senderName(msg.From))
+
+	userID := msg.From.ID
+	photo := msg.Photo[len(msg.Photo)-1]
+	caption := strings.TrimSpace(msg.Caption)
+
+	// без принятых условий фото не анализируется: оно ждет ответа на экране согласия
+	if accepted, required := h.consentRequired(ctx, userID); required {
+		h.setPending(msg.Chat.ID, userID, photo.FileID, caption)
+		h.askConsent(ctx, b, msg.Chat.ID, accepted)
+		return
+	}
+
+	if caption != "" {
+		h.analyze(ctx, b, msg.Chat.ID, userID, photo.FileID, caption)
+		return
+	}
+
+	h.setPending(msg.Chat.ID, userID, photo.FileID, "")
+	h.askClarify(ctx, b, msg.Chat.ID)
+}
+
+func (h *PhotoHandler) setPending(chatID, userID int64, fileID, caption string) {
+	h.mu.Lock()
+	h.pending[pendingKey{chatID: chatID, userID: userID}] = pendingPhoto{
+		fileID:   fileID,
+		caption:  caption,
+		received: time.Now(),
+	}
+	h.mu.Unlock()
+}
+
+// takePending забирает фото пользователя, ожидающее ответа, если оно еще не устарело.
+// Чужое фото не выдается: нажавший кнопку получает только свое.
+func (h *PhotoHandler) takePending(chatID, userID int64) (pendingPhoto, bool) {
+	key := pendingKey{chatID: chatID, userID: userID}
+
+	h.mu.Lock()
+	photo, ok := h.pending[key]
+	delete(h.pending, key)
+	h.mu.Unlock()
+
+	return photo, ok && time.Since(photo.received) <= pendingTTL
+}
+
+// ForgetUser убирает фото пользователя, ожидающие ответа, во всех чатах
+func (h *PhotoHandler) ForgetUser(_ context.Context, userID int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for key := range h.pending {
+		if key.userID == userID {
+			delete(h.pending, key)
+		}
+	}
+	return nil
+}
+
+// askClarify задает уточняющий вопрос к фото без подписи
+func (h *PhotoHandler) askClarify(ctx context.Context, b *bot.Bot, chatID int64) {
+	locale := i18n.FromContext(ctx)
+
+	var rows [][]models.InlineKeyboardButton
+	for _, opt := range clarifyOptions {
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: i18n.T(locale, "photo.clarify."+opt.key, nil), CallbackData: h.codec.Encode(clarifyPrefix, clarifyVersion, opt.key)},
+		})
+	}
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        i18n.T(locale, "photo.clarify_question", nil),
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: rows},
+	})
+	if err != nil {
+		log.Printf("Error sending clarify question: %v", err)
+	}
+}
+
+func (h *PhotoHandler) handleClarify(ctx context.Context, b *bot.Bot, update *models.Update, payload callback.Payload) {
+	query := update.CallbackQuery
+	log.Printf("📝 Clarify answer %s from @%s", payload.Action, query.From.Username)
+
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: query.ID,
+	})
+	if err != nil {
+		log.Printf("Error answering clarify callback: %v", err)
+	}
+
+	opt, ok := findClarifyOption(payload.Action)
+	if !ok || query.Message.Message == nil {
+		return
+	}
+	chatID := query.Message.Message.Chat.ID
+
+	photo, ok := h.takePending(chatID, query.From.ID)
+	if !ok {
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   i18n.T(i18n.FromContext(ctx), "photo.expired", nil),
+		})
+		if err != nil {
+			log.Printf("Error sending expired photo message: %v", err)
+		}
+		return
+	}
+
+	_, err = b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:    chatID,
+		MessageID: query.Message.Message.ID,
+	})
+	if err != nil {
+		log.Printf("Error removing clarify keyboard: %v", err)
+	}
+
+	h.analyze(ctx, b, chatID, query.From.ID, photo.fileID, opt.context(i18n.FromContext(ctx)))
+}
+
+func findClarifyOption(key string) (clarifyOption, bool) {
+	for _, opt := range clarifyOptions {
+		if opt.key == key {
+			return opt, true
+		}
+	}
+	return clarifyOption{}, false
+}
+
+func (h *PhotoHandler) analyze(ctx context.Context, b *bot.Bot, chatID, userID int64, fileID string, userContext string) {
+	locale := i18n.FromContext(ctx)
+
+	_, err := b.SendChatAction(ctx, &bot.SendChatActionParams{
+		ChatID: chatID,
+		Action: models.ChatActionTyping,
+	})
+	if err != nil {
+		log.Printf("Error sending chat action: %v", err)
+	}
+
+	imageBytes, err := h.downloadFile(ctx, b, fileID)
+	if err != nil {
+		log.Printf("Error downloading photo: %v", err)
+		sendText(ctx, b, chatID, i18n.T(locale, "photo.download_failed", nil))
+		return
+	}
+
+	userSettings, err := h.settings.Get(ctx, userID)
+	if err != nil {
+		log.Printf("Error loading settings: %v", err)
+		userSettings = storage.DefaultSettings(userID)
+	}
+	current := h.currentMedications(ctx, userID)
+	userProfile := h.profile(ctx, userID)
+
+	result, err := h.gemini.AnalyzeImageWithContext(ctx, imageBytes, http.DetectContentType(imageBytes), gemini.AnalysisContext{
+		UserContext: userContext,
+		Medications: medications.PromptLines(current),
+		Profile:     profile.PromptLines(userProfile),
+		Detail:      profile.DetailPrompt(userProfile),
+		Language:    locale,
+	})
+	if err != nil {
+		log.Printf("Error analyzing photo: %v", err)
+		sendText(ctx, b, chatID, i18n.T(locale, "photo.analysis_failed", nil))
+		return
+	}
+
+	analysis := &storage.Analysis{
+		UserID:          userID,
+		FileID:          fileID,
+		UserContext:     userContext,
+		Text:            result.Text,
+		Diagnosis:       result.Diagnosis,
+		Recommendations: result.Recommendations,
+		BristolType:     bristol.ExtractFromText(result.Text),
+	}
+	// в режиме инкогнито ни анализ, ни фото не сохраняются
+	if !userSettings.Incognito {
+		if err := h.analyses.Create(ctx, analysis); err != nil {
+			log.Printf("Error saving analysis: %v", err)
+		} else if userSettings.KeepImages {
+			h.keepImage(ctx, userID, analysis.ID, imageBytes)
+		}
+	}
+
+	chunks := commands.SplitMessage(result.Text, commands.MaxMessageLength)
+	for i, chunk := range chunks {
+		params := &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   chunk,
+		}
+		// Кнопка сохранения в дневник - под последней частью ответа
+		if i == len(chunks)-1 && analysis.ID != 0 {
+			params.ReplyMarkup = &models.InlineKeyboardMarkup{
+				InlineKeyboard: [][]models.InlineKeyboardButton{
+					{{Text: i18n.T(locale, "photo.button_save", nil), CallbackData: h.codec.Encode(callbacks.DiarySavePrefix, callbacks.DiarySaveVersion, callbacks.DiarySaveAction, analysis.ID)}},
+				},
+			}
+		}
+
+		if _, err := b.SendMessage(ctx, params); err != nil {
+			log.Printf("Error sending analysis result: %v", err)
+		}
+	}
+
+	annotations := medications.Explain(current, result.Diagnosis, analysis.BristolType)
+	if len(annotations) > 0 {
+		var sb strings.Builder
+		sb.WriteString(i18n.T(locale, "photo.medications_header", nil) + "\n")
+		for _, a := range annotations {
+			sb.WriteString("• " + a.Text(locale) + "\n")
+		}
+		sb.WriteString("\n" + i18n.T(locale, "photo.medications_footer", nil))
+		sendText(ctx, b, chatID, sb.String())
+	}
+
+	if userSettings.Incognito {
+		sendText(ctx, b, chatID, i18n.T(locale, "photo.incognito", nil))
+	}
+}
+
+// profile возвращает анкету пользователя или nil, если она не заполнена
+func (h *PhotoHandler) profile(ctx context.Context, userID int64) *storage.Profile {
+	// анкету обычно уже загрузил userMiddleware
+	if p := session.Profile(ctx); p != nil && p.UserID == userID {
+		return p
+	}
+	p, err := h.profiles.Get(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Error loading profile: %v", err)
+		}
+		return nil
+	}
+	return p
+}
+
+// currentMedications возвращает препараты, которые пользователь принимает сейчас
+func (h *PhotoHandler) currentMedications(ctx context.Context, userID int64) []storage.Medication {
+	list, err := h.medications.List(ctx, userID)
+	if err != nil {
+		log.Printf("Error loading medications: %v", err)
+		return nil
+	}
+	return medications.Active(list, time.Now())
+}
+
+// keepImage сохраняет оригинал фото к анализу
+func (h *PhotoHandler) keepImage(ctx context.Context, userID, analysisID int64, image []byte) {
+	if err := h.analyses.AttachImage(ctx, userID, analysisID, image); err != nil {
+		log.Printf("Error keeping photo: %v", err)
+	}
+}
+
+func (h *PhotoHandler) downloadFile(ctx context.Context, b *bot.Bot, fileID string) ([]byte, error) {
+	file, err := b.GetFile(ctx, &bot.GetFileParams{FileID: fileID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.FileDownloadLink(file), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// GetCallbackRoutes возвращает обработчики кнопок экрана согласия и уточняющего вопроса
+func (h *PhotoHandler) GetCallbackRoutes() []callback.Route {
+	return []callback.Route{
+		{Prefix: consent.CallbackPrefix, Version: consent.CallbackVersion, Handle: h.handleConsent},
+		{Prefix: clarifyPrefix, Version: clarifyVersion, Handle: h.handleClarify},
+	}
+}
+
+func sendText(ctx context.Context, b *bot.Bot, chatID int64, text string) {
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   text,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
+
+func senderName(user *models.User) string {
+	if user == nil || user.Username == "" {
+		return "anonymous"
+	}
+	return user.Username
+}
@@ -0,0 +1,56 @@
+package media
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func newTestHandler() *PhotoHandler {
+	return &PhotoHandler{pending: make(map[pendingKey]pendingPhoto)}
+}
+
+func TestMatchOnlyPrivatePhotos(t *testing.T) {
+	h := newTestHandler()
+	photo := []models.PhotoSize{{FileID: "file"}}
+
+	private := &models.Update{Message: &models.Message{
+		Chat:  models.Chat{ID: 1, Type: models.ChatTypePrivate},
+		From:  &models.User{ID: 1},
+		Photo: photo,
+	}}
+	if !h.Match(private) {
+		t.Error("photo from a private chat is not analyzed")
+	}
+
+	group := &models.Update{Message: &models.Message{
+		Chat:  models.Chat{ID: -100, Type: models.ChatTypeSupergroup},
+		From:  &models.User{ID: 1},
+		Photo: photo,
+	}}
+	if h.Match(group) {
+		t.Error("photo from a group is analyzed")
+	}
+}
+
+func TestTakePendingOnlyOwnPhoto(t *testing.T) {
+	h := newTestHandler()
+	const chatID = -100
+	h.setPending(chatID, 1, "first", "")
+	h.setPending(chatID, 2, "second", "")
+
+	if _, ok := h.takePending(chatID, 3); ok {
+		t.Fatal("user without a photo got someone else's")
+	}
+	if photo, ok := h.takePending(chatID, 2); !ok || photo.fileID != "second" {
+		t.Fatalf("takePending = %q, %v; want the user's own photo", photo.fileID, ok)
+	}
+
+	if err := h.ForgetUser(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := h.takePending(chatID, 1); ok {
+		t.Error("photo of a forgotten user is still pending")
+	}
+}
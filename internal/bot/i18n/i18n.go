@@ -0,0 +1,227 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale - язык, на котором бот отвечает, если язык пользователя не поддерживается
+const DefaultLocale = "ru"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Params - значения именованных подстановок вида {name}
+type Params map[string]any
+
+// message - текст сообщения или его формы для разных чисел
+type message struct {
+	text   string
+	plural map[string]string
+}
+
+func (m *message) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &m.text); err == nil {
+		return nil
+	}
+	return json.Unmarshal(data, &m.plural)
+}
+
+// Catalog - сообщения бота на всех поддерживаемых языках
+type Catalog struct {
+	messages map[string]map[string]message
+}
+
+// Load читает каталог из файлов <язык>.json
+func Load(fsys fs.FS) (*Catalog, error) {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Catalog{messages: make(map[string]map[string]message)}
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		var messages map[string]message
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		c.messages[strings.TrimSuffix(path.Base(name), ".json")] = messages
+	}
+
+	if _, ok := c.messages[DefaultLocale]; !ok {
+		return nil, fmt.Errorf("catalog for default locale %q is missing", DefaultLocale)
+	}
+	return c, nil
+}
+
+// defaultCatalog - встроенный каталог. Файлы вшиваются при сборке,
+// поэтому ошибка в них - ошибка программы, а не окружения.
+var defaultCatalog = func() *Catalog {
+	sub, err := fs.Sub(localeFiles, "locales")
+	if err != nil {
+		panic(err)
+	}
+	c, err := Load(sub)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}()
+
+// Supported сообщает, есть ли каталог для языка
+func Supported(locale string) bool {
+	_, ok := defaultCatalog.messages[locale]
+	return ok
+}
+
+// Locales возвращает поддерживаемые языки по алфавиту
+func Locales() []string {
+	locales := make([]string, 0, len(defaultCatalog.messages))
+	for locale := range defaultCatalog.messages {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Resolve выбирает язык: сначала из настроек, потом из языка Telegram
+// (например, "en-US" -> "en"), иначе DefaultLocale
+func Resolve(preferred, telegramCode string) string {
+	if Supported(preferred) {
+		return preferred
+	}
+	base, _, _ := strings.Cut(strings.ToLower(telegramCode), "-")
+	if Supported(base) {
+		return base
+	}
+	return DefaultLocale
+}
+
+// T возвращает сообщение key на языке locale с подстановками params
+func T(locale, key string, params Params) string {
+	return defaultCatalog.T(locale, key, params)
+}
+
+// N возвращает форму сообщения key для числа n. Число доступно как {count}.
+func N(locale, key string, n int, params Params) string {
+	return defaultCatalog.N(locale, key, n, params)
+}
+
+// T возвращает сообщение из каталога. Если перевода нет, берется DefaultLocale,
+// если нет и его - сам ключ, чтобы пропуск было видно, но бот не падал.
+func (c *Catalog) T(locale, key string, params Params) string {
+	m, ok := c.lookup(locale, key)
+	if !ok {
+		return key
+	}
+	text := m.text
+	if m.plural != nil {
+		text = m.plural["other"]
+	}
+	return substitute(text, params)
+}
+
+// N возвращает форму сообщения по правилам множественного числа языка
+func (c *Catalog) N(locale, key string, n int, params Params) string {
+	m, ok := c.lookup(locale, key)
+	if !ok {
+		return key
+	}
+
+	text := m.text
+	if m.plural != nil {
+		if !Supported(locale) {
+			locale = DefaultLocale
+		}
+		form, ok := m.plural[pluralForm(locale, n)]
+		if !ok {
+			form = m.plural["other"]
+		}
+		text = form
+	}
+
+	all := Params{"count": n}
+	for k, v := range params {
+		all[k] = v
+	}
+	return substitute(text, all)
+}
+
+func (c *Catalog) lookup(locale, key string) (message, bool) {
+	if m, ok := c.messages[locale][key]; ok {
+		return m, true
+	}
+	m, ok := c.messages[DefaultLocale][key]
+	return m, ok
+}
+
+// pluralForm возвращает категорию CLDR для числа n: one, few, many или other
+func pluralForm(locale string, n int) string {
+	if n < 0 {
+		n = -n
+	}
+
+	switch locale {
+	case "ru":
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return "one"
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	default:
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+}
+
+func substitute(text string, params Params) string {
+	if len(params) == 0 {
+		return text
+	}
+
+	pairs := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		var s string
+		switch v := value.(type) {
+		case string:
+			s = v
+		case int:
+			s = strconv.Itoa(v)
+		default:
+			s = fmt.Sprint(v)
+		}
+		pairs = append(pairs, "{"+name+"}", s)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+type contextKey struct{}
+
+// WithLocale сохраняет язык пользователя в контексте обработки обновления
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+// FromContext возвращает язык из контекста или DefaultLocale
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(contextKey{}).(string); ok && locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
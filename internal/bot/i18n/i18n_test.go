@@ -0,0 +1,132 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+// pluralForms - формы, которые должны быть у каждого сообщения с числом
+var pluralForms = map[string][]string{
+	"ru": {"one", "few", "many"},
+	"en": {"one", "other"},
+}
+
+var placeholder = regexp.MustCompile(`\{(\w+)\}`)
+
+func TestLocalesHaveSameKeys(t *testing.T) {
+	base := defaultCatalog.messages[DefaultLocale]
+
+	for _, locale := range Locales() {
+		messages := defaultCatalog.messages[locale]
+		for key := range base {
+			if _, ok := messages[key]; !ok {
+				t.Errorf("%s: missing key %q", locale, key)
+			}
+		}
+		for key := range messages {
+			if _, ok := base[key]; !ok {
+				t.Errorf("%s: key %q is not in %s", locale, key, DefaultLocale)
+			}
+		}
+	}
+}
+
+func TestPluralMessagesHaveAllForms(t *testing.T) {
+	for _, locale := range Locales() {
+		forms, ok := pluralForms[locale]
+		if !ok {
+			t.Errorf("%s: plural forms are not listed in the test", locale)
+			continue
+		}
+
+		for key, m := range defaultCatalog.messages[locale] {
+			if base := defaultCatalog.messages[DefaultLocale][key]; (m.plural == nil) != (base.plural == nil) {
+				t.Errorf("%s: %q is plural in one locale and plain in another", locale, key)
+			}
+			if m.plural == nil {
+				continue
+			}
+			for _, form := range forms {
+				if m.plural[form] == "" {
+					t.Errorf("%s: %q has no %q form", locale, key, form)
+				}
+			}
+		}
+	}
+}
+
+func TestLocalesUseSamePlaceholders(t *testing.T) {
+	for _, locale := range Locales() {
+		for key, m := range defaultCatalog.messages[locale] {
+			base := defaultCatalog.messages[DefaultLocale][key]
+			got, want := placeholders(m), placeholders(base)
+			if !slices.Equal(got, want) {
+				t.Errorf("%s: %q uses %v, %s uses %v", locale, key, got, DefaultLocale, want)
+			}
+		}
+	}
+}
+
+func TestNRussianForms(t *testing.T) {
+	c, err := Load(fstest.MapFS{
+		"ru.json": {Data: []byte(`{"days": {"one": "{count} день", "few": "{count} дня", "many": "{count} дней"}}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for n, want := range map[int]string{
+		1:   "1 день",
+		2:   "2 дня",
+		5:   "5 дней",
+		11:  "11 дней",
+		12:  "12 дней",
+		21:  "21 день",
+		22:  "22 дня",
+		111: "111 дней",
+	} {
+		if got := c.N("ru", "days", n, nil); got != want {
+			t.Errorf("N(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestFallbacks(t *testing.T) {
+	c, err := Load(fstest.MapFS{
+		"ru.json": {Data: []byte(`{"hello": "Привет, {name}"}`)},
+		"en.json": {Data: []byte(`{}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := c.T("en", "hello", Params{"name": "Ann"}); got != "Привет, Ann" {
+		t.Errorf("missing translation = %q, want the default locale text", got)
+	}
+	if got := c.T("en", "missing", nil); got != "missing" {
+		t.Errorf("missing key = %q, want the key itself", got)
+	}
+}
+
+// placeholders возвращает отсортированные имена подстановок во всех формах сообщения
+func placeholders(m message) []string {
+	texts := []string{m.text}
+	for _, form := range m.plural {
+		texts = append(texts, form)
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, text := range texts {
+		for _, match := range placeholder.FindAllStringSubmatch(text, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				names = append(names, match[1])
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,326 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/dispatcher"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/session"
+	"github.com/merdernoty/stool-guru-bot/internal/config"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// middlewareStep - звено обработки обновлений, которое можно выключить в конфигурации
+type middlewareStep struct {
+	name       string
+	enabled    bool
+	middleware bot.Middleware
+}
+
+// buildMiddlewares собирает звенья в порядке списка: первое оборачивает все остальные.
+// Порядок важен: recover ловит панику в любом звене, бан и лимит отсекают
+// обновление до записи в базу, язык выбирается по уже загруженным настройкам.
+func buildMiddlewares(cfg *config.Config, store storage.Storage, updates *dispatcher.Dispatcher) []bot.Middleware {
+	steps := []middlewareStep{
+		// очередь чата - всегда первая: остальные звенья выполняются уже внутри задачи
+		{name: "dispatch", enabled: true, middleware: dispatchMiddleware(updates)},
+		{name: "recover", enabled: cfg.RecoverPanics, middleware: recoverMiddleware},
+		{name: "logging", enabled: cfg.LogUpdates, middleware: loggingMiddleware(cfg.Debug)},
+		{name: "timing", enabled: cfg.TimeUpdates, middleware: timingMiddleware(cfg.SlowUpdate)},
+		{name: "bans", enabled: cfg.CheckBans && len(cfg.BannedIDs) > 0, middleware: banMiddleware(cfg.BannedIDs)},
+		{name: "ratelimit", enabled: cfg.RateLimitPerMinute > 0, middleware: rateLimitMiddleware(cfg.RateLimitPerMinute, time.Minute)},
+		{name: "users", enabled: cfg.LoadUsers, middleware: userMiddleware(store.Users(), store.Profiles())},
+		{name: "locale", enabled: cfg.ResolveLocale, middleware: localeMiddleware(store.Settings())},
+	}
+
+	var middlewares []bot.Middleware
+	var names []string
+	for _, step := range steps {
+		if !step.enabled {
+			continue
+		}
+		middlewares = append(middlewares, step.middleware)
+		names = append(names, step.name)
+	}
+
+	log.Printf("🧩 Middlewares: %s", strings.Join(names, " → "))
+	return middlewares
+}
+
+// updateSender возвращает автора обновления, nil - если его нет (например, пост в канале)
+func updateSender(update *models.Update) *models.User {
+	switch {
+	case update.Message != nil:
+		return update.Message.From
+	case update.CallbackQuery != nil:
+		return &update.CallbackQuery.From
+	}
+	return nil
+}
+
+// updateChat возвращает чат обновления, 0 - если ответить некуда
+func updateChat(update *models.Update) int64 {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message.Message != nil:
+		return update.CallbackQuery.Message.Message.Chat.ID
+	}
+	return 0
+}
+
+func updateKind(update *models.Update) string {
+	switch {
+	case update.Message != nil && len(update.Message.Photo) > 0:
+		return "photo"
+	case update.Message != nil:
+		return "message"
+	case update.CallbackQuery != nil:
+		return "callback"
+	}
+	return "other"
+}
+
+// dispatchMiddleware ставит обновление в очередь его чата и сразу возвращается:
+// обновления одного чата обрабатываются по порядку, разных чатов - параллельно.
+// Обновления без чата и автора ни с чем не упорядочиваются и выполняются сразу.
+func dispatchMiddleware(updates *dispatcher.Dispatcher) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			job := func() { next(ctx, b, update) }
+
+			key := updateChat(update)
+			if from := updateSender(update); key == 0 && from != nil {
+				key = from.ID
+			}
+			if key == 0 {
+				if !updates.Go(job) {
+					log.Printf("🚧 Dropped update=%d: dispatcher is closed", update.ID)
+				}
+				return
+			}
+
+			if !updates.Dispatch(key, job) {
+				log.Printf("🚧 Dropped update=%d chat=%d: queue is full", update.ID, key)
+			}
+		}
+	}
+}
+
+// recoverMiddleware не дает панике в обработчике уронить бота и извиняется перед пользователем
+func recoverMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			log.Printf("💥 Panic while handling update %d: %v\n%s", update.ID, r, debug.Stack())
+
+			chatID := updateChat(update)
+			if chatID == 0 {
+				return
+			}
+			// язык из настроек кладут в контекст внутренние звенья, здесь его еще нет
+			locale := i18n.DefaultLocale
+			if from := updateSender(update); from != nil {
+				locale = i18n.Resolve("", from.LanguageCode)
+			}
+			_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   i18n.T(locale, "update.panic", nil),
+			})
+			if err != nil {
+				log.Printf("Error sending apology: %v", err)
+			}
+		}()
+		next(ctx, b, update)
+	}
+}
+
+// loggingMiddleware пишет в лог каждое обновление полями key=value.
+// Текст сообщений - данные о здоровье, поэтому он попадает в лог только в debug-режиме.
+func loggingMiddleware(debugMode bool) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			fields := []string{
+				fmt.Sprintf("update=%d", update.ID),
+				"kind=" + updateKind(update),
+			}
+			if from := updateSender(update); from != nil {
+				fields = append(fields, fmt.Sprintf("user=%d", from.ID))
+				if debugMode && from.Username != "" {
+					fields = append(fields, "username=@"+from.Username)
+				}
+			}
+			if chatID := updateChat(update); chatID != 0 {
+				fields = append(fields, fmt.Sprintf("chat=%d", chatID))
+			}
+			if debugMode {
+				switch {
+				case update.Message != nil:
+					fields = append(fields, fmt.Sprintf("text=%q", update.Message.Text))
+				case update.CallbackQuery != nil:
+					fields = append(fields, fmt.Sprintf("data=%q", update.CallbackQuery.Data))
+				}
+			}
+
+			log.Printf("📨 %s", strings.Join(fields, " "))
+			next(ctx, b, update)
+		}
+	}
+}
+
+// timingMiddleware предупреждает об обновлениях, которые обрабатывались дольше slow
+func timingMiddleware(slow time.Duration) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			started := time.Now()
+			next(ctx, b, update)
+
+			if elapsed := time.Since(started); elapsed >= slow {
+				log.Printf("🐢 Slow update=%d kind=%s took=%v", update.ID, updateKind(update), elapsed.Round(time.Millisecond))
+			}
+		}
+	}
+}
+
+// banMiddleware молча отбрасывает обновления от пользователей из BANNED_IDS
+func banMiddleware(bannedIDs []int64) bot.Middleware {
+	banned := make(map[int64]bool, len(bannedIDs))
+	for _, id := range bannedIDs {
+		banned[id] = true
+	}
+
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			if from := updateSender(update); from != nil && banned[from.ID] {
+				log.Printf("🚫 Dropped update=%d from banned user=%d", update.ID, from.ID)
+				return
+			}
+			next(ctx, b, update)
+		}
+	}
+}
+
+// rateLimiter считает обновления пользователя в текущем окне
+type rateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	started time.Time
+	counts  map[int64]int
+}
+
+// allow учитывает обновление и сообщает, укладывается ли пользователь в лимит.
+// notify - первое обновление сверх лимита в окне: о нем стоит предупредить.
+func (l *rateLimiter) allow(userID int64, now time.Time) (allowed, notify bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// новое окно - счетчики всех пользователей начинаются заново
+	if now.Sub(l.started) >= l.window {
+		l.started = now
+		l.counts = make(map[int64]int)
+	}
+	l.counts[userID]++
+	count := l.counts[userID]
+	return count <= l.limit, count == l.limit+1
+}
+
+// rateLimitMiddleware ограничивает число обновлений от одного пользователя за окно
+func rateLimitMiddleware(limit int, window time.Duration) bot.Middleware {
+	limiter := &rateLimiter{limit: limit, window: window}
+
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			from := updateSender(update)
+			if from == nil {
+				next(ctx, b, update)
+				return
+			}
+
+			allowed, notify := limiter.allow(from.ID, time.Now())
+			if allowed {
+				next(ctx, b, update)
+				return
+			}
+
+			log.Printf("🚦 Rate limited update=%d user=%d", update.ID, from.ID)
+			chatID := updateChat(update)
+			if !notify || chatID == 0 {
+				return
+			}
+			_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   i18n.T(i18n.Resolve("", from.LanguageCode), "update.rate_limited", nil),
+			})
+			if err != nil {
+				log.Printf("Error sending rate limit notice: %v", err)
+			}
+		}
+	}
+}
+
+// userMiddleware запоминает пользователя, от которого пришло обновление,
+// и кладет в контекст его данные и анкету
+func userMiddleware(users storage.UserRepository, profiles storage.ProfileRepository) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			from := updateSender(update)
+
+			if from != nil && !from.IsBot {
+				user := &storage.User{
+					ID:           from.ID,
+					Username:     from.Username,
+					FirstName:    from.FirstName,
+					LanguageCode: from.LanguageCode,
+				}
+				if err := users.Upsert(ctx, user); err != nil {
+					log.Printf("Error saving user %d: %v", from.ID, err)
+				}
+				ctx = session.WithUser(ctx, user)
+
+				profile, err := profiles.Get(ctx, from.ID)
+				if err != nil && !errors.Is(err, storage.ErrNotFound) {
+					log.Printf("Error loading profile for %d: %v", from.ID, err)
+				}
+				if err == nil {
+					ctx = session.WithProfile(ctx, profile)
+				}
+			}
+
+			next(ctx, b, update)
+		}
+	}
+}
+
+// localeMiddleware выбирает язык ответов: из настроек, иначе по языку Telegram
+func localeMiddleware(settings storage.SettingsRepository) bot.Middleware {
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			if from := updateSender(update); from != nil {
+				preferred := ""
+				userSettings, err := settings.Get(ctx, from.ID)
+				if err != nil {
+					log.Printf("Error loading settings for %d: %v", from.ID, err)
+				} else {
+					preferred = userSettings.Language
+				}
+				ctx = i18n.WithLocale(ctx, i18n.Resolve(preferred, from.LanguageCode))
+			}
+
+			next(ctx, b, update)
+		}
+	}
+}
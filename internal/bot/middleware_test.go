@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/dispatcher"
+)
+
+func TestDispatchMiddlewareKeepsChatOrder(t *testing.T) {
+	const n = 100
+	updates := dispatcher.New(n)
+
+	var mu sync.Mutex
+	var got []int64
+	handler := dispatchMiddleware(updates)(func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		time.Sleep(time.Duration(rand.Intn(100)) * time.Microsecond)
+		mu.Lock()
+		got = append(got, update.ID)
+		mu.Unlock()
+	})
+
+	for i := int64(1); i <= n; i++ {
+		handler(context.Background(), nil, &models.Update{
+			ID: i,
+			Message: &models.Message{
+				Chat: models.Chat{ID: 100},
+				From: &models.User{ID: 100},
+				Text: "text",
+			},
+		})
+	}
+	updates.Close()
+
+	if len(got) != n {
+		t.Fatalf("handled %d updates, want %d", len(got), n)
+	}
+	for i, id := range got {
+		if id != int64(i+1) {
+			t.Fatalf("update %d handled at position %d", id, i)
+		}
+	}
+}
+
+func TestDispatchMiddlewareDoesNotQueueChatlessUpdates(t *testing.T) {
+	updates := dispatcher.New(1)
+
+	// обновления без чата и автора не должны стоять в одной общей очереди
+	first := make(chan struct{})
+	done := make(chan struct{})
+	handler := dispatchMiddleware(updates)(func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		if update.ID == 1 {
+			<-first
+			close(done)
+			return
+		}
+		close(first)
+	})
+
+	handler(context.Background(), nil, &models.Update{ID: 1})
+	handler(context.Background(), nil, &models.Update{ID: 2})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("chatless updates wait for each other")
+	}
+	updates.Close()
+}
@@ -1,69 +1,131 @@
 package router
 
 import (
+	"context"
 	"log"
+	"strings"
 
 	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/conversation"
 	"github.com/merdernoty/stool-guru-bot/internal/bot/handlers/callbacks"
 	"github.com/merdernoty/stool-guru-bot/internal/bot/handlers/commands"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/handlers/media"
 )
 
+// route - обработчик и условие, при котором он вызывается
+type route struct {
+	match   bot.MatchFunc
+	handler bot.HandlerFunc
+}
+
+// Router сам выбирает обработчик обновления. Бот передает ему все обновления
+// как обработчику по умолчанию, поэтому выбор происходит внутри очереди чата,
+// уже после обработки предыдущих обновлений: состояние диалога к этому моменту актуально.
 type Router struct {
-	startHandler *commands.StartHandler
-	helpHandler  *commands.HelpHandler
+	routes   []route
+	fallback bot.HandlerFunc
+
+	conversations *conversation.Manager
+	commands      *commands.Registry
+	codec         *callback.Codec
+
+	// Media handlers
+	photoHandler *media.PhotoHandler
 
 	// Callback handlers
 	callbackHandlers *callbacks.CallbackHandlers
 }
 
+// callbackRouteSource - обработчик кнопок с подписанными данными
+type callbackRouteSource interface {
+	GetCallbackRoutes() []callback.Route
+}
+
 func NewRouter(
-	startHandler *commands.StartHandler,
-	helpHandler *commands.HelpHandler,
+	conversations *conversation.Manager,
+	commandRegistry *commands.Registry,
+	callbackCodec *callback.Codec,
+	photoHandler *media.PhotoHandler,
 	callbackHandlers *callbacks.CallbackHandlers,
+	fallback bot.HandlerFunc,
 ) *Router {
 	return &Router{
-		startHandler:     startHandler,
-		helpHandler:      helpHandler,
+		fallback:         fallback,
+		conversations:    conversations,
+		commands:         commandRegistry,
+		codec:            callbackCodec,
+		photoHandler:     photoHandler,
 		callbackHandlers: callbackHandlers,
 	}
 }
 
-func (r *Router) RegisterHandlers(b *bot.Bot) {
+func (r *Router) RegisterHandlers() {
 	log.Println("📝 Registering handlers...")
 
-	r.registerCommands(b)
-	r.registerCallbacks(b)
+	r.registerCommands()
+	r.registerConversations()
+	r.registerMedia()
+	r.registerCallbacks()
 
 	log.Println("✅ All handlers registered successfully")
 }
 
-func (r *Router) registerCommands(b *bot.Bot) {
-	commandHandlers := []commands.CommandHandler{
-		r.startHandler,
-		r.helpHandler,
+// Handle вызывает первый подходящий обработчик в порядке регистрации,
+// а если подходящего нет - обработчик по умолчанию
+func (r *Router) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	for _, route := range r.routes {
+		if route.match(update) {
+			route.handler(ctx, b, update)
+			return
+		}
 	}
+	r.fallback(ctx, b, update)
+}
 
-	for _, cmd := range commandHandlers {
-		b.RegisterHandler(
-			bot.HandlerTypeMessageText,
-			cmd.GetPattern(),
-			cmd.GetMatchType(),
-			cmd.Handle,
-		)
-		log.Printf("🔗 Registered command: %s", cmd.GetPattern())
+func (r *Router) add(match bot.MatchFunc, handler bot.HandlerFunc) {
+	r.routes = append(r.routes, route{match: match, handler: handler})
+}
+
+// callbackPrefix выбирает кнопки, данные которых начинаются с prefix
+func callbackPrefix(prefix string) bot.MatchFunc {
+	return func(update *models.Update) bool {
+		return update.CallbackQuery != nil && strings.HasPrefix(update.CallbackQuery.Data, prefix)
 	}
 }
 
-func (r *Router) registerCallbacks(b *bot.Bot) {
-	callbackPatterns := r.callbackHandlers.GetCallbackPatterns()
-
-	for pattern, handler := range callbackPatterns {
-		b.RegisterHandler(
-			bot.HandlerTypeCallbackQueryData,
-			pattern,
-			bot.MatchTypeExact,
-			handler,
-		)
-		log.Printf("🔗 Registered callback: %s", pattern)
+func (r *Router) registerCommands() {
+	for _, cmd := range r.commands.Handlers() {
+		r.add(r.commands.MatchFunc(cmd), cmd.Handle)
+		log.Printf("🔗 Registered command: /%s", strings.Join(cmd.Command().Names(), ", /"))
 	}
-}
\ No newline at end of file
+}
+
+// registerConversations должен идти раньше медиа и колбэков:
+// ответы в активном диалоге не должны попадать в обычные обработчики
+func (r *Router) registerConversations() {
+	r.add(r.conversations.Match, r.conversations.Handle)
+	log.Println("🔗 Registered conversation handler")
+}
+
+func (r *Router) registerMedia() {
+	r.add(r.photoHandler.Match, r.photoHandler.Handle)
+	log.Println("🔗 Registered photo handler")
+}
+
+func (r *Router) registerCallbacks() {
+	routes := r.conversations.GetCallbackRoutes()
+	routes = append(routes, r.callbackHandlers.GetCallbackRoutes()...)
+	routes = append(routes, r.photoHandler.GetCallbackRoutes()...)
+	for _, cmd := range r.commands.Handlers() {
+		if h, ok := cmd.(callbackRouteSource); ok {
+			routes = append(routes, h.GetCallbackRoutes()...)
+		}
+	}
+
+	for _, route := range routes {
+		r.add(callbackPrefix(route.Prefix), r.codec.HandlerFunc(route))
+		log.Printf("🔗 Registered signed callback: %s v%d", route.Prefix, route.Version)
+	}
+}
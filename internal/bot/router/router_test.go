@@ -0,0 +1,85 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/dispatcher"
+)
+
+func textUpdate(id int64, chatID int64, text string) *models.Update {
+	return &models.Update{
+		ID: id,
+		Message: &models.Message{
+			Chat: models.Chat{ID: chatID},
+			Text: text,
+		},
+	}
+}
+
+// Обработчик выбирается внутри очереди чата: ответы, пришедшие сразу за командой,
+// попадают в диалог, который команда начала, даже если в момент получения его еще не было
+func TestHandleResolvesInsideChatQueue(t *testing.T) {
+	const n = 50
+
+	var mu sync.Mutex
+	inDialog := false
+	var got []string
+
+	r := &Router{}
+	r.fallback = func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, "default:"+update.Message.Text)
+	}
+	r.add(func(update *models.Update) bool {
+		return update.Message != nil && update.Message.Text == "/log"
+	}, func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		mu.Lock()
+		defer mu.Unlock()
+		inDialog = true
+		got = append(got, "start")
+	})
+	r.add(func(update *models.Update) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return inDialog
+	}, func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, "dialog:"+update.Message.Text)
+	})
+
+	updates := dispatcher.New(n + 1)
+	ctx := context.Background()
+	updates.Dispatch(7, func() { r.Handle(ctx, nil, textUpdate(1, 7, "/log")) })
+	for i := 0; i < n; i++ {
+		update := textUpdate(int64(i+2), 7, fmt.Sprint(i))
+		updates.Dispatch(7, func() { r.Handle(ctx, nil, update) })
+	}
+	updates.Close()
+
+	if len(got) != n+1 || got[0] != "start" {
+		t.Fatalf("got %v, want start and %d dialog answers", got, n)
+	}
+	for i, v := range got[1:] {
+		if want := fmt.Sprintf("dialog:%d", i); v != want {
+			t.Fatalf("answer %d = %q, want %q", i, v, want)
+		}
+	}
+}
+
+func TestCallbackMatchers(t *testing.T) {
+	update := &models.Update{CallbackQuery: &models.CallbackQuery{Data: "hist1:page:2~abc"}}
+
+	if !callbackPrefix("hist1:")(update) || callbackPrefix("med1:")(update) {
+		t.Error("callbackPrefix matched the wrong data")
+	}
+	if callbackPrefix("")(textUpdate(1, 1, "text")) {
+		t.Error("callback matcher matched a message")
+	}
+}
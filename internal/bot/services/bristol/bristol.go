@@ -0,0 +1,74 @@
+package bristol
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+)
+
+// Type - тип стула по Бристольской шкале. Название типа - в каталоге сообщений (bristol.type.<номер>).
+type Type struct {
+	Number int
+	Emoji  string
+}
+
+// Types - все семь типов шкалы по порядку
+var Types = []Type{
+	{Number: 1, Emoji: "🪨"},
+	{Number: 2, Emoji: "🥜"},
+	{Number: 3, Emoji: "🌽"},
+	{Number: 4, Emoji: "🍌"},
+	{Number: 5, Emoji: "🫘"},
+	{Number: 6, Emoji: "🥣"},
+	{Number: 7, Emoji: "💧"},
+}
+
+// Get возвращает тип по номеру
+func Get(number int) (Type, bool) {
+	if number < 1 || number > len(Types) {
+		return Type{}, false
+	}
+	return Types[number-1], true
+}
+
+// Valid проверяет, что номер лежит в пределах шкалы
+func Valid(number int) bool {
+	return number >= 1 && number <= len(Types)
+}
+
+// Normal сообщает, считается ли тип нормой (3 и 4)
+func Normal(number int) bool {
+	return number == 3 || number == 4
+}
+
+// Label - название типа на языке locale
+func (t Type) Label(locale string) string {
+	return i18n.T(locale, "bristol.type."+strconv.Itoa(t.Number), nil)
+}
+
+// Title - короткое название типа для кнопок и списков
+func Title(locale string, number int) string {
+	t, ok := Get(number)
+	if !ok {
+		return "—"
+	}
+	return i18n.T(locale, "bristol.title", i18n.Params{
+		"emoji":  t.Emoji,
+		"number": t.Number,
+		"label":  t.Label(locale),
+	})
+}
+
+var typePattern = regexp.MustCompile(`(?i)(?:тип\p{L}*|type)\s*[:№#-]?\s*([1-7])\b`)
+
+// ExtractFromText пытается найти тип по Бристольской шкале в тексте анализа.
+// Возвращает 0, если тип не указан.
+func ExtractFromText(text string) int {
+	match := typePattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0
+	}
+	number, _ := strconv.Atoi(match[1])
+	return number
+}
@@ -0,0 +1,351 @@
+package charts
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	defaultWidth  = 900
+	defaultHeight = 500
+
+	marginLeft   = 60
+	marginRight  = 30
+	marginTop    = 60
+	marginBottom = 70
+)
+
+var (
+	Background = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	AxisColor  = color.RGBA{R: 90, G: 90, B: 90, A: 255}
+	GridColor  = color.RGBA{R: 225, G: 225, B: 225, A: 255}
+	TextColor  = color.RGBA{R: 40, G: 40, B: 40, A: 255}
+
+	Blue   = color.RGBA{R: 66, G: 133, B: 244, A: 255}
+	Green  = color.RGBA{R: 52, G: 168, B: 83, A: 255}
+	Orange = color.RGBA{R: 251, G: 140, B: 0, A: 255}
+	Red    = color.RGBA{R: 219, G: 68, B: 55, A: 255}
+	Brown  = color.RGBA{R: 141, G: 110, B: 99, A: 255}
+)
+
+// Series - набор значений одной линии
+type Series struct {
+	Name   string
+	Values []float64
+	Color  color.RGBA
+}
+
+// BarChart - столбчатая диаграмма. Colors задает цвет каждого столбца, по умолчанию Blue.
+type BarChart struct {
+	Title  string
+	Labels []string
+	Values []float64
+	Colors []color.RGBA
+	Width  int
+	Height int
+}
+
+// LineChart - линейный график. Пропуски в данных обозначаются math.NaN().
+type LineChart struct {
+	Title  string
+	Labels []string
+	Series []Series
+	// YMax - верхняя граница оси Y, 0 - подобрать по данным
+	YMax   float64
+	Width  int
+	Height int
+}
+
+var (
+	facesOnce  sync.Once
+	titleFace  font.Face
+	labelFace  font.Face
+	facesError error
+)
+
+func loadFaces() error {
+	facesOnce.Do(func() {
+		parsed, err := opentype.Parse(goregular.TTF)
+		if err != nil {
+			facesError = fmt.Errorf("failed to parse font: %w", err)
+			return
+		}
+		titleFace, err = opentype.NewFace(parsed, &opentype.FaceOptions{Size: 20, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			facesError = fmt.Errorf("failed to create title face: %w", err)
+			return
+		}
+		labelFace, err = opentype.NewFace(parsed, &opentype.FaceOptions{Size: 13, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			facesError = fmt.Errorf("failed to create label face: %w", err)
+		}
+	})
+	return facesError
+}
+
+// canvas - область рисования с осями
+type canvas struct {
+	img  *image.RGBA
+	plot image.Rectangle
+	yMax float64
+}
+
+func newCanvas(width, height int, title string, yMax float64) (*canvas, error) {
+	if err := loadFaces(); err != nil {
+		return nil, err
+	}
+	if width == 0 {
+		width = defaultWidth
+	}
+	if height == 0 {
+		height = defaultHeight
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: Background}, image.Point{}, draw.Src)
+
+	c := &canvas{
+		img:  img,
+		plot: image.Rect(marginLeft, marginTop, width-marginRight, height-marginBottom),
+		yMax: niceMax(yMax),
+	}
+
+	c.text(titleFace, title, width/2, 35, true)
+	c.drawGrid()
+	return c, nil
+}
+
+func (c *canvas) drawGrid() {
+	const ticks = 5
+	for i := 0; i <= ticks; i++ {
+		value := c.yMax * float64(i) / ticks
+		y := c.yFor(value)
+		lineColor := GridColor
+		if i == 0 {
+			lineColor = AxisColor
+		}
+		c.line(c.plot.Min.X, y, c.plot.Max.X, y, lineColor, 1)
+		c.textRight(labelFace, formatValue(value), c.plot.Min.X-8, y+4)
+	}
+	c.line(c.plot.Min.X, c.plot.Min.Y, c.plot.Min.X, c.plot.Max.Y, AxisColor, 1)
+}
+
+func (c *canvas) yFor(value float64) int {
+	if c.yMax == 0 {
+		return c.plot.Max.Y
+	}
+	ratio := value / c.yMax
+	return c.plot.Max.Y - int(math.Round(ratio*float64(c.plot.Dy())))
+}
+
+// xLabels подписывает ось X, пропуская подписи, если они не помещаются
+func (c *canvas) xLabels(labels []string, xFor func(i int) int) {
+	if len(labels) == 0 {
+		return
+	}
+	maxLabels := c.plot.Dx() / 45
+	step := 1
+	if maxLabels > 0 && len(labels) > maxLabels {
+		step = (len(labels) + maxLabels - 1) / maxLabels
+	}
+	for i := 0; i < len(labels); i += step {
+		c.text(labelFace, labels[i], xFor(i), c.plot.Max.Y+20, true)
+	}
+}
+
+func (c *canvas) legend(series []Series) {
+	x := c.plot.Min.X
+	y := c.img.Bounds().Dy() - 20
+	for _, s := range series {
+		if s.Name == "" {
+			continue
+		}
+		c.rect(image.Rect(x, y-10, x+14, y+2), s.Color)
+		x += 20
+		x += c.text(labelFace, s.Name, x, y, false) + 24
+	}
+}
+
+func (c *canvas) rect(r image.Rectangle, col color.RGBA) {
+	draw.Draw(c.img, r.Intersect(c.img.Bounds()), &image.Uniform{C: col}, image.Point{}, draw.Src)
+}
+
+// line рисует отрезок толщиной width алгоритмом Брезенхэма
+func (c *canvas) line(x0, y0, x1, y1 int, col color.RGBA, width int) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	half := width / 2
+
+	for {
+		c.rect(image.Rect(x0-half, y0-half, x0-half+width, y0-half+width), col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// text выводит строку и возвращает ее ширину в пикселях
+func (c *canvas) text(face font.Face, s string, x, y int, centered bool) int {
+	d := &font.Drawer{Dst: c.img, Src: &image.Uniform{C: TextColor}, Face: face}
+	width := d.MeasureString(s).Round()
+	if centered {
+		x -= width / 2
+	}
+	d.Dot = fixed.P(x, y)
+	d.DrawString(s)
+	return width
+}
+
+func (c *canvas) textRight(face font.Face, s string, x, y int) {
+	d := &font.Drawer{Face: face}
+	c.text(face, s, x-d.MeasureString(s).Round(), y, false)
+}
+
+func (c *canvas) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderBar рисует столбчатую диаграмму в PNG
+func RenderBar(chart BarChart) ([]byte, error) {
+	maxValue := 0.0
+	for _, v := range chart.Values {
+		maxValue = math.Max(maxValue, v)
+	}
+
+	c, err := newCanvas(chart.Width, chart.Height, chart.Title, maxValue)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(chart.Values)
+	if n > 0 {
+		slot := float64(c.plot.Dx()) / float64(n)
+		barWidth := int(math.Max(2, slot*0.7))
+		xFor := func(i int) int {
+			return c.plot.Min.X + int(slot*float64(i)+slot/2)
+		}
+
+		for i, v := range chart.Values {
+			col := Blue
+			if i < len(chart.Colors) {
+				col = chart.Colors[i]
+			}
+			x := xFor(i)
+			c.rect(image.Rect(x-barWidth/2, c.yFor(v), x+barWidth/2, c.plot.Max.Y), col)
+			if v > 0 && slot >= 28 {
+				c.text(labelFace, formatValue(v), x, c.yFor(v)-6, true)
+			}
+		}
+		c.xLabels(chart.Labels, xFor)
+	}
+
+	return c.encode()
+}
+
+// RenderLine рисует линейный график в PNG
+func RenderLine(chart LineChart) ([]byte, error) {
+	yMax := chart.YMax
+	if yMax == 0 {
+		for _, s := range chart.Series {
+			for _, v := range s.Values {
+				if !math.IsNaN(v) {
+					yMax = math.Max(yMax, v)
+				}
+			}
+		}
+	}
+
+	c, err := newCanvas(chart.Width, chart.Height, chart.Title, yMax)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(chart.Labels)
+	xFor := func(i int) int {
+		if n <= 1 {
+			return c.plot.Min.X + c.plot.Dx()/2
+		}
+		return c.plot.Min.X + int(float64(c.plot.Dx())*float64(i)/float64(n-1))
+	}
+
+	for _, s := range chart.Series {
+		prevX, prevY, hasPrev := 0, 0, false
+		for i, v := range s.Values {
+			if math.IsNaN(v) {
+				hasPrev = false
+				continue
+			}
+			x, y := xFor(i), c.yFor(v)
+			if hasPrev {
+				c.line(prevX, prevY, x, y, s.Color, 3)
+			}
+			c.rect(image.Rect(x-3, y-3, x+4, y+4), s.Color)
+			prevX, prevY, hasPrev = x, y, true
+		}
+	}
+
+	c.xLabels(chart.Labels, xFor)
+	c.legend(chart.Series)
+	return c.encode()
+}
+
+// niceMax округляет верхнюю границу оси до "круглого" значения
+func niceMax(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 2.5, 5, 10} {
+		if v <= m*magnitude {
+			return m * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+func formatValue(v float64) string {
+	if v == math.Trunc(v) {
+		return strconv.Itoa(int(v))
+	}
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,23 @@
+package consent
+
+import "github.com/merdernoty/stool-guru-bot/internal/storage"
+
+// Version - текущая версия условий. Ее нужно увеличивать при каждом изменении
+// текста условий в каталоге сообщений: тогда бот попросит согласие заново.
+const Version = 2
+
+const (
+	// CallbackPrefix - префикс кнопок экрана согласия
+	CallbackPrefix = "consent:"
+	// CallbackVersion - версия формата кнопок экрана согласия
+	CallbackVersion = 1
+
+	ActionAccept  = "accept"
+	ActionDecline = "decline"
+)
+
+// Required сообщает, нужно ли запросить согласие: его еще не было
+// или пользователь принимал более старую версию условий
+func Required(c *storage.Consent) bool {
+	return c == nil || c.Version < Version
+}
@@ -0,0 +1,420 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// Data - все данные пользователя для выгрузки
+type Data struct {
+	ExportedAt     time.Time       `json:"exported_at"`
+	UserID         int64           `json:"user_id"`
+	Settings       Settings        `json:"settings"`
+	Profile        *Profile        `json:"profile,omitempty"`
+	Consent        *Consent        `json:"consent,omitempty"`
+	DiaryEntries   []DiaryEntry    `json:"diary_entries"`
+	Analyses       []Analysis      `json:"analyses"`
+	Questionnaires []Questionnaire `json:"questionnaires"`
+	Lifestyle      []Lifestyle     `json:"lifestyle_entries"`
+	Medications    []Medication    `json:"medications"`
+}
+
+type Settings struct {
+	Language      string `json:"language"`
+	Timezone      string `json:"timezone"`
+	KeepImages    bool   `json:"keep_images"`
+	Incognito     bool   `json:"incognito"`
+	Notifications string `json:"notifications"`
+}
+
+// Consent - последняя принятая версия условий
+type Consent struct {
+	Version    int       `json:"version"`
+	AcceptedAt time.Time `json:"accepted_at"`
+}
+
+// Profile - анкета, в выгрузке нет, если пользователь ее не заполнял
+type Profile struct {
+	AgeRange    string    `json:"age_range,omitempty"`
+	Sex         string    `json:"sex,omitempty"`
+	Conditions  []string  `json:"conditions,omitempty"`
+	Diet        string    `json:"diet,omitempty"`
+	DetailLevel string    `json:"detail_level,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type DiaryEntry struct {
+	ID          int64     `json:"id"`
+	OccurredAt  time.Time `json:"occurred_at"`
+	BristolType int       `json:"bristol_type"`
+	Urgency     int       `json:"urgency"`
+	Pain        int       `json:"pain"`
+	Note        string    `json:"note,omitempty"`
+	AnalysisID  int64     `json:"analysis_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Analysis struct {
+	ID              int64     `json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	BristolType     int       `json:"bristol_type"`
+	UserContext     string    `json:"user_context,omitempty"`
+	Diagnosis       string    `json:"diagnosis,omitempty"`
+	Recommendations string    `json:"recommendations,omitempty"`
+	Text            string    `json:"text"`
+}
+
+type Questionnaire struct {
+	ID        int64           `json:"id"`
+	Kind      string          `json:"kind"`
+	Score     int             `json:"score"`
+	Band      string          `json:"band"`
+	Answers   json.RawMessage `json:"answers,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type Lifestyle struct {
+	ID         int64     `json:"id"`
+	Kind       string    `json:"kind"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Tags       []string  `json:"tags,omitempty"`
+	Value      int       `json:"value,omitempty"`
+	Note       string    `json:"note,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Medication struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Category  string    `json:"category"`
+	StartedAt time.Time `json:"started_at"`
+	// StoppedAt - nil, если курс продолжается
+	StoppedAt *time.Time `json:"stopped_at,omitempty"`
+	// IntakeDays - дни, за которые отмечен прием
+	IntakeDays []string `json:"intake_days"`
+}
+
+// Collect собирает из хранилища все данные пользователя
+func Collect(ctx context.Context, store storage.Storage, userID int64) (*Data, error) {
+	settings, err := store.Settings().Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	userProfile, err := store.Profiles().Get(ctx, userID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	accepted, err := store.Consents().Latest(ctx, userID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, fmt.Errorf("failed to load consent: %w", err)
+	}
+
+	entries, err := store.Diary().List(ctx, userID, storage.DiaryFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load diary: %w", err)
+	}
+
+	analyses, err := store.Analyses().List(ctx, userID, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load analyses: %w", err)
+	}
+
+	results, err := store.Questionnaires().List(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load questionnaires: %w", err)
+	}
+
+	lifestyle, err := store.Lifestyle().List(ctx, userID, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lifestyle entries: %w", err)
+	}
+
+	medications, err := store.Medications().List(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load medications: %w", err)
+	}
+
+	intakes, err := store.Medications().Intakes(ctx, userID, time.Unix(0, 0), time.Now().Add(time.Hour))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load medication intakes: %w", err)
+	}
+	intakeDays := make(map[int64][]string)
+	for _, intake := range intakes {
+		intakeDays[intake.MedicationID] = append(intakeDays[intake.MedicationID], intake.Day)
+	}
+
+	data := &Data{
+		ExportedAt: time.Now().UTC(),
+		UserID:     userID,
+		Settings: Settings{
+			Language:      settings.Language,
+			Timezone:      settings.Timezone,
+			KeepImages:    settings.KeepImages,
+			Incognito:     settings.Incognito,
+			Notifications: settings.Notifications,
+		},
+		DiaryEntries:   make([]DiaryEntry, 0, len(entries)),
+		Analyses:       make([]Analysis, 0, len(analyses)),
+		Questionnaires: make([]Questionnaire, 0, len(results)),
+		Lifestyle:      make([]Lifestyle, 0, len(lifestyle)),
+		Medications:    make([]Medication, 0, len(medications)),
+	}
+	if accepted != nil {
+		data.Consent = &Consent{Version: accepted.Version, AcceptedAt: accepted.AcceptedAt}
+	}
+	if userProfile != nil {
+		data.Profile = &Profile{
+			AgeRange:    userProfile.AgeRange,
+			Sex:         userProfile.Sex,
+			Conditions:  userProfile.Conditions,
+			Diet:        userProfile.Diet,
+			DetailLevel: userProfile.DetailLevel,
+			UpdatedAt:   userProfile.UpdatedAt,
+		}
+	}
+
+	for _, e := range entries {
+		data.DiaryEntries = append(data.DiaryEntries, DiaryEntry{
+			ID:          e.ID,
+			OccurredAt:  e.OccurredAt,
+			BristolType: e.BristolType,
+			Urgency:     e.Urgency,
+			Pain:        e.Pain,
+			Note:        e.Note,
+			AnalysisID:  e.AnalysisID,
+			CreatedAt:   e.CreatedAt,
+		})
+	}
+
+	for _, a := range analyses {
+		data.Analyses = append(data.Analyses, Analysis{
+			ID:              a.ID,
+			CreatedAt:       a.CreatedAt,
+			BristolType:     a.BristolType,
+			UserContext:     a.UserContext,
+			Diagnosis:       a.Diagnosis,
+			Recommendations: a.Recommendations,
+			Text:            a.Text,
+		})
+	}
+
+	for _, r := range results {
+		q := Questionnaire{
+			ID:        r.ID,
+			Kind:      r.Kind,
+			Score:     r.Score,
+			Band:      r.Band,
+			CreatedAt: r.CreatedAt,
+		}
+		if json.Valid([]byte(r.Answers)) {
+			q.Answers = json.RawMessage(r.Answers)
+		}
+		data.Questionnaires = append(data.Questionnaires, q)
+	}
+
+	for _, l := range lifestyle {
+		data.Lifestyle = append(data.Lifestyle, Lifestyle{
+			ID:         l.ID,
+			Kind:       l.Kind,
+			OccurredAt: l.OccurredAt,
+			Tags:       l.Tags,
+			Value:      l.Value,
+			Note:       l.Note,
+			CreatedAt:  l.CreatedAt,
+		})
+	}
+
+	for _, m := range medications {
+		medication := Medication{
+			ID:         m.ID,
+			Name:       m.Name,
+			Category:   m.Category,
+			StartedAt:  m.StartedAt,
+			IntakeDays: append([]string{}, intakeDays[m.ID]...),
+		}
+		if !m.StoppedAt.IsZero() {
+			stoppedAt := m.StoppedAt
+			medication.StoppedAt = &stoppedAt
+		}
+		data.Medications = append(data.Medications, medication)
+	}
+
+	return data, nil
+}
+
+// JSON сериализует выгрузку в JSON с отступами
+func JSON(data *Data) ([]byte, error) {
+	encoded, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode json: %w", err)
+	}
+	return encoded, nil
+}
+
+var csvHeader = []string{
+	"record_type", "id", "timestamp",
+	"bristol_type", "urgency", "pain", "note", "analysis_id",
+	"analysis_text", "diagnosis", "recommendations",
+	"questionnaire", "score", "band", "answers",
+	"lifestyle_kind", "tags", "value",
+	"medication", "category", "stopped_at", "intake_days",
+	"setting", "value",
+}
+
+// CSV сериализует выгрузку в одну таблицу: тип записи в первой колонке,
+// неприменимые к типу колонки остаются пустыми. Файл начинается с BOM,
+// чтобы Excel правильно показывал кириллицу.
+func CSV(data *Data) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\uFEFF")
+
+	w := csv.NewWriter(&buf)
+	rows := [][]string{csvHeader}
+
+	for _, e := range data.DiaryEntries {
+		rows = append(rows, csvRow(map[string]string{
+			"record_type":  "diary_entry",
+			"id":           strconv.FormatInt(e.ID, 10),
+			"timestamp":    formatTime(e.OccurredAt),
+			"bristol_type": strconv.Itoa(e.BristolType),
+			"urgency":      strconv.Itoa(e.Urgency),
+			"pain":         strconv.Itoa(e.Pain),
+			"note":         e.Note,
+			"analysis_id":  formatID(e.AnalysisID),
+		}))
+	}
+
+	for _, a := range data.Analyses {
+		rows = append(rows, csvRow(map[string]string{
+			"record_type":     "analysis",
+			"id":              strconv.FormatInt(a.ID, 10),
+			"timestamp":       formatTime(a.CreatedAt),
+			"bristol_type":    strconv.Itoa(a.BristolType),
+			"note":            a.UserContext,
+			"analysis_text":   a.Text,
+			"diagnosis":       a.Diagnosis,
+			"recommendations": a.Recommendations,
+		}))
+	}
+
+	for _, q := range data.Questionnaires {
+		rows = append(rows, csvRow(map[string]string{
+			"record_type":   "questionnaire",
+			"id":            strconv.FormatInt(q.ID, 10),
+			"timestamp":     formatTime(q.CreatedAt),
+			"questionnaire": q.Kind,
+			"score":         strconv.Itoa(q.Score),
+			"band":          q.Band,
+			"answers":       string(q.Answers),
+		}))
+	}
+
+	for _, l := range data.Lifestyle {
+		rows = append(rows, csvRow(map[string]string{
+			"record_type":    "lifestyle",
+			"id":             strconv.FormatInt(l.ID, 10),
+			"timestamp":      formatTime(l.OccurredAt),
+			"note":           l.Note,
+			"lifestyle_kind": l.Kind,
+			"tags":           strings.Join(l.Tags, ","),
+			"value":          strconv.Itoa(l.Value),
+		}))
+	}
+
+	for _, m := range data.Medications {
+		rows = append(rows, csvRow(map[string]string{
+			"record_type": "medication",
+			"id":          strconv.FormatInt(m.ID, 10),
+			"timestamp":   formatTime(m.StartedAt),
+			"medication":  m.Name,
+			"category":    m.Category,
+			"stopped_at":  formatOptionalTime(m.StoppedAt),
+			"intake_days": strings.Join(m.IntakeDays, ","),
+		}))
+	}
+
+	settings := [][2]string{
+		{"language", data.Settings.Language},
+		{"timezone", data.Settings.Timezone},
+		{"keep_images", strconv.FormatBool(data.Settings.KeepImages)},
+		{"incognito", strconv.FormatBool(data.Settings.Incognito)},
+		{"notifications", data.Settings.Notifications},
+	}
+	for _, s := range settings {
+		rows = append(rows, csvRow(map[string]string{
+			"record_type": "setting",
+			"setting":     s[0],
+			"value":       s[1],
+		}))
+	}
+
+	if c := data.Consent; c != nil {
+		rows = append(rows, csvRow(map[string]string{
+			"record_type": "consent",
+			"timestamp":   formatTime(c.AcceptedAt),
+			"value":       strconv.Itoa(c.Version),
+		}))
+	}
+
+	if p := data.Profile; p != nil {
+		answers := [][2]string{
+			{"age_range", p.AgeRange},
+			{"sex", p.Sex},
+			{"conditions", strings.Join(p.Conditions, ",")},
+			{"diet", p.Diet},
+			{"detail_level", p.DetailLevel},
+		}
+		for _, a := range answers {
+			rows = append(rows, csvRow(map[string]string{
+				"record_type": "profile",
+				"setting":     a[0],
+				"value":       a[1],
+			}))
+		}
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("failed to write csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func csvRow(values map[string]string) []string {
+	row := make([]string, len(csvHeader))
+	for i, column := range csvHeader {
+		row[i] = values[column]
+	}
+	return row
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
+
+func formatID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,56 @@
+package export
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid export token")
+	ErrExpiredToken = errors.New("export token expired")
+)
+
+// NewToken выпускает подписанный токен доступа к выгрузке пользователя: <user>.<expires>.<hmac>
+func NewToken(secret string, userID int64, expiresAt time.Time) string {
+	payload := strconv.FormatInt(userID, 10) + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return payload + "." + sign(secret, payload)
+}
+
+// VerifyToken проверяет подпись и срок действия токена и возвращает ID пользователя
+func VerifyToken(secret, token string, now time.Time) (int64, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, ErrInvalidToken
+	}
+
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(sign(secret, payload)), []byte(parts[2])) {
+		return 0, ErrInvalidToken
+	}
+
+	userID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: bad user id", ErrInvalidToken)
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: bad expiry", ErrInvalidToken)
+	}
+	if now.Unix() > expires {
+		return 0, ErrExpiredToken
+	}
+
+	return userID, nil
+}
+
+func sign(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("export:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -15,6 +15,20 @@ type GeminiService struct {
 	model  string
 }
 
+// AnalysisContext - сведения о пользователе, которые уточняют анализ фото
+type AnalysisContext struct {
+	// UserContext - подпись к фото или ответ на уточняющий вопрос, непроверенный текст
+	UserContext string
+	// Medications - лекарства, которые пользователь сейчас принимает
+	Medications []string
+	// Profile - сведения из анкеты: возраст, пол, диагнозы, питание
+	Profile []string
+	// Detail - указание о подробности ответа, пусто - обычный ответ
+	Detail string
+	// Language - язык пользователя, на нем модель отвечает; пусто или "ru" - русский
+	Language string
+}
+
 // AnalysisResult - результат анализа изображения
 type AnalysisResult struct {
 	Text            string `json:"text"`
@@ -49,6 +63,14 @@ func NewGeminiService(apiKey string) (*GeminiService, error) {
 
 // AnalyzeImage анализирует изображение с помощью Gemini AI
 func (g *GeminiService) AnalyzeImage(ctx context.Context, imageBytes []byte, mimeType string) (*AnalysisResult, error) {
+	return g.AnalyzeImageWithContext(ctx, imageBytes, mimeType, AnalysisContext{})
+}
+
+// AnalyzeImageWithContext анализирует изображение с учетом сведений о пользователе:
+// анкеты, принимаемых лекарств и контекста к фото (подпись, ответ на уточняющий вопрос).
+// Текст, введенный пользователем, считается непроверенным и передается модели
+// в отдельных размеченных блоках.
+func (g *GeminiService) AnalyzeImageWithContext(ctx context.Context, imageBytes []byte, mimeType string, analysisContext AnalysisContext) (*AnalysisResult, error) {
 	if len(imageBytes) == 0 {
 		return nil, fmt.Errorf("данные изображения не могут быть пустыми")
 	}
@@ -59,30 +81,7 @@ func (g *GeminiService) AnalyzeImage(ctx context.Context, imageBytes []byte, mim
 
 	// Создаем части сообщения с текстом и изображением
 	parts := []*genai.Part{
-		genai.NewPartFromText(`Ты опытный врач-гастроэнтеролог. Проанализируй данное изображение стула/кала и дай профессиональную медицинскую оценку.
-
-ВАЖНО: Анализируй только если на изображении действительно стул/кал. Если это что-то другое, скажи об этом.
-
-Если это стул, дай анализ по следующим критериям:
-1. ФОРМА И КОНСИСТЕНЦИЯ (по Бристольской шкале стула 1-7)
-2. ЦВЕТ и возможные причины
-3. РАЗМЕР и общий вид
-4. ПОТЕНЦИАЛЬНЫЕ ПРОБЛЕМЫ
-
-Структурируй ответ так:
-🔬 АНАЛИЗ:
-[Подробное описание]
-
-⚕️ ОЦЕНКА:
-[Оценка по Бристольской шкале и общее состояние]
-
-🥗 РЕКОМЕНДАЦИИ:
-[Конкретные советы по питанию]
-
-⚠️ ВНИМАНИЕ:
-[Когда нужна медицинская помощь]
-
-Отвечай профессионально, но понятно. Напоминай, что это не заменяет консультацию врача.`),
+		genai.NewPartFromText(buildAnalysisPrompt(analysisContext)),
 		genai.NewPartFromBytes(imageBytes, mimeType),
 	}
 
@@ -171,20 +170,14 @@ func (g *GeminiService) parseResponse(response string) (diagnosis, recommendatio
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		
-		if strings.Contains(line, "АНАЛИЗ:") || strings.Contains(line, "ОЦЕНКА:") {
-			currentSection = "diagnosis"
-			continue
-		} else if strings.Contains(line, "РЕКОМЕНДАЦИИ:") {
-			currentSection = "recommendations"
-			continue
-		} else if strings.Contains(line, "ВНИМАНИЕ:") {
-			currentSection = "attention"
+		if section := headingSection(line); section != "" {
+			currentSection = section
 			continue
 		}
 		
-		if currentSection == "diagnosis" && line != "" {
+		if currentSection == sectionDiagnosis && line != "" {
 			diagnosisLines = append(diagnosisLines, line)
-		} else if currentSection == "recommendations" && line != "" {
+		} else if currentSection == sectionRecommendations && line != "" {
 			recommendationLines = append(recommendationLines, line)
 		}
 	}
@@ -0,0 +1,34 @@
+package gemini
+
+import "testing"
+
+func TestParseResponse(t *testing.T) {
+	tests := []struct {
+		name            string
+		response        string
+		diagnosis       string
+		recommendations string
+	}{
+		{
+			name:            "russian",
+			response:        "🔬 АНАЛИЗ:\nСтул оформленный.\n\n⚕️ ОЦЕНКА:\nТип 4.\n\n🥗 РЕКОМЕНДАЦИИ:\nПейте воду.\n\n⚠️ ВНИМАНИЕ:\nПри крови обратитесь к врачу.",
+			diagnosis:       "Стул оформленный. Тип 4.",
+			recommendations: "Пейте воду.",
+		},
+		{
+			name:            "english",
+			response:        "🔬 ANALYSIS:\nStool is formed.\n\n⚕️ ASSESSMENT:\nType 4.\n\n🥗 RECOMMENDATIONS:\nDrink water.\n\n⚠️ WARNING:\nSee a doctor if there is blood.",
+			diagnosis:       "Stool is formed. Type 4.",
+			recommendations: "Drink water.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnosis, recommendations := (&GeminiService{}).parseResponse(tt.response)
+			if diagnosis != tt.diagnosis || recommendations != tt.recommendations {
+				t.Errorf("parseResponse = %q, %q; want %q, %q", diagnosis, recommendations, tt.diagnosis, tt.recommendations)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package ibs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+)
+
+// Answers - ответы на опросник IBS-SSS (IBS Severity Scoring System).
+// Шкалы выраженности - от 0 до 100, число дней с болью - за последние 10 дней.
+type Answers struct {
+	PainSeverity         int `json:"pain_severity"`
+	PainDays             int `json:"pain_days"`
+	DistensionSeverity   int `json:"distension_severity"`
+	BowelDissatisfaction int `json:"bowel_dissatisfaction"`
+	LifeInterference     int `json:"life_interference"`
+}
+
+// Band - степень тяжести по сумме баллов IBS-SSS
+type Band string
+
+const (
+	BandRemission Band = "remission"
+	BandMild      Band = "mild"
+	BandModerate  Band = "moderate"
+	BandSevere    Band = "severe"
+)
+
+// MaxScore - максимальная сумма баллов
+const MaxScore = 500
+
+// Kind - тип опросника в хранилище результатов
+const Kind = "ibs_sss"
+
+// Result - результат прохождения опросника
+type Result struct {
+	Answers   Answers   `json:"answers"`
+	Score     int       `json:"score"`
+	Band      Band      `json:"band"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate проверяет, что ответы лежат в допустимых диапазонах
+func (a Answers) Validate() error {
+	scales := map[string]int{
+		"pain_severity":         a.PainSeverity,
+		"distension_severity":   a.DistensionSeverity,
+		"bowel_dissatisfaction": a.BowelDissatisfaction,
+		"life_interference":     a.LifeInterference,
+	}
+	for name, value := range scales {
+		if value < 0 || value > 100 {
+			return fmt.Errorf("%s must be between 0 and 100, got %d", name, value)
+		}
+	}
+
+	if a.PainDays < 0 || a.PainDays > 10 {
+		return fmt.Errorf("pain_days must be between 0 and 10, got %d", a.PainDays)
+	}
+	return nil
+}
+
+// Score считает сумму баллов: четыре шкалы по 100 баллов
+// плюс число дней с болью из 10, умноженное на 10
+func Score(a Answers) (Result, error) {
+	if err := a.Validate(); err != nil {
+		return Result{}, err
+	}
+
+	total := a.PainSeverity +
+		a.PainDays*10 +
+		a.DistensionSeverity +
+		a.BowelDissatisfaction +
+		a.LifeInterference
+
+	return Result{
+		Answers: a,
+		Score:   total,
+		Band:    BandFor(total),
+	}, nil
+}
+
+// BandFor возвращает степень тяжести по стандартным порогам IBS-SSS:
+// меньше 75 - ремиссия, 75-174 - легкая, 175-299 - средняя, от 300 - тяжелая
+func BandFor(score int) Band {
+	switch {
+	case score < 75:
+		return BandRemission
+	case score < 175:
+		return BandMild
+	case score < 300:
+		return BandModerate
+	default:
+		return BandSevere
+	}
+}
+
+// Label - название степени тяжести на языке locale
+func (b Band) Label(locale string) string {
+	switch b {
+	case BandRemission, BandMild, BandModerate, BandSevere:
+		return i18n.T(locale, "ibs.band."+string(b), nil)
+	default:
+		return string(b)
+	}
+}
@@ -0,0 +1,99 @@
+package ibs
+
+import "testing"
+
+func TestBandFor(t *testing.T) {
+	tests := []struct {
+		score int
+		want  Band
+	}{
+		{score: 0, want: BandRemission},
+		{score: 74, want: BandRemission},
+		{score: 75, want: BandMild},
+		{score: 174, want: BandMild},
+		{score: 175, want: BandModerate},
+		{score: 299, want: BandModerate},
+		{score: 300, want: BandSevere},
+		{score: MaxScore, want: BandSevere},
+	}
+
+	for _, tt := range tests {
+		if got := BandFor(tt.score); got != tt.want {
+			t.Errorf("BandFor(%d) = %s, want %s", tt.score, got, tt.want)
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers Answers
+		want    int
+		band    Band
+	}{
+		{name: "no symptoms", answers: Answers{}, want: 0, band: BandRemission},
+		// дни с болью весят по 10 баллов
+		{name: "pain days only", answers: Answers{PainDays: 7}, want: 70, band: BandRemission},
+		{
+			name:    "mild boundary",
+			answers: Answers{PainSeverity: 20, PainDays: 3, DistensionSeverity: 15, BowelDissatisfaction: 10},
+			want:    75,
+			band:    BandMild,
+		},
+		{
+			name:    "severe boundary",
+			answers: Answers{PainSeverity: 60, PainDays: 6, DistensionSeverity: 60, BowelDissatisfaction: 60, LifeInterference: 60},
+			want:    300,
+			band:    BandSevere,
+		},
+		{
+			name:    "maximum",
+			answers: Answers{PainSeverity: 100, PainDays: 10, DistensionSeverity: 100, BowelDissatisfaction: 100, LifeInterference: 100},
+			want:    MaxScore,
+			band:    BandSevere,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Score(tt.answers)
+			if err != nil {
+				t.Fatalf("Score(%+v) error: %v", tt.answers, err)
+			}
+			if result.Score != tt.want || result.Band != tt.band {
+				t.Errorf("Score(%+v) = %d (%s), want %d (%s)", tt.answers, result.Score, result.Band, tt.want, tt.band)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsOutOfRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers Answers
+	}{
+		{name: "negative pain", answers: Answers{PainSeverity: -1}},
+		{name: "pain above 100", answers: Answers{PainSeverity: 101}},
+		{name: "distension above 100", answers: Answers{DistensionSeverity: 101}},
+		{name: "negative dissatisfaction", answers: Answers{BowelDissatisfaction: -1}},
+		{name: "interference above 100", answers: Answers{LifeInterference: 101}},
+		{name: "negative pain days", answers: Answers{PainDays: -1}},
+		{name: "pain days above 10", answers: Answers{PainDays: 11}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.answers.Validate(); err == nil {
+				t.Errorf("Validate(%+v) accepted out of range answers", tt.answers)
+			}
+			if _, err := Score(tt.answers); err == nil {
+				t.Errorf("Score(%+v) scored out of range answers", tt.answers)
+			}
+		})
+	}
+
+	edges := Answers{PainSeverity: 100, PainDays: 10, DistensionSeverity: 0, BowelDissatisfaction: 100, LifeInterference: 0}
+	if err := edges.Validate(); err != nil {
+		t.Errorf("Validate(%+v) rejected answers at the range edges: %v", edges, err)
+	}
+}
@@ -0,0 +1,269 @@
+package lifestyle
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/bristol"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+const (
+	// MinSamples - сколько записей нужно в каждой группе, чтобы сравнивать их
+	MinSamples = 3
+	// likelyRatio - во сколько раз чаще должны случаться плохие дни, чтобы назвать фактор вероятным триггером
+	likelyRatio = 1.5
+
+	// PoorSleepHours - сон короче этого считается недосыпом
+	PoorSleepHours = 6
+	// HighStress - уровень стресса, начиная с которого он считается высоким
+	HighStress = 7
+)
+
+// Evidence - насколько можно доверять найденной связи
+type Evidence int
+
+const (
+	EvidenceWeak Evidence = iota
+	EvidenceModerate
+	EvidenceStrong
+)
+
+// Factor - возможный триггер, который сравнивается с последующими записями дневника
+type Factor struct {
+	Key string
+	// Kind - какие записи образа жизни нужны, чтобы понять, был ли фактор
+	Kind string
+	// MinLag и MaxLag - окно перед записью дневника, в котором ищется фактор
+	MinLag time.Duration
+	MaxLag time.Duration
+	// Present сообщает, есть ли фактор в записи образа жизни
+	Present func(e storage.LifestyleEntry) bool
+}
+
+// Factors возвращает все проверяемые факторы: метки еды, недосып и высокий стресс.
+// Еда влияет на стул спустя несколько часов и до суток, сон и стресс - в течение суток.
+func Factors() []Factor {
+	var factors []Factor
+	for _, t := range Tags {
+		key := t.Key
+		factors = append(factors, Factor{
+			Key:    key,
+			Kind:   storage.LifestyleMeal,
+			MinLag: 2 * time.Hour,
+			MaxLag: 24 * time.Hour,
+			Present: func(e storage.LifestyleEntry) bool {
+				for _, tag := range e.Tags {
+					if tag == key {
+						return true
+					}
+				}
+				return false
+			},
+		})
+	}
+
+	return append(factors,
+		Factor{
+			Key:    "poor_sleep",
+			Kind:   storage.LifestyleSleep,
+			MaxLag: 24 * time.Hour,
+			Present: func(e storage.LifestyleEntry) bool {
+				return e.Value < PoorSleepHours
+			},
+		},
+		Factor{
+			Key:    "high_stress",
+			Kind:   storage.LifestyleStress,
+			MaxLag: 24 * time.Hour,
+			Present: func(e storage.LifestyleEntry) bool {
+				return e.Value >= HighStress
+			},
+		},
+	)
+}
+
+// Label - подпись фактора на языке locale: метки еды подписываются так же, как кнопки
+func (f Factor) Label(locale string) string {
+	if f.Kind == storage.LifestyleMeal {
+		return TagLabel(locale, f.Key)
+	}
+	return i18n.T(locale, "lifestyle.factor."+f.Key, nil)
+}
+
+// Insight - сравнение записей дневника после фактора и без него
+type Insight struct {
+	Factor Factor
+
+	// Exposed - записей дневника, перед которыми фактор был, ExposedBad - из них плохих
+	Exposed    int
+	ExposedBad int
+	// Unexposed - записей, перед которыми фактора не было, хотя записи такого вида велись
+	Unexposed    int
+	UnexposedBad int
+
+	AvgPainExposed   float64
+	AvgPainUnexposed float64
+
+	// PValue - односторонний точный тест Фишера: вероятность случайно получить такую же или большую разницу
+	PValue float64
+}
+
+// ExposedRate - доля плохих записей после фактора
+func (i Insight) ExposedRate() float64 {
+	return rate(i.ExposedBad, i.Exposed)
+}
+
+// UnexposedRate - доля плохих записей без фактора
+func (i Insight) UnexposedRate() float64 {
+	return rate(i.UnexposedBad, i.Unexposed)
+}
+
+// RelativeRisk - во сколько раз чаще плохие записи после фактора.
+// Поправка 0.5 не дает делить на ноль при малых выборках.
+func (i Insight) RelativeRisk() float64 {
+	exposed := (float64(i.ExposedBad) + 0.5) / (float64(i.Exposed) + 1)
+	unexposed := (float64(i.UnexposedBad) + 0.5) / (float64(i.Unexposed) + 1)
+	return exposed / unexposed
+}
+
+// Likely сообщает, похож ли фактор на триггер
+func (i Insight) Likely() bool {
+	return i.Exposed >= MinSamples && i.Unexposed >= MinSamples &&
+		i.ExposedRate() > i.UnexposedRate() && i.RelativeRisk() >= likelyRatio
+}
+
+// Evidence оценивает надежность связи по размеру выборки и значимости
+func (i Insight) Evidence() Evidence {
+	switch {
+	case i.PValue < 0.01 && i.Exposed >= 10 && i.Unexposed >= 10:
+		return EvidenceStrong
+	case i.PValue < 0.05:
+		return EvidenceModerate
+	default:
+		return EvidenceWeak
+	}
+}
+
+// BadEntry сообщает, считается ли запись дневника плохой: твердый или жидкий стул,
+// заметная боль или очень срочный позыв. Тип 0 - не указан и плохим не считается.
+func BadEntry(e storage.DiaryEntry) bool {
+	if bristol.Valid(e.BristolType) && (e.BristolType <= 2 || e.BristolType >= 6) {
+		return true
+	}
+	return e.Pain >= 5 || e.Urgency >= 2
+}
+
+// Analyze сравнивает записи дневника с записями о еде, сне и стрессе.
+// Запись дневника участвует в сравнении по фактору, только если в его окне
+// есть записи нужного вида: иначе неизвестно, был фактор или его просто не отметили.
+// Возвращает факторы с достаточной выборкой: сначала вероятные триггеры по силе связи.
+func Analyze(entries []storage.DiaryEntry, lifestyle []storage.LifestyleEntry) []Insight {
+	sorted := make([]storage.LifestyleEntry, len(lifestyle))
+	copy(sorted, lifestyle)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].OccurredAt.Before(sorted[j].OccurredAt) })
+
+	var insights []Insight
+	for _, factor := range Factors() {
+		insight := Insight{Factor: factor}
+		var painExposed, painUnexposed int
+
+		for _, entry := range entries {
+			from := entry.OccurredAt.Add(-factor.MaxLag)
+			to := entry.OccurredAt.Add(-factor.MinLag)
+
+			observed, present := false, false
+			for _, l := range window(sorted, from, to) {
+				if l.Kind != factor.Kind {
+					continue
+				}
+				observed = true
+				if factor.Present(l) {
+					present = true
+					break
+				}
+			}
+			if !observed {
+				continue
+			}
+
+			bad := 0
+			if BadEntry(entry) {
+				bad = 1
+			}
+			if present {
+				insight.Exposed++
+				insight.ExposedBad += bad
+				painExposed += entry.Pain
+			} else {
+				insight.Unexposed++
+				insight.UnexposedBad += bad
+				painUnexposed += entry.Pain
+			}
+		}
+
+		if insight.Exposed < MinSamples || insight.Unexposed < MinSamples {
+			continue
+		}
+
+		insight.AvgPainExposed = float64(painExposed) / float64(insight.Exposed)
+		insight.AvgPainUnexposed = float64(painUnexposed) / float64(insight.Unexposed)
+		insight.PValue = fisherGreater(insight.ExposedBad, insight.Exposed-insight.ExposedBad,
+			insight.UnexposedBad, insight.Unexposed-insight.UnexposedBad)
+		insights = append(insights, insight)
+	}
+
+	sort.SliceStable(insights, func(i, j int) bool {
+		if insights[i].Likely() != insights[j].Likely() {
+			return insights[i].Likely()
+		}
+		return insights[i].RelativeRisk() > insights[j].RelativeRisk()
+	})
+	return insights
+}
+
+// window возвращает записи из отсортированного по времени списка, попавшие в [from, to]
+func window(sorted []storage.LifestyleEntry, from, to time.Time) []storage.LifestyleEntry {
+	start := sort.Search(len(sorted), func(i int) bool { return !sorted[i].OccurredAt.Before(from) })
+	end := sort.Search(len(sorted), func(i int) bool { return sorted[i].OccurredAt.After(to) })
+	if start >= end {
+		return nil
+	}
+	return sorted[start:end]
+}
+
+func rate(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
+
+// fisherGreater - односторонний точный тест Фишера для таблицы [[a, b], [c, d]]:
+// вероятность получить a или больше при независимости строк и столбцов
+func fisherGreater(a, b, c, d int) float64 {
+	rowTotal := a + b
+	colTotal := a + c
+	n := a + b + c + d
+
+	maxA := min(rowTotal, colTotal)
+	p := 0.0
+	for x := a; x <= maxA; x++ {
+		p += math.Exp(logChoose(colTotal, x) + logChoose(n-colTotal, rowTotal-x) - logChoose(n, rowTotal))
+	}
+	return math.Min(p, 1)
+}
+
+func logChoose(n, k int) float64 {
+	if k < 0 || k > n {
+		return math.Inf(-1)
+	}
+	return lgamma(n+1) - lgamma(k+1) - lgamma(n-k+1)
+}
+
+func lgamma(n int) float64 {
+	v, _ := math.Lgamma(float64(n))
+	return v
+}
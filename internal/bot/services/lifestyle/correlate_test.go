@@ -0,0 +1,54 @@
+package lifestyle
+
+import (
+	"math"
+	"testing"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+func TestBadEntry(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry storage.DiaryEntry
+		want  bool
+	}{
+		{name: "type not set", entry: storage.DiaryEntry{BristolType: 0}, want: false},
+		{name: "type 1", entry: storage.DiaryEntry{BristolType: 1}, want: true},
+		{name: "type 4", entry: storage.DiaryEntry{BristolType: 4}, want: false},
+		{name: "type 7", entry: storage.DiaryEntry{BristolType: 7}, want: true},
+		{name: "type not set with pain", entry: storage.DiaryEntry{BristolType: 0, Pain: 5}, want: true},
+		{name: "type 4 with urgency", entry: storage.DiaryEntry{BristolType: 4, Urgency: 2}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BadEntry(tt.entry); got != tt.want {
+				t.Errorf("BadEntry(%+v) = %v, want %v", tt.entry, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFisherGreater(t *testing.T) {
+	// значения - точные суммы гипергеометрического распределения
+	tests := []struct {
+		a, b, c, d int
+		want       float64
+	}{
+		// "леди, пробующая чай": 17/70
+		{a: 3, b: 1, c: 1, d: 3, want: 17.0 / 70},
+		{a: 4, b: 0, c: 0, d: 4, want: 1.0 / 70},
+		// (C(9,8)*C(7,2) + C(9,9)*C(7,1)) / C(16,10)
+		{a: 8, b: 2, c: 1, d: 5, want: 196.0 / 8008},
+		// меньше ожидаемого - ничего не значит
+		{a: 0, b: 5, c: 5, d: 0, want: 1},
+	}
+
+	for _, tt := range tests {
+		got := fisherGreater(tt.a, tt.b, tt.c, tt.d)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("fisherGreater(%d, %d, %d, %d) = %.6f, want %.6f", tt.a, tt.b, tt.c, tt.d, got, tt.want)
+		}
+	}
+}
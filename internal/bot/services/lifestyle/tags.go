@@ -0,0 +1,77 @@
+package lifestyle
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+)
+
+// Tag - быстрая метка приема пищи. Подпись метки - в каталоге сообщений (lifestyle.tag.<key>).
+type Tag struct {
+	Key string
+	// Keywords - основы слов, по которым метка находится в свободном тексте
+	Keywords []string
+}
+
+// Tags - метки, которые можно выбрать кнопками или найти в описании еды
+var Tags = []Tag{
+	{Key: "dairy", Keywords: []string{"молок", "молоч", "сыр", "творог", "кефир", "йогурт", "сливк", "мороженое"}},
+	{Key: "gluten", Keywords: []string{"хлеб", "пшен", "макарон", "паста", "выпечк", "пицц", "булк", "батон", "печенье", "глютен"}},
+	{Key: "spicy", Keywords: []string{"остр", "перец", "перц", "халапеньо", "васаби"}},
+	{Key: "coffee", Keywords: []string{"кофе", "эспрессо", "капучино", "латте", "американо"}},
+	{Key: "alcohol", Keywords: []string{"алкогол", "вино", "вина", "пиво", "пива", "водк", "коньяк", "виски", "коктейл", "шампанск"}},
+	{Key: "fatty", Keywords: []string{"жирн", "жарен", "фастфуд", "бургер", "картошка фри", "шашлык", "сало"}},
+	{Key: "sweets", Keywords: []string{"сладк", "торт", "конфет", "шоколад", "десерт", "пирожн", "сахар"}},
+	{Key: "legumes", Keywords: []string{"бобов", "фасол", "горох", "чечевиц", "хумус"}},
+}
+
+// Label - подпись метки на языке locale
+func (t Tag) Label(locale string) string {
+	return i18n.T(locale, "lifestyle.tag."+t.Key, nil)
+}
+
+// TagLabel возвращает подпись метки или ее ключ, если метка неизвестна
+func TagLabel(locale, key string) string {
+	for _, t := range Tags {
+		if t.Key == key {
+			return t.Label(locale)
+		}
+	}
+	return key
+}
+
+// ValidTag сообщает, известна ли метка
+func ValidTag(key string) bool {
+	return slices.ContainsFunc(Tags, func(t Tag) bool { return t.Key == key })
+}
+
+// DetectTags находит метки в свободном описании еды. Порядок меток - как в Tags.
+func DetectTags(text string) []string {
+	text = strings.ToLower(text)
+
+	var found []string
+	for _, t := range Tags {
+		for _, keyword := range t.Keywords {
+			if strings.Contains(text, keyword) {
+				found = append(found, t.Key)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// MergeTags объединяет метки без повторов, сохраняя порядок Tags
+func MergeTags(sets ...[]string) []string {
+	var merged []string
+	for _, t := range Tags {
+		for _, set := range sets {
+			if slices.Contains(set, t.Key) {
+				merged = append(merged, t.Key)
+				break
+			}
+		}
+	}
+	return merged
+}
@@ -0,0 +1,292 @@
+package medications
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// CallbackPrefix и CallbackVersion - префикс и версия подписанных данных кнопок /meds
+const (
+	CallbackPrefix  = "med:"
+	CallbackVersion = 1
+)
+
+// maxNameLength - ограничение длины названия препарата в символах
+const maxNameLength = 100
+
+// Effect - известное влияние препарата на стул
+type Effect struct {
+	// Keywords - основы слов в тексте анализа на всех языках ответа, которые объясняются препаратом
+	Keywords []string
+	// BristolTypes - типы по Бристольской шкале, которые объясняются препаратом
+	BristolTypes []int
+	// Note - ключ пояснения в каталоге сообщений
+	Note string
+}
+
+// Category - группа препаратов с похожим влиянием на стул. Название группы
+// для пользователя - в каталоге сообщений (medications.category.<key>),
+// Title - ее описание в запросе к модели.
+type Category struct {
+	Key   string
+	Emoji string
+	Title string
+	// Names - основы названий, по которым группа определяется автоматически
+	Names   []string
+	Effects []Effect
+}
+
+// OtherCategory - группа для препаратов без известного влияния на стул
+const OtherCategory = "other"
+
+var hardStool = []int{1, 2}
+var looseStool = []int{6, 7}
+
+// Categories - группы препаратов, о которых знает бот. Порядок важен для Detect:
+// слабительные проверяются раньше пробиотиков, чтобы лактулоза не считалась пробиотиком.
+var Categories = []Category{
+	{
+		Key:   "antibiotic",
+		Emoji: "💊",
+		Title: "Антибиотик",
+		Names: []string{"антибиот", "амокси", "аугмент", "флемокс", "азитро", "сумамед", "кларитро", "ципро", "левофлок", "метронид", "доксицикл", "цефтри", "цефурок", "цефикс", "ванкомиц", "рифаксим", "альфа нормикс",
+			"antibiot", "amoxi", "augment", "azithro", "clarithro", "cipro", "levoflox", "metronid", "doxycyc", "ceftri", "cefurox", "cefix", "vancomyc", "rifaxim"},
+		Effects: []Effect{{
+			Keywords:     []string{"жидк", "водянист", "диаре", "понос", "кашицеобраз", "liquid", "watery", "diarrh", "loose", "mushy"},
+			BristolTypes: looseStool,
+			Note:         "medications.note.antibiotic_loose",
+		}},
+	},
+	{
+		Key:   "iron",
+		Emoji: "🩸",
+		Title: "Железо",
+		Names: []string{"желез", "ферр", "сорбифер", "мальтофер", "тардиферон", "фенюльс", "актиферрин", "тотема", "iron"},
+		Effects: []Effect{
+			{
+				Keywords: []string{"черн", "темн", "дегтеоб", "зелено-черн", "black", "dark", "tarry"},
+				Note:     "medications.note.iron_color",
+			},
+			{
+				Keywords:     []string{"запор", "тверд", "сухо", "constipat", "hard", "dry"},
+				BristolTypes: hardStool,
+				Note:         "medications.note.iron_hard",
+			},
+		},
+	},
+	{
+		Key:   "bismuth",
+		Emoji: "⚫",
+		Title: "Висмут",
+		Names: []string{"висмут", "де-нол", "денол", "новобисмол", "bismuth", "pepto"},
+		Effects: []Effect{{
+			Keywords: []string{"черн", "темн", "дегтеоб", "black", "dark", "tarry"},
+			Note:     "medications.note.bismuth_color",
+		}},
+	},
+	{
+		Key:   "ppi",
+		Emoji: "🧪",
+		Title: "ИПП (омепразол и др.)",
+		Names: []string{"омепраз", "эзомепраз", "пантопраз", "лансопраз", "рабепраз", "декслансопраз", "омез", "нексиум", "нольпаза", "контролок", "париет",
+			"omepraz", "esomepraz", "pantopraz", "lansopraz", "rabepraz", "nexium", "prilosec"},
+		Effects: []Effect{{
+			Keywords:     []string{"жидк", "диаре", "понос", "liquid", "diarrh", "loose"},
+			BristolTypes: looseStool,
+			Note:         "medications.note.ppi_loose",
+		}},
+	},
+	{
+		Key:   "laxative",
+		Emoji: "🚽",
+		Title: "Слабительное",
+		Names: []string{"слабит", "лактулоз", "дюфалак", "нормазе", "макрогол", "форлакс", "транзипег", "фортранс", "сенна", "сенаде", "бисакодил", "дульколакс", "гутталакс", "пикосульф", "мукофальк",
+			"laxat", "lactulos", "macrogol", "miralax", "senna", "bisacodyl", "dulcolax", "picosulf", "psyllium", "metamucil"},
+		Effects: []Effect{{
+			Keywords:     []string{"жидк", "водянист", "мягк", "диаре", "liquid", "watery", "soft", "diarrh"},
+			BristolTypes: looseStool,
+			Note:         "medications.note.laxative_loose",
+		}},
+	},
+	{
+		Key:   "probiotic",
+		Emoji: "🦠",
+		Title: "Пробиотик",
+		Names: []string{"пробиот", "линекс", "бифи", "лактобак", "энтерол", "аципол", "максилак", "флорок", "сахаромицет",
+			"probiot", "lactobac", "bifido", "saccharomyc", "florastor", "culturelle"},
+		Effects: []Effect{{
+			Keywords: []string{"вздут", "газообраз", "метеоризм", "bloat", "flatulen"},
+			Note:     "medications.note.probiotic_bloating",
+		}},
+	},
+	{
+		Key:   "antidiarrheal",
+		Emoji: "🛑",
+		Title: "Закрепляющее",
+		Names: []string{"лоперамид", "имодиум", "лопедиум", "диара", "смект", "диосмект", "loperamid", "imodium", "smecta"},
+		Effects: []Effect{{
+			Keywords:     []string{"запор", "тверд", "сухо", "constipat", "hard", "dry"},
+			BristolTypes: hardStool,
+			Note:         "medications.note.antidiarrheal_hard",
+		}},
+	},
+	{
+		Key:   OtherCategory,
+		Emoji: "📦",
+		Title: "Другое",
+	},
+}
+
+// Label - подпись группы для кнопок и сообщений на языке locale
+func (c *Category) Label(locale string) string {
+	return c.Emoji + " " + i18n.T(locale, "medications.category."+c.Key, nil)
+}
+
+// Find возвращает группу по ключу или nil
+func Find(key string) *Category {
+	for i := range Categories {
+		if Categories[i].Key == key {
+			return &Categories[i]
+		}
+	}
+	return nil
+}
+
+// Label возвращает подпись группы препарата
+func Label(locale, key string) string {
+	if c := Find(key); c != nil {
+		return c.Label(locale)
+	}
+	return Find(OtherCategory).Label(locale)
+}
+
+// Detect угадывает группу по названию препарата, OtherCategory - если не удалось
+func Detect(name string) string {
+	name = strings.ToLower(name)
+	for _, c := range Categories {
+		for _, stem := range c.Names {
+			if strings.Contains(name, stem) {
+				return c.Key
+			}
+		}
+	}
+	return OtherCategory
+}
+
+// NormalizeName убирает лишние пробелы и обрезает название по длине
+func NormalizeName(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	if runes := []rune(name); len(runes) > maxNameLength {
+		name = string(runes[:maxNameLength])
+	}
+	return name
+}
+
+// Active возвращает препараты, которые принимались в момент t
+func Active(medications []storage.Medication, t time.Time) []storage.Medication {
+	var active []storage.Medication
+	for _, m := range medications {
+		if m.ActiveAt(t) {
+			active = append(active, m)
+		}
+	}
+	return active
+}
+
+// Annotation - находка, которую может объяснять принимаемый препарат
+type Annotation struct {
+	Medication storage.Medication
+	Note       string
+}
+
+// Text описывает пометку для пользователя и отчета на языке locale
+func (a Annotation) Text(locale string) string {
+	return fmt.Sprintf("%s: %s", a.Medication.Name, i18n.T(locale, a.Note, nil))
+}
+
+// Explain ищет находки в оценке анализа и типе стула, которые обычно объясняются
+// препаратами из medications. Предложения с отрицанием пропускаются.
+func Explain(medications []storage.Medication, text string, bristolType int) []Annotation {
+	text = affirmative(text)
+
+	var annotations []Annotation
+	for _, m := range medications {
+		c := Find(m.Category)
+		if c == nil {
+			continue
+		}
+		for _, effect := range c.Effects {
+			if effect.matches(text, bristolType) {
+				annotations = append(annotations, Annotation{Medication: m, Note: effect.Note})
+			}
+		}
+	}
+	return annotations
+}
+
+func (e Effect) matches(text string, bristolType int) bool {
+	for _, t := range e.BristolTypes {
+		if t == bristolType {
+			return true
+		}
+	}
+	for _, keyword := range e.Keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// negations - слова отрицания на языках ответа модели
+var negations = map[string]bool{
+	"нет": true, "не": true, "без": true,
+	"no": true, "not": true, "without": true, "never": true,
+}
+
+// affirmative оставляет из текста только предложения без отрицаний,
+// чтобы "крови нет" и "no blood" не считались находкой
+func affirmative(text string) string {
+	sentences := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return strings.ContainsRune(".!?\n", r)
+	})
+
+	var kept []string
+	for _, sentence := range sentences {
+		if !Negated(sentence) {
+			kept = append(kept, sentence)
+		}
+	}
+	return strings.Join(kept, ". ")
+}
+
+// Negated сообщает, есть ли в предложении отрицание, включая "isn't" и "doesn't"
+func Negated(sentence string) bool {
+	words := strings.FieldsFunc(sentence, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\'' && r != '’'
+	})
+	for _, word := range words {
+		if negations[word] || strings.HasSuffix(word, "n't") || strings.HasSuffix(word, "n’t") {
+			return true
+		}
+	}
+	return false
+}
+
+// PromptLines описывает принимаемые препараты для промпта модели: название и группа
+func PromptLines(medications []storage.Medication) []string {
+	lines := make([]string, 0, len(medications))
+	for _, m := range medications {
+		line := m.Name
+		if c := Find(m.Category); c != nil && c.Key != OtherCategory {
+			line += " (" + c.Title + ")"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
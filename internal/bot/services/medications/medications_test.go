@@ -0,0 +1,50 @@
+package medications
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+func TestExplain(t *testing.T) {
+	iron := storage.Medication{Name: "Iron", Category: "iron"}
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "russian dark stool", text: "Стул темного цвета.", want: []string{"medications.note.iron_color"}},
+		{name: "russian negation", text: "Крови нет, стул темный.", want: nil},
+		{name: "english dark stool", text: "The stool is dark, almost black.", want: []string{"medications.note.iron_color"}},
+		{name: "english negation", text: "The stool is not dark. It isn't hard either.", want: nil},
+		{name: "english hard stool", text: "Signs of constipation.", want: []string{"medications.note.iron_hard"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, a := range Explain([]storage.Medication{iron}, tt.text, 0) {
+				got = append(got, a.Note)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Explain(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectEnglishNames(t *testing.T) {
+	for name, want := range map[string]string{
+		"Iron bisglycinate": "iron",
+		"Amoxicillin":       "antibiotic",
+		"Omeprazole 20 mg":  "ppi",
+		"Lactulose":         "laxative",
+		"Imodium":           "antidiarrheal",
+	} {
+		if got := Detect(name); got != want {
+			t.Errorf("Detect(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package preferences
+
+import (
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+const (
+	// CallbackPrefix - префикс кнопок /settings
+	CallbackPrefix = "set:"
+	// CallbackVersion - версия формата кнопок /settings
+	CallbackVersion = 1
+)
+
+// Choice - вариант настройки, который выбирается кнопкой
+type Choice struct {
+	Key string
+	// Message - ключ подписи в каталоге сообщений
+	Message string
+}
+
+// Label - подпись варианта на языке locale
+func (c Choice) Label(locale string) string {
+	return i18n.T(locale, c.Message, nil)
+}
+
+// Languages - языки ответов бота. Пустой ключ - язык из настроек Telegram.
+var Languages = []Choice{
+	{Key: "", Message: "settings.language.telegram"},
+	{Key: "ru", Message: "settings.language.ru"},
+	{Key: "en", Message: "settings.language.en"},
+}
+
+// NotificationModes - как присылать напоминания
+var NotificationModes = []Choice{
+	{Key: storage.NotifySound, Message: "settings.notify.sound"},
+	{Key: storage.NotifySilent, Message: "settings.notify.silent"},
+	{Key: storage.NotifyOff, Message: "settings.notify.off"},
+}
+
+// Valid сообщает, есть ли вариант с ключом key
+func Valid(choices []Choice, key string) bool {
+	for _, c := range choices {
+		if c.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Label возвращает подпись варианта или сам ключ, если вариант неизвестен
+func Label(locale string, choices []Choice, key string) string {
+	for _, c := range choices {
+		if c.Key == key {
+			return c.Label(locale)
+		}
+	}
+	return key
+}
+
+// OnOff - подпись для переключателей
+func OnOff(locale string, enabled bool) string {
+	if enabled {
+		return i18n.T(locale, "settings.on", nil)
+	}
+	return i18n.T(locale, "settings.off", nil)
+}
@@ -0,0 +1,154 @@
+package profile
+
+import (
+	"strings"
+
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+const (
+	// CallbackPrefix - префикс кнопок /profile
+	CallbackPrefix = "profile:"
+	// CallbackVersion - версия формата кнопок /profile
+	CallbackVersion = 1
+)
+
+// Option - вариант ответа анкеты. Подпись варианта - в каталоге сообщений (profile.option.<key>).
+type Option struct {
+	Key string
+	// Prompt - как вариант описывается в запросе к модели, пусто - не описывается
+	Prompt string
+}
+
+// Варианты уровня детализации ответов
+const (
+	DetailBrief    = "brief"
+	DetailStandard = "standard"
+	DetailDetailed = "detailed"
+)
+
+const (
+	// Skip - вариант "не указывать"
+	Skip = "skip"
+	// ConditionNone - вариант "нет диагнозов" в списке заболеваний
+	ConditionNone = "none"
+)
+
+// AgeRanges - возрастные группы
+var AgeRanges = []Option{
+	{Key: "under_18", Prompt: "возраст до 18 лет"},
+	{Key: "18_29", Prompt: "возраст 18–29 лет"},
+	{Key: "30_44", Prompt: "возраст 30–44 года"},
+	{Key: "45_59", Prompt: "возраст 45–59 лет"},
+	{Key: "60_plus", Prompt: "возраст 60 лет и старше"},
+	{Key: Skip},
+}
+
+// Sexes - варианты пола
+var Sexes = []Option{
+	{Key: "female", Prompt: "пол женский"},
+	{Key: "male", Prompt: "пол мужской"},
+	{Key: Skip},
+}
+
+// Conditions - известные диагнозы, можно выбрать несколько
+var Conditions = []Option{
+	{Key: "ibs", Prompt: "синдром раздраженного кишечника"},
+	{Key: "ibd", Prompt: "воспалительное заболевание кишечника (болезнь Крона или язвенный колит)"},
+	{Key: "celiac", Prompt: "целиакия"},
+	{Key: "post_surgery", Prompt: "перенесенная операция на органах ЖКТ"},
+}
+
+// Diets - типы питания
+var Diets = []Option{
+	{Key: "omnivore", Prompt: "обычное смешанное питание"},
+	{Key: "vegetarian", Prompt: "вегетарианское питание"},
+	{Key: "vegan", Prompt: "веганское питание"},
+	{Key: "gluten_free", Prompt: "безглютеновая диета"},
+	{Key: "low_fodmap", Prompt: "диета с низким содержанием FODMAP"},
+	{Key: "keto", Prompt: "кетогенное или низкоуглеводное питание"},
+}
+
+// DetailLevels - насколько подробно отвечать
+var DetailLevels = []Option{
+	{Key: DetailBrief, Prompt: "Пользователь просит отвечать кратко: главное в 4-6 предложениях, без подробных объяснений."},
+	{Key: DetailStandard},
+	{Key: DetailDetailed, Prompt: "Пользователь просит подробный ответ: объясняй причины и механизмы, приводи больше практических советов."},
+}
+
+// Label - подпись варианта на языке locale
+func (o Option) Label(locale string) string {
+	return i18n.T(locale, "profile.option."+o.Key, nil)
+}
+
+// Find возвращает вариант по ключу или nil
+func Find(options []Option, key string) *Option {
+	for i := range options {
+		if options[i].Key == key {
+			return &options[i]
+		}
+	}
+	return nil
+}
+
+// Label возвращает подпись варианта или "не указано"
+func Label(locale string, options []Option, key string) string {
+	if o := Find(options, key); o != nil && o.Key != Skip {
+		return o.Label(locale)
+	}
+	return i18n.T(locale, "profile.not_set", nil)
+}
+
+// ConditionsLabel перечисляет заболевания для пользователя
+func ConditionsLabel(locale string, keys []string) string {
+	var labels []string
+	for _, key := range keys {
+		if o := Find(Conditions, key); o != nil {
+			labels = append(labels, o.Label(locale))
+		}
+	}
+	if len(labels) > 0 {
+		return strings.Join(labels, ", ")
+	}
+	if len(keys) > 0 && keys[0] == ConditionNone {
+		return i18n.T(locale, "profile.no_conditions", nil)
+	}
+	return i18n.T(locale, "profile.not_set", nil)
+}
+
+// PromptLines описывает анкету для запроса к модели. Сведения берутся только
+// из фиксированных вариантов ответа, текст пользователя сюда не попадает.
+func PromptLines(p *storage.Profile) []string {
+	if p == nil {
+		return nil
+	}
+
+	var lines []string
+	if o := Find(AgeRanges, p.AgeRange); o != nil && o.Prompt != "" {
+		lines = append(lines, o.Prompt)
+	}
+	if o := Find(Sexes, p.Sex); o != nil && o.Prompt != "" {
+		lines = append(lines, o.Prompt)
+	}
+	for _, key := range p.Conditions {
+		if o := Find(Conditions, key); o != nil {
+			lines = append(lines, "диагноз: "+o.Prompt)
+		}
+	}
+	if o := Find(Diets, p.Diet); o != nil {
+		lines = append(lines, o.Prompt)
+	}
+	return lines
+}
+
+// DetailPrompt возвращает указание модели о подробности ответа, пусто - по умолчанию
+func DetailPrompt(p *storage.Profile) string {
+	if p == nil {
+		return ""
+	}
+	if o := Find(DetailLevels, p.DetailLevel); o != nil {
+		return o.Prompt
+	}
+	return ""
+}
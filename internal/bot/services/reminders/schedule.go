@@ -0,0 +1,107 @@
+package reminders
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+)
+
+const minutesPerDay = 24 * 60
+
+// Timezone - часовой пояс, который можно выбрать кнопкой
+type Timezone struct {
+	Name string
+}
+
+// Label - название пояса на языке locale
+func (tz Timezone) Label(locale string) string {
+	return i18n.T(locale, "timezone."+tz.Name, nil)
+}
+
+// Timezones - часовые пояса, предлагаемые пользователю
+var Timezones = []Timezone{
+	{Name: "Europe/Kaliningrad"},
+	{Name: "Europe/Moscow"},
+	{Name: "Europe/Samara"},
+	{Name: "Asia/Yekaterinburg"},
+	{Name: "Asia/Omsk"},
+	{Name: "Asia/Novosibirsk"},
+	{Name: "Asia/Krasnoyarsk"},
+	{Name: "Asia/Irkutsk"},
+	{Name: "Asia/Yakutsk"},
+	{Name: "Asia/Vladivostok"},
+	{Name: "Asia/Magadan"},
+	{Name: "Asia/Kamchatka"},
+	{Name: "Europe/Minsk"},
+	{Name: "Europe/Kyiv"},
+	{Name: "Asia/Almaty"},
+	{Name: "Asia/Tashkent"},
+	{Name: "Asia/Tbilisi"},
+	{Name: "Europe/Berlin"},
+	{Name: "Europe/London"},
+	{Name: "UTC"},
+}
+
+// TimezoneLabel возвращает название пояса для пользователя
+func TimezoneLabel(locale, name string) string {
+	for _, tz := range Timezones {
+		if tz.Name == name {
+			return tz.Label(locale)
+		}
+	}
+	return name
+}
+
+// NextRun возвращает ближайший после after момент, когда по местному времени loc
+// наступает minute минут от полуночи
+func NextRun(minute int, loc *time.Location, after time.Time) time.Time {
+	local := after.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), minute/60, minute%60, 0, 0, loc)
+	if !next.After(after) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, minute/60, minute%60, 0, 0, loc)
+	}
+	return next
+}
+
+// InQuiet сообщает, попадает ли момент t (в местном времени) в тихие часы [from, to).
+// Интервал может переходить через полночь. При from == to тихих часов нет.
+func InQuiet(from, to int, t time.Time) bool {
+	if from == to {
+		return false
+	}
+	m := t.Hour()*60 + t.Minute()
+	if from < to {
+		return m >= from && m < to
+	}
+	return m >= from || m < to
+}
+
+// FormatMinute выводит минуты от полуночи как ЧЧ:ММ
+func FormatMinute(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+// ParseMinute разбирает ЧЧ:ММ в минуты от полуночи
+func ParseMinute(text string) (int, error) {
+	hours, minutes, ok := strings.Cut(strings.TrimSpace(text), ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time %q", text)
+	}
+	h, err := strconv.Atoi(hours)
+	if err != nil || h < 0 || h > 23 {
+		return 0, fmt.Errorf("invalid hour in %q", text)
+	}
+	m, err := strconv.Atoi(minutes)
+	if err != nil || m < 0 || m > 59 {
+		return 0, fmt.Errorf("invalid minute in %q", text)
+	}
+	return h*60 + m, nil
+}
+
+// ValidMinute проверяет, что значение - время суток
+func ValidMinute(minute int) bool {
+	return minute >= 0 && minute < minutesPerDay
+}
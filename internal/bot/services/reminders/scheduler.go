@@ -0,0 +1,177 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/callback"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// CallbackPrefix и CallbackVersion - префикс и версия подписанных данных кнопок напоминаний
+const (
+	CallbackPrefix  = "rem:"
+	CallbackVersion = 1
+)
+
+const (
+	checkInterval = time.Minute
+	batchSize     = 100
+
+	// maxDelay - насколько может опоздать напоминание. Пропущенные, например
+	// пока бот был выключен, не отправляются, а переносятся на следующий день.
+	maxDelay = 30 * time.Minute
+	// retryDelay - через сколько повторить напоминание после ошибки отправки
+	retryDelay = 5 * time.Minute
+)
+
+// SnoozeOptions - варианты отсрочки в минутах
+var SnoozeOptions = []int{60, 180}
+
+// Scheduler отправляет ежедневные напоминания в местное время пользователей
+type Scheduler struct {
+	store storage.Storage
+	bot   *bot.Bot
+	codec *callback.Codec
+}
+
+func NewScheduler(store storage.Storage, b *bot.Bot, codec *callback.Codec) *Scheduler {
+	return &Scheduler{
+		store: store,
+		bot:   b,
+		codec: codec,
+	}
+}
+
+// Run проверяет напоминания раз в минуту, пока не отменен ctx
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Println("⏰ Reminder scheduler started")
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.tick(ctx, time.Now()); err != nil {
+			log.Printf("Error processing reminders: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("⏰ Reminder scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context, now time.Time) error {
+	due, err := s.store.Reminders().Due(ctx, now, batchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range due {
+		reminder := &due[i]
+		if err := s.fire(ctx, reminder, now); err != nil {
+			log.Printf("Error processing reminder %d: %v", reminder.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) fire(ctx context.Context, reminder *storage.Reminder, now time.Time) error {
+	userSettings, err := s.store.Settings().Get(ctx, reminder.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	loc := userSettings.Location()
+	local := now.In(loc)
+
+	switch {
+	case now.Sub(reminder.NextAt) > maxDelay:
+		reminder.NextAt = NextRun(reminder.Minute, loc, now)
+	case InQuiet(userSettings.QuietFrom, userSettings.QuietTo, local):
+		reminder.NextAt = NextRun(userSettings.QuietTo, loc, now)
+	case userSettings.Notifications == storage.NotifyOff:
+		// уведомления выключены в /settings: напоминание остается, но не отправляется
+		reminder.NextAt = NextRun(reminder.Minute, loc, now)
+	default:
+		locale := s.locale(ctx, userSettings)
+		err := s.send(ctx, reminder, locale, userSettings.Notifications == storage.NotifySilent)
+		switch {
+		case errors.Is(err, bot.ErrorForbidden):
+			// пользователь заблокировал бота
+			log.Printf("⏰ Reminder %d disabled: bot is blocked by user %d", reminder.ID, reminder.UserID)
+			reminder.Enabled = false
+		case err != nil:
+			log.Printf("Error sending reminder %d: %v", reminder.ID, err)
+			reminder.NextAt = now.Add(retryDelay)
+		default:
+			reminder.NextAt = NextRun(reminder.Minute, loc, now)
+		}
+	}
+
+	return s.store.Reminders().Update(ctx, reminder)
+}
+
+// Reschedule пересчитывает время срабатывания напоминаний пользователя,
+// например после смены часового пояса
+func Reschedule(ctx context.Context, repo storage.ReminderRepository, userID int64, loc *time.Location, now time.Time) error {
+	list, err := repo.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for i := range list {
+		list[i].NextAt = NextRun(list[i].Minute, loc, now)
+		if err := repo.Update(ctx, &list[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// locale возвращает язык пользователя так же, как userMiddleware:
+// выбранный в /settings или язык из настроек Telegram
+func (s *Scheduler) locale(ctx context.Context, userSettings *storage.Settings) string {
+	if i18n.Supported(userSettings.Language) {
+		return userSettings.Language
+	}
+	user, err := s.store.Users().Get(ctx, userSettings.UserID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Error loading user %d: %v", userSettings.UserID, err)
+		}
+		return i18n.DefaultLocale
+	}
+	return i18n.Resolve("", user.LanguageCode)
+}
+
+func (s *Scheduler) send(ctx context.Context, reminder *storage.Reminder, locale string, silent bool) error {
+	var snooze []models.InlineKeyboardButton
+	for _, minutes := range SnoozeOptions {
+		snooze = append(snooze, models.InlineKeyboardButton{
+			Text:         i18n.N(locale, "remind.button.snooze", minutes/60, nil),
+			CallbackData: s.codec.Encode(CallbackPrefix, CallbackVersion, "snooze", reminder.ID, minutes),
+		})
+	}
+
+	_, err := s.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    reminder.ChatID,
+		Text:      i18n.T(locale, "remind.message", nil),
+		ParseMode: models.ParseModeHTML,
+		// без звука, если пользователь выбрал тихие уведомления
+		DisableNotification: silent,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+			{{Text: i18n.T(locale, "remind.button.log", nil), CallbackData: s.codec.Encode(CallbackPrefix, CallbackVersion, "log")}},
+			snooze,
+			{{Text: i18n.T(locale, "remind.button.off", nil), CallbackData: s.codec.Encode(CallbackPrefix, CallbackVersion, "off", reminder.ID)}},
+		}},
+	})
+	return err
+}
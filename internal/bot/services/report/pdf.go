@@ -0,0 +1,240 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/ibs"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/medications"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/profile"
+)
+
+const (
+	fontFamily = "Go"
+
+	titleSize   = 16
+	headingSize = 12
+	textSize    = 10
+	smallSize   = 8
+
+	lineHeight = 5.5
+)
+
+// RenderPDF формирует PDF-отчет для врача на языке locale
+func RenderPDF(r *Report, locale string) ([]byte, error) {
+	t := func(key string, params i18n.Params) string { return i18n.T(locale, key, params) }
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontFamily, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", gobold.TTF)
+	pdf.SetTitle(t("report.pdf_title", nil), true)
+	pdf.SetAutoPageBreak(true, 18)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-14)
+		pdf.SetFont(fontFamily, "", smallSize)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 4, t("report.disclaimer", nil), "", 1, "C", false, 0, "")
+		pdf.CellFormat(0, 4, t("report.page", i18n.Params{"page": pdf.PageNo()}), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont(fontFamily, "B", titleSize)
+	pdf.CellFormat(0, 10, t("report.title", nil), "", 1, "L", false, 0, "")
+	pdf.SetFont(fontFamily, "", textSize)
+	pdf.SetTextColor(90, 90, 90)
+	writeLine(pdf, t("report.period", i18n.Params{
+		"from": r.Summary.From.Format("02.01.2006"),
+		"to":   r.Summary.To.AddDate(0, 0, -1).Format("02.01.2006"),
+		"days": i18n.N(locale, "report.days", r.Days, nil),
+	}))
+	writeLine(pdf, t("report.generated", i18n.Params{"time": r.GeneratedAt.In(r.Location).Format("02.01.2006 15:04 MST")}))
+	pdf.SetTextColor(0, 0, 0)
+
+	heading(pdf, t("report.section.patient", nil))
+	writeProfile(pdf, r, locale)
+
+	heading(pdf, t("report.section.summary", nil))
+	writeSummary(pdf, r, locale)
+	if err := writeCharts(pdf, r); err != nil {
+		return nil, err
+	}
+
+	heading(pdf, t("report.section.red_flags", nil))
+	writeRedFlags(pdf, r, locale)
+
+	heading(pdf, t("report.section.questionnaires", nil))
+	writeQuestionnaires(pdf, r, locale)
+
+	heading(pdf, t("report.section.medications", nil))
+	writeMedications(pdf, r, locale)
+
+	heading(pdf, t("report.section.ai_summary", nil))
+	if r.AISummary != "" {
+		pdf.SetFont(fontFamily, "", textSize)
+		pdf.MultiCell(0, lineHeight, strings.TrimSpace(r.AISummary), "", "L", false)
+	} else {
+		writeLine(pdf, t("report.ai_unavailable", nil))
+	}
+	pdf.SetFont(fontFamily, "", smallSize)
+	pdf.SetTextColor(120, 120, 120)
+	pdf.MultiCell(0, 4, t("report.ai_note", nil), "", "L", false)
+	pdf.SetTextColor(0, 0, 0)
+
+	if err := pdf.Error(); err != nil {
+		return nil, fmt.Errorf("failed to build pdf: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render pdf: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func heading(pdf *fpdf.Fpdf, text string) {
+	pdf.Ln(4)
+	pdf.SetFont(fontFamily, "B", headingSize)
+	pdf.CellFormat(0, 8, text, "B", 1, "L", false, 0, "")
+	pdf.Ln(1)
+	pdf.SetFont(fontFamily, "", textSize)
+}
+
+func writeLine(pdf *fpdf.Fpdf, text string) {
+	pdf.MultiCell(0, lineHeight, text, "", "L", false)
+}
+
+func writeProfile(pdf *fpdf.Fpdf, r *Report, locale string) {
+	name := r.User.FirstName
+	if r.User.Username != "" {
+		name = strings.TrimSpace(name + " (@" + r.User.Username + ")")
+	}
+	if name == "" {
+		name = i18n.T(locale, "profile.not_set", nil)
+	}
+	writeLine(pdf, i18n.T(locale, "report.telegram_name", i18n.Params{"name": name}))
+	writeLine(pdf, i18n.T(locale, "report.timezone", i18n.Params{"timezone": r.Location.String()}))
+
+	p := r.Profile
+	if p == nil {
+		writeLine(pdf, i18n.T(locale, "report.no_profile", nil))
+		return
+	}
+	field := func(key, value string) {
+		writeLine(pdf, i18n.T(locale, key, i18n.Params{"value": value}))
+	}
+	field("report.age", profile.Label(locale, profile.AgeRanges, p.AgeRange))
+	field("report.sex", plainLabel(profile.Label(locale, profile.Sexes, p.Sex)))
+	field("report.conditions", profile.ConditionsLabel(locale, p.Conditions))
+	field("report.diet", plainLabel(profile.Label(locale, profile.Diets, p.Diet)))
+}
+
+func writeSummary(pdf *fpdf.Fpdf, r *Report, locale string) {
+	s := r.Summary
+	if s.Total == 0 {
+		writeLine(pdf, i18n.T(locale, "report.no_entries", nil))
+		return
+	}
+
+	writeLine(pdf, i18n.T(locale, "report.total", i18n.Params{"total": s.Total, "avg": fmt.Sprintf("%.1f", s.AvgPerDay)}))
+	if s.Typed > 0 {
+		writeLine(pdf, i18n.T(locale, "report.normal_share", i18n.Params{"percent": fmt.Sprintf("%.0f", s.NormalShare*100)}))
+	}
+	writeLine(pdf, i18n.T(locale, "report.avg_pain", i18n.Params{"pain": fmt.Sprintf("%.1f", s.AvgPain)}))
+
+	var counts []string
+	for number := 1; number <= 7; number++ {
+		counts = append(counts, i18n.T(locale, "report.type_count", i18n.Params{"number": number, "count": s.BristolCounts[number]}))
+	}
+	writeLine(pdf, i18n.T(locale, "report.distribution", i18n.Params{"counts": strings.Join(counts, ", ")}))
+}
+
+func writeCharts(pdf *fpdf.Fpdf, r *Report) error {
+	pageWidth, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	width := pageWidth - left - right
+
+	for _, chart := range r.Charts {
+		options := fpdf.ImageOptions{ImageType: "PNG", ReadDpi: false}
+		info := pdf.RegisterImageOptionsReader(chart.Name, options, bytes.NewReader(chart.PNG))
+		if err := pdf.Error(); err != nil {
+			return fmt.Errorf("failed to embed chart %s: %w", chart.Name, err)
+		}
+
+		// высота пропорциональна ширине, чтобы не искажать график
+		height := width * info.Height() / info.Width()
+		pdf.Ln(2)
+		pdf.ImageOptions(chart.Name, left, pdf.GetY(), width, height, true, options, 0, "")
+	}
+	return nil
+}
+
+func writeRedFlags(pdf *fpdf.Fpdf, r *Report, locale string) {
+	if len(r.RedFlags) == 0 {
+		writeLine(pdf, i18n.T(locale, "report.no_red_flags", nil))
+		return
+	}
+
+	for _, flag := range r.RedFlags {
+		pdf.SetTextColor(180, 30, 30)
+		writeLine(pdf, "• "+flag.At.In(r.Location).Format("02.01.2006 15:04")+" - "+flag.Description(locale))
+		if note := flag.Note(locale); note != "" {
+			pdf.SetTextColor(110, 110, 110)
+			writeLine(pdf, "   "+i18n.T(locale, "report.possible_cause", i18n.Params{"note": note}))
+		}
+	}
+	pdf.SetTextColor(0, 0, 0)
+}
+
+func writeMedications(pdf *fpdf.Fpdf, r *Report, locale string) {
+	if len(r.Medications) == 0 {
+		writeLine(pdf, i18n.T(locale, "report.no_medications", nil))
+		return
+	}
+
+	for _, c := range r.Medications {
+		m := c.Medication
+		period := i18n.T(locale, "report.course_since", i18n.Params{"from": m.StartedAt.In(r.Location).Format("02.01.2006")})
+		if !m.StoppedAt.IsZero() {
+			period = i18n.T(locale, "report.course_range", i18n.Params{
+				"from": m.StartedAt.In(r.Location).Format("02.01.2006"),
+				"to":   m.StoppedAt.In(r.Location).Format("02.01.2006"),
+			})
+		}
+		writeLine(pdf, i18n.N(locale, "report.course", c.Intakes, i18n.Params{
+			"name":     m.Name,
+			"category": plainLabel(medications.Label(locale, m.Category)),
+			"period":   period,
+		}))
+	}
+}
+
+func writeQuestionnaires(pdf *fpdf.Fpdf, r *Report, locale string) {
+	var found bool
+	for _, q := range r.Questionnaires {
+		if q.Kind != ibs.Kind {
+			continue
+		}
+		found = true
+		writeLine(pdf, i18n.N(locale, "report.questionnaire", q.Score, i18n.Params{
+			"date": q.CreatedAt.In(r.Location).Format("02.01.2006"),
+			"band": plainLabel(ibs.Band(q.Band).Label(locale)),
+		}))
+	}
+	if !found {
+		writeLine(pdf, i18n.T(locale, "report.no_questionnaires", nil))
+	}
+}
+
+// plainLabel убирает эмодзи в начале подписи: в шрифте PDF их нет
+func plainLabel(label string) string {
+	return strings.TrimLeftFunc(label, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
@@ -0,0 +1,131 @@
+package report
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/ibs"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/medications"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/stats"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// RedFlag - событие, которое стоит обсудить с врачом
+type RedFlag struct {
+	At time.Time
+	// Message - ключ описания в каталоге сообщений, Count - число в нем ({count})
+	Message string
+	Count   int
+	// Finding - ключ находки в анализе фото ({finding}), если признак из анализа
+	Finding string
+	// Annotations - препараты, которыми признак может объясняться
+	Annotations []medications.Annotation
+}
+
+// Description описывает признак на языке locale
+func (f RedFlag) Description(locale string) string {
+	var params i18n.Params
+	if f.Finding != "" {
+		params = i18n.Params{"finding": i18n.T(locale, f.Finding, nil)}
+	}
+	return i18n.N(locale, f.Message, f.Count, params)
+}
+
+// Note - чем признак может объясняться, пусто - объяснений нет
+func (f RedFlag) Note(locale string) string {
+	notes := make([]string, len(f.Annotations))
+	for i, a := range f.Annotations {
+		notes[i] = a.Text(locale)
+	}
+	return strings.Join(notes, "; ")
+}
+
+const (
+	severePain       = 8
+	frequentPerDay   = 6
+	maxUrgency       = 2
+	watery           = 7
+	alarmSentenceSep = ".!?\n"
+)
+
+// alarmKeywords - признаки из текста анализа на языках ответа модели, которые
+// нельзя пропустить, и ключи их названий в каталоге сообщений
+var alarmKeywords = map[string]string{
+	"кров":     "report.finding.blood",
+	"дегтеоб":  "report.finding.tarry",
+	"черн":     "report.finding.black",
+	"глинист":  "report.finding.clay",
+	"обесцвеч": "report.finding.pale",
+	"blood":    "report.finding.blood",
+	"tarry":    "report.finding.tarry",
+	"black":    "report.finding.black",
+	"clay":     "report.finding.clay",
+	"pale":     "report.finding.pale",
+}
+
+// DetectRedFlags ищет тревожные признаки в дневнике, анализах и опросниках
+func DetectRedFlags(entries []storage.DiaryEntry, analyses []storage.Analysis, questionnaires []storage.QuestionnaireResult, summary stats.Summary) []RedFlag {
+	var flags []RedFlag
+
+	for _, e := range entries {
+		if e.OccurredAt.Before(summary.From) {
+			continue
+		}
+		if e.Pain >= severePain {
+			flags = append(flags, RedFlag{At: e.OccurredAt, Message: "report.flag.severe_pain", Count: e.Pain})
+		}
+		if e.BristolType == watery && e.Urgency >= maxUrgency {
+			flags = append(flags, RedFlag{At: e.OccurredAt, Message: "report.flag.watery_urgent"})
+		}
+	}
+
+	for _, day := range summary.Days {
+		if day.Count >= frequentPerDay {
+			flags = append(flags, RedFlag{At: day.Date, Message: "report.flag.frequent", Count: day.Count})
+		}
+	}
+
+	for _, a := range analyses {
+		for _, finding := range alarmFindings(a.Diagnosis) {
+			flags = append(flags, RedFlag{At: a.CreatedAt, Message: "report.flag.photo", Finding: finding})
+		}
+	}
+
+	for _, q := range questionnaires {
+		if q.Kind == ibs.Kind && ibs.Band(q.Band) == ibs.BandSevere {
+			flags = append(flags, RedFlag{At: q.CreatedAt, Message: "report.flag.ibs_severe", Count: q.Score})
+		}
+	}
+
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].At.Before(flags[j].At)
+	})
+	return flags
+}
+
+// alarmFindings ищет ключевые слова в оценке анализа, пропуская предложения с отрицанием.
+// Возвращает ключи находок в каталоге сообщений.
+func alarmFindings(text string) []string {
+	var findings []string
+	seen := make(map[string]bool)
+
+	sentences := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return strings.ContainsRune(alarmSentenceSep, r)
+	})
+	for _, sentence := range sentences {
+		if medications.Negated(sentence) {
+			continue
+		}
+		for keyword, finding := range alarmKeywords {
+			if strings.Contains(sentence, keyword) && !seen[finding] {
+				seen[finding] = true
+				findings = append(findings, finding)
+			}
+		}
+	}
+
+	sort.Strings(findings)
+	return findings
+}
@@ -0,0 +1,211 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/gemini"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/ibs"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/medications"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/profile"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/stats"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// Report - данные отчета для врача за выбранный период
+type Report struct {
+	GeneratedAt time.Time
+	Days        int
+	Location    *time.Location
+
+	User     *storage.User
+	Settings *storage.Settings
+	// Profile - анкета пользователя, nil - не заполнена
+	Profile *storage.Profile
+
+	Summary        stats.Summary
+	Charts         []stats.Chart
+	RedFlags       []RedFlag
+	Questionnaires []storage.QuestionnaireResult
+	Medications    []Course
+
+	// AISummary - краткое описание от модели, не является диагнозом
+	AISummary string
+}
+
+// Course - препарат, который принимался в период отчета
+type Course struct {
+	Medication storage.Medication
+	// Intakes - сколько дней за период прием отмечен в боте
+	Intakes int
+}
+
+// Collect собирает данные отчета и рисует графики с подписями на языке locale
+func Collect(ctx context.Context, store storage.Storage, userID int64, days int, now time.Time, locale string) (*Report, error) {
+	settings, err := store.Settings().Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	loc := settings.Location()
+
+	user, err := store.Users().Get(ctx, userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		user = &storage.User{ID: userID}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+
+	userProfile, err := store.Profiles().Get(ctx, userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		userProfile = nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	from := now.AddDate(0, 0, -days-1)
+	entries, err := store.Diary().List(ctx, userID, storage.DiaryFilter{From: from})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load diary: %w", err)
+	}
+
+	summary := stats.Compute(entries, days, now, loc)
+
+	analyses, err := store.Analyses().List(ctx, userID, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load analyses: %w", err)
+	}
+
+	results, err := store.Questionnaires().List(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load questionnaires: %w", err)
+	}
+
+	var questionnaires []storage.QuestionnaireResult
+	for _, r := range results {
+		if !r.CreatedAt.Before(summary.From) {
+			questionnaires = append(questionnaires, r)
+		}
+	}
+
+	var periodAnalyses []storage.Analysis
+	for _, a := range analyses {
+		if !a.CreatedAt.Before(summary.From) {
+			periodAnalyses = append(periodAnalyses, a)
+		}
+	}
+
+	courses, err := collectCourses(ctx, store.Medications(), userID, summary.From, summary.To)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{
+		GeneratedAt:    now,
+		Days:           days,
+		Location:       loc,
+		User:           user,
+		Settings:       settings,
+		Profile:        userProfile,
+		Summary:        summary,
+		RedFlags:       DetectRedFlags(entries, periodAnalyses, questionnaires, summary),
+		Questionnaires: questionnaires,
+		Medications:    courses,
+	}
+	annotateRedFlags(r.RedFlags, courses, locale)
+
+	if summary.Total > 0 {
+		r.Charts, err = stats.RenderCharts(summary, locale)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// SummaryPrompt - запрос к модели на краткое описание на языке locale. В него попадают
+// только агрегированные показатели, без заметок пользователя.
+func (r *Report) SummaryPrompt(locale string) string {
+	var sb strings.Builder
+
+	sb.WriteString("Ты помогаешь пациенту подготовиться к приему гастроэнтеролога. ")
+	sb.WriteString("По агрегированным данным дневника стула напиши нейтральное описание из 3-5 предложений для врача. ")
+	sb.WriteString("Не ставь диагнозов, не назначай лечение, не используй markdown. Опиши только наблюдаемые закономерности.\n")
+	if instruction := gemini.LanguagePrompt(locale); instruction != "" {
+		sb.WriteString(instruction + "\n")
+	}
+	sb.WriteString("\n")
+
+	for _, line := range profile.PromptLines(r.Profile) {
+		fmt.Fprintf(&sb, "О пациенте: %s\n", line)
+	}
+
+	s := r.Summary
+	fmt.Fprintf(&sb, "Период: %d дней\n", r.Days)
+	fmt.Fprintf(&sb, "Записей: %d, в среднем %.1f в день\n", s.Total, s.AvgPerDay)
+	for number := 1; number <= 7; number++ {
+		fmt.Fprintf(&sb, "Бристольский тип %d: %d\n", number, s.BristolCounts[number])
+	}
+	fmt.Fprintf(&sb, "Доля типов 3-4: %.0f%%\n", s.NormalShare*100)
+	fmt.Fprintf(&sb, "Средняя боль: %.1f из 10\n", s.AvgPain)
+
+	for _, q := range r.Questionnaires {
+		if q.Kind == ibs.Kind {
+			fmt.Fprintf(&sb, "IBS-SSS %s: %d баллов\n", q.CreatedAt.In(r.Location).Format("02.01"), q.Score)
+		}
+	}
+	// сами данные описываются по-русски, как и весь запрос; язык ответа задан выше
+	for _, flag := range r.RedFlags {
+		fmt.Fprintf(&sb, "Тревожный признак: %s\n", flag.Description(i18n.DefaultLocale))
+	}
+	// названия препаратов вводит пользователь, поэтому в запрос попадают только группы
+	for _, c := range r.Medications {
+		if category := medications.Find(c.Medication.Category); category != nil {
+			fmt.Fprintf(&sb, "Принимал препарат: %s\n", category.Title)
+		}
+	}
+
+	return sb.String()
+}
+
+// collectCourses находит препараты, курс которых пересекается с [from, to), и считает отметки о приеме
+func collectCourses(ctx context.Context, repo storage.MedicationRepository, userID int64, from, to time.Time) ([]Course, error) {
+	list, err := repo.List(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load medications: %w", err)
+	}
+
+	intakes, err := repo.Intakes(ctx, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load medication intakes: %w", err)
+	}
+	counts := make(map[int64]int)
+	for _, intake := range intakes {
+		counts[intake.MedicationID]++
+	}
+
+	var courses []Course
+	for _, m := range list {
+		if m.StartedAt.Before(to) && (m.StoppedAt.IsZero() || m.StoppedAt.After(from)) {
+			courses = append(courses, Course{Medication: m, Intakes: counts[m.ID]})
+		}
+	}
+	return courses, nil
+}
+
+// annotateRedFlags помечает тревожные признаки, которые обычно объясняются принимаемыми препаратами.
+// Описание признака сверяется на языке отчета: признаки препаратов знают оба языка.
+func annotateRedFlags(flags []RedFlag, courses []Course, locale string) {
+	all := make([]storage.Medication, len(courses))
+	for i, c := range courses {
+		all[i] = c.Medication
+	}
+
+	for i := range flags {
+		flags[i].Annotations = medications.Explain(medications.Active(all, flags[i].At), flags[i].Description(locale), 0)
+	}
+}
@@ -0,0 +1,52 @@
+package report
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/gemini"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+func TestSummaryPromptLanguage(t *testing.T) {
+	r := &Report{Days: 7, Location: time.UTC}
+
+	if got := r.SummaryPrompt("en"); !strings.Contains(got, gemini.LanguagePrompt("en")) {
+		t.Errorf("English prompt has no language instruction:\n%s", got)
+	}
+	if got := r.SummaryPrompt("ru"); strings.Contains(got, gemini.LanguagePrompt("en")) {
+		t.Errorf("Russian prompt asks for English:\n%s", got)
+	}
+}
+
+func TestAlarmFindings(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "Стул черный, дегтеобразный.", want: []string{"report.finding.black", "report.finding.tarry"}},
+		{text: "Крови нет.", want: nil},
+		{text: "The stool is black and tarry.", want: []string{"report.finding.black", "report.finding.tarry"}},
+		{text: "There is no blood.", want: nil},
+	}
+
+	for _, tt := range tests {
+		if got := alarmFindings(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("alarmFindings(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestAnnotateRedFlagsInEnglish(t *testing.T) {
+	at := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	iron := storage.Medication{Name: "Iron", Category: "iron", StartedAt: at.AddDate(0, 0, -7)}
+	flags := []RedFlag{{At: at, Message: "report.flag.photo", Finding: "report.finding.black"}}
+
+	annotateRedFlags(flags, []Course{{Medication: iron}}, "en")
+
+	if len(flags[0].Annotations) != 1 || flags[0].Annotations[0].Note != "medications.note.iron_color" {
+		t.Errorf("annotations = %+v, want the iron color note", flags[0].Annotations)
+	}
+}
@@ -0,0 +1,93 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// Policy - сроки хранения данных. Нулевое значение - хранить без ограничения.
+type Policy struct {
+	PhotoDays      int
+	AnalysisMonths int
+}
+
+// Enabled сообщает, нужно ли вообще что-то удалять
+func (p Policy) Enabled() bool {
+	return p.PhotoDays > 0 || p.AnalysisMonths > 0
+}
+
+// Job периодически удаляет данные старше сроков хранения
+type Job struct {
+	store    storage.Storage
+	policy   Policy
+	interval time.Duration
+}
+
+func NewJob(store storage.Storage, policy Policy, interval time.Duration) *Job {
+	return &Job{
+		store:    store,
+		policy:   policy,
+		interval: interval,
+	}
+}
+
+// Run выполняет очистку сразу и затем каждые interval, пока не отменен ctx
+func (j *Job) Run(ctx context.Context) {
+	if !j.policy.Enabled() {
+		log.Println("🗑 Retention policy is not configured, cleanup job disabled")
+		return
+	}
+
+	log.Printf("🗑 Retention job started: photos %d days, analyses %d months, every %v",
+		j.policy.PhotoDays, j.policy.AnalysisMonths, j.interval)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.RunOnce(ctx, time.Now()); err != nil {
+			log.Printf("Error applying retention policy: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("🗑 Retention job stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce применяет политику относительно момента now и пишет итог в журнал аудита
+func (j *Job) RunOnce(ctx context.Context, now time.Time) error {
+	var photos, analyses int64
+	var err error
+
+	if j.policy.PhotoDays > 0 {
+		photos, err = j.store.Analyses().ClearFilesBefore(ctx, now.AddDate(0, 0, -j.policy.PhotoDays))
+		if err != nil {
+			return err
+		}
+	}
+
+	if j.policy.AnalysisMonths > 0 {
+		analyses, err = j.store.Analyses().DeleteBefore(ctx, now.AddDate(0, -j.policy.AnalysisMonths, 0))
+		if err != nil {
+			return err
+		}
+	}
+
+	if photos == 0 && analyses == 0 {
+		return nil
+	}
+
+	log.Printf("🗑 Retention cleanup: %d photos, %d analyses removed", photos, analyses)
+	return j.store.Audit().Create(ctx, &storage.AuditRecord{
+		Action:  storage.AuditActionRetention,
+		Details: fmt.Sprintf("photos=%d analyses=%d", photos, analyses),
+	})
+}
@@ -0,0 +1,91 @@
+package stats
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/merdernoty/stool-guru-bot/internal/bot/i18n"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/charts"
+)
+
+// Chart - отрисованный график
+type Chart struct {
+	Name string
+	PNG  []byte
+}
+
+// RenderCharts рисует графики частоты, распределения по Бристольской шкале и симптомов
+// с подписями на языке locale
+func RenderCharts(s Summary, locale string) ([]Chart, error) {
+	days := len(s.Days)
+	labels := make([]string, days)
+	frequency := make([]float64, days)
+	pain := make([]float64, days)
+	urgency := make([]float64, days)
+
+	for i, day := range s.Days {
+		labels[i] = day.Date.Format("02.01")
+		frequency[i] = float64(day.Count)
+		pain[i] = day.AvgPain
+		// срочность хранится по шкале 0-2, приводим к шкале боли 0-10
+		urgency[i] = day.AvgUrgency * 5
+		if math.IsNaN(day.AvgUrgency) {
+			urgency[i] = math.NaN()
+		}
+	}
+
+	frequencyPNG, err := charts.RenderBar(charts.BarChart{
+		Title:  i18n.T(locale, "stats.chart.frequency", i18n.Params{"period": i18n.N(locale, "common.days_short", days, nil)}),
+		Labels: labels,
+		Values: frequency,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render frequency chart: %w", err)
+	}
+
+	bristolLabels := make([]string, 7)
+	bristolValues := make([]float64, 7)
+	bristolColors := make([]color.RGBA, 7)
+	for number := 1; number <= 7; number++ {
+		bristolLabels[number-1] = i18n.T(locale, "stats.chart.bristol_type", i18n.Params{"number": number})
+		bristolValues[number-1] = float64(s.BristolCounts[number])
+		switch {
+		case number <= 2:
+			bristolColors[number-1] = charts.Brown
+		case number <= 4:
+			bristolColors[number-1] = charts.Green
+		default:
+			bristolColors[number-1] = charts.Orange
+		}
+	}
+
+	bristolPNG, err := charts.RenderBar(charts.BarChart{
+		Title:  i18n.T(locale, "stats.chart.bristol", nil),
+		Labels: bristolLabels,
+		Values: bristolValues,
+		Colors: bristolColors,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render bristol chart: %w", err)
+	}
+
+	symptomsPNG, err := charts.RenderLine(charts.LineChart{
+		Title:  i18n.T(locale, "stats.chart.symptoms", nil),
+		Labels: labels,
+		YMax:   10,
+		Series: []charts.Series{
+			{Name: i18n.T(locale, "stats.chart.pain", nil), Values: pain, Color: charts.Red},
+			{Name: i18n.T(locale, "stats.chart.urgency", nil), Values: urgency, Color: charts.Blue},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render symptoms chart: %w", err)
+	}
+
+	return []Chart{
+		{Name: "frequency.png", PNG: frequencyPNG},
+		{Name: "bristol.png", PNG: bristolPNG},
+		{Name: "symptoms.png", PNG: symptomsPNG},
+	}, nil
+}
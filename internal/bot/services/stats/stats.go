@@ -0,0 +1,115 @@
+package stats
+
+import (
+	"math"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/bristol"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+// Day - показатели за один календарный день пользователя
+type Day struct {
+	Date       time.Time
+	Count      int
+	AvgPain    float64
+	AvgUrgency float64
+}
+
+// Summary - сводка дневника за период
+type Summary struct {
+	From time.Time
+	To   time.Time
+
+	Days []Day
+	// BristolCounts - число записей по типам, индекс 0 - тип не указан
+	BristolCounts [8]int
+
+	Total       int
+	Typed       int
+	NormalShare float64
+	AvgPerDay   float64
+	AvgPain     float64
+}
+
+// Compute считает сводку по записям за days дней, заканчивая днем now.
+// Дни определяются в часовом поясе loc. Дни без записей входят в Days с нулями и NaN.
+func Compute(entries []storage.DiaryEntry, days int, now time.Time, loc *time.Location) Summary {
+	localNow := now.In(loc)
+	end := time.Date(localNow.Year(), localNow.Month(), localNow.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	start := end.AddDate(0, 0, -days)
+
+	summary := Summary{
+		From: start,
+		To:   end,
+		Days: make([]Day, days),
+	}
+
+	painSums := make([]float64, days)
+	urgencySums := make([]float64, days)
+	for i := range summary.Days {
+		summary.Days[i].Date = start.AddDate(0, 0, i)
+	}
+
+	normal := 0
+	painTotal := 0.0
+	for _, entry := range entries {
+		local := entry.OccurredAt.In(loc)
+		if local.Before(start) || !local.Before(end) {
+			continue
+		}
+
+		index := dayIndex(start, local)
+		if index < 0 || index >= days {
+			continue
+		}
+
+		summary.Total++
+		summary.Days[index].Count++
+		painSums[index] += float64(entry.Pain)
+		urgencySums[index] += float64(entry.Urgency)
+		painTotal += float64(entry.Pain)
+
+		if bristol.Valid(entry.BristolType) {
+			summary.BristolCounts[entry.BristolType]++
+			summary.Typed++
+			if bristol.Normal(entry.BristolType) {
+				normal++
+			}
+		} else {
+			summary.BristolCounts[0]++
+		}
+	}
+
+	for i := range summary.Days {
+		day := &summary.Days[i]
+		if day.Count == 0 {
+			day.AvgPain = math.NaN()
+			day.AvgUrgency = math.NaN()
+			continue
+		}
+		day.AvgPain = painSums[i] / float64(day.Count)
+		day.AvgUrgency = urgencySums[i] / float64(day.Count)
+	}
+
+	if summary.Typed > 0 {
+		summary.NormalShare = float64(normal) / float64(summary.Typed)
+	}
+	if days > 0 {
+		summary.AvgPerDay = float64(summary.Total) / float64(days)
+	}
+	if summary.Total > 0 {
+		summary.AvgPain = painTotal / float64(summary.Total)
+	}
+
+	return summary
+}
+
+// dayIndex считает номер календарного дня от start, не завися от переходов на летнее время
+func dayIndex(start, t time.Time) int {
+	sy, sm, sd := start.Date()
+	ty, tm, td := t.Date()
+	startDay := time.Date(sy, sm, sd, 0, 0, 0, 0, time.UTC)
+	day := time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC)
+	return int(day.Sub(startDay).Hours() / 24)
+}
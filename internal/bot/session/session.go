@@ -0,0 +1,37 @@
+package session
+
+import (
+	"context"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+type contextKey int
+
+const (
+	userKey contextKey = iota
+	profileKey
+)
+
+// WithUser сохраняет в контексте пользователя, от которого пришло обновление
+func WithUser(ctx context.Context, user *storage.User) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+// User возвращает пользователя текущего обновления или nil
+func User(ctx context.Context) *storage.User {
+	user, _ := ctx.Value(userKey).(*storage.User)
+	return user
+}
+
+// WithProfile сохраняет в контексте анкету пользователя
+func WithProfile(ctx context.Context, profile *storage.Profile) context.Context {
+	return context.WithValue(ctx, profileKey, profile)
+}
+
+// Profile возвращает анкету пользователя текущего обновления или nil,
+// если анкеты нет или она не загружалась
+func Profile(ctx context.Context) *storage.Profile {
+	profile, _ := ctx.Value(profileKey).(*storage.Profile)
+	return profile
+}
@@ -1,15 +1,22 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// telegramNetworks - опубликованные Telegram адреса, с которых приходят вебхуки
+const telegramNetworks = "149.154.160.0/20,91.108.4.0/22"
+
 type Config struct {
 	TelegramToken string
 	WebhookURL    string
@@ -17,6 +24,63 @@ type Config struct {
 	Debug         bool
 	Timeout       time.Duration
 	GeminiAPIKey  string
+
+	// Защита вебхука: секрет из заголовка X-Telegram-Bot-Api-Secret-Token,
+	// трудноугадываемый путь и, по желанию, только адреса Telegram.
+	// Секрет и путь по умолчанию выводятся из токена бота.
+	WebhookSecret     string
+	WebhookPath       string
+	WebhookRestrictIP bool
+	WebhookAllowedIPs []netip.Prefix
+	WebhookTrustProxy bool
+
+	ConversationTimeout time.Duration
+	DatabasePath        string
+
+	// Telegram ID администраторов: им доступны служебные команды
+	AdminIDs []int64
+
+	// Сколько обновлений одного чата может ждать обработки, остальные отбрасываются
+	UpdateQueueSize int
+
+	// Звенья обработки обновлений, см. bot.buildMiddlewares
+	RecoverPanics      bool
+	LogUpdates         bool
+	TimeUpdates        bool
+	SlowUpdate         time.Duration
+	LoadUsers          bool
+	ResolveLocale      bool
+	CheckBans          bool
+	BannedIDs          []int64
+	RateLimitPerMinute int
+
+	// Секрет подписи данных кнопок. Если не задан, используется токен бота:
+	// при его смене старые кнопки перестанут работать.
+	CallbackSecret string
+
+	ExportSecret   string
+	ExportTokenTTL time.Duration
+
+	// Сроки хранения, 0 - без ограничения
+	PhotoRetentionDays      int
+	AnalysisRetentionMonths int
+	RetentionInterval       time.Duration
+
+	// Мастер-ключ шифрования в base64 (или файл с ним) и выведенные из ротации ключи через запятую
+	EncryptionKey          string
+	EncryptionKeyFile      string
+	EncryptionPreviousKeys string
+
+	// Хранилище оригиналов фото: fs (каталог BlobPath) или s3
+	BlobBackend string
+	BlobPath    string
+	S3Endpoint  string
+	S3Bucket    string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+	S3Prefix    string
+	S3PathStyle bool
 }
 
 func Load() (*Config, error) {
@@ -24,6 +88,21 @@ func Load() (*Config, error) {
 		log.Printf("Warning: .env file not found: %v", err)
 	}
 
+	adminIDs, err := parseIDs(getEnv("ADMIN_IDS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ADMIN_IDS: %w", err)
+	}
+
+	bannedIDs, err := parseIDs(getEnv("BANNED_IDS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BANNED_IDS: %w", err)
+	}
+
+	webhookAllowedIPs, err := parsePrefixes(getEnv("WEBHOOK_ALLOWED_IPS", telegramNetworks))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_ALLOWED_IPS: %w", err)
+	}
+
 	cfg := &Config{
 		TelegramToken: getEnv("TELEGRAM_TOKEN", ""),
 		WebhookURL:    getEnv("WEBHOOK_URL", ""),
@@ -31,6 +110,61 @@ func Load() (*Config, error) {
 		GeminiAPIKey:  getEnv("GEMINI_API_KEY", ""),
 		Debug:         getEnvAsBool("DEBUG", false),
 		Timeout:       time.Duration(getEnvAsInt("TIMEOUT_SECONDS", 60)) * time.Second,
+
+		WebhookSecret:     getEnv("WEBHOOK_SECRET", ""),
+		WebhookPath:       getEnv("WEBHOOK_PATH", ""),
+		WebhookRestrictIP: getEnvAsBool("WEBHOOK_RESTRICT_IP", false),
+		WebhookAllowedIPs: webhookAllowedIPs,
+		WebhookTrustProxy: getEnvAsBool("WEBHOOK_TRUST_PROXY", false),
+
+		ConversationTimeout: time.Duration(getEnvAsInt("CONVERSATION_TIMEOUT_MINUTES", 30)) * time.Minute,
+		DatabasePath:        getEnv("DATABASE_PATH", "data/stool-guru.db"),
+
+		AdminIDs:       adminIDs,
+		CallbackSecret: getEnv("CALLBACK_SECRET", ""),
+
+		UpdateQueueSize: getEnvAsInt("UPDATE_QUEUE_SIZE", 32),
+
+		RecoverPanics:      getEnvAsBool("MIDDLEWARE_RECOVER", true),
+		LogUpdates:         getEnvAsBool("MIDDLEWARE_LOGGING", true),
+		TimeUpdates:        getEnvAsBool("MIDDLEWARE_TIMING", true),
+		SlowUpdate:         time.Duration(getEnvAsInt("SLOW_UPDATE_MS", 3000)) * time.Millisecond,
+		LoadUsers:          getEnvAsBool("MIDDLEWARE_USERS", true),
+		ResolveLocale:      getEnvAsBool("MIDDLEWARE_LOCALE", true),
+		CheckBans:          getEnvAsBool("MIDDLEWARE_BANS", true),
+		BannedIDs:          bannedIDs,
+		RateLimitPerMinute: getEnvAsInt("RATE_LIMIT_PER_MINUTE", 30),
+
+		ExportSecret:   getEnv("EXPORT_SECRET", ""),
+		ExportTokenTTL: time.Duration(getEnvAsInt("EXPORT_TOKEN_TTL_MINUTES", 60)) * time.Minute,
+
+		PhotoRetentionDays:      getEnvAsInt("PHOTO_RETENTION_DAYS", 0),
+		AnalysisRetentionMonths: getEnvAsInt("ANALYSIS_RETENTION_MONTHS", 0),
+		RetentionInterval:       time.Duration(getEnvAsInt("RETENTION_INTERVAL_MINUTES", 60)) * time.Minute,
+
+		EncryptionKey:          getEnv("ENCRYPTION_KEY", ""),
+		EncryptionKeyFile:      getEnv("ENCRYPTION_KEY_FILE", ""),
+		EncryptionPreviousKeys: getEnv("ENCRYPTION_PREVIOUS_KEYS", ""),
+
+		BlobBackend: getEnv("BLOB_BACKEND", "fs"),
+		BlobPath:    getEnv("BLOB_PATH", "data/blobs"),
+		S3Endpoint:  getEnv("S3_ENDPOINT", ""),
+		S3Bucket:    getEnv("S3_BUCKET", ""),
+		S3Region:    getEnv("S3_REGION", "us-east-1"),
+		S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+		S3Prefix:    getEnv("S3_PREFIX", "images"),
+		S3PathStyle: getEnvAsBool("S3_PATH_STYLE", true),
+	}
+
+	if cfg.CallbackSecret == "" {
+		cfg.CallbackSecret = cfg.TelegramToken
+	}
+	if cfg.WebhookSecret == "" {
+		cfg.WebhookSecret = deriveSecret("webhook-secret:", cfg.TelegramToken, 64)
+	}
+	if cfg.WebhookPath == "" {
+		cfg.WebhookPath = "/bot/" + deriveSecret("webhook-path:", cfg.TelegramToken, 32)
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -45,6 +179,55 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("TELEGRAM_TOKEN is required")
 	}
 
+	if c.DatabasePath == "" {
+		return fmt.Errorf("DATABASE_PATH is required")
+	}
+
+	if c.EncryptionKey != "" && c.EncryptionKeyFile != "" {
+		return fmt.Errorf("set either ENCRYPTION_KEY or ENCRYPTION_KEY_FILE, not both")
+	}
+
+	switch c.BlobBackend {
+	case "fs":
+		if c.BlobPath == "" {
+			return fmt.Errorf("BLOB_PATH is required for BLOB_BACKEND=fs")
+		}
+	case "s3":
+		if c.S3Endpoint == "" || c.S3Bucket == "" || c.S3AccessKey == "" || c.S3SecretKey == "" {
+			return fmt.Errorf("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY are required for BLOB_BACKEND=s3")
+		}
+	default:
+		return fmt.Errorf("unknown BLOB_BACKEND %q: use fs or s3", c.BlobBackend)
+	}
+
+	if c.PhotoRetentionDays < 0 || c.AnalysisRetentionMonths < 0 {
+		return fmt.Errorf("retention periods must not be negative")
+	}
+
+	if c.UpdateQueueSize <= 0 {
+		return fmt.Errorf("UPDATE_QUEUE_SIZE must be positive")
+	}
+
+	if c.RateLimitPerMinute < 0 {
+		return fmt.Errorf("RATE_LIMIT_PER_MINUTE must not be negative")
+	}
+
+	if c.RetentionInterval <= 0 {
+		return fmt.Errorf("RETENTION_INTERVAL_MINUTES must be positive")
+	}
+
+	if !validWebhookSecret(c.WebhookSecret) {
+		return fmt.Errorf("WEBHOOK_SECRET must be 1-256 characters A-Z, a-z, 0-9, _ or -")
+	}
+
+	if !strings.HasPrefix(c.WebhookPath, "/") {
+		return fmt.Errorf("WEBHOOK_PATH must start with /")
+	}
+
+	if c.WebhookRestrictIP && len(c.WebhookAllowedIPs) == 0 {
+		return fmt.Errorf("WEBHOOK_ALLOWED_IPS is required when WEBHOOK_RESTRICT_IP=true")
+	}
+
 	if !c.Debug && c.WebhookURL == "" {
 		return fmt.Errorf("WEBHOOK_URL is required in production mode (DEBUG=false)")
 	}
@@ -58,8 +241,10 @@ func (c *Config) String() string {
 		tokenDisplay = "set"
 	}
 
-	return fmt.Sprintf("Config{Port: %s, Debug: %t, WebhookURL: %s, Token: %s, Timeout: %v}",
-		c.Port, c.Debug, c.WebhookURL, tokenDisplay, c.Timeout)
+	encryption := c.EncryptionKey != "" || c.EncryptionKeyFile != ""
+
+	return fmt.Sprintf("Config{Port: %s, Debug: %t, WebhookURL: %s, WebhookRestrictIP: %t, Token: %s, Timeout: %v, Database: %s, Encryption: %t}",
+		c.Port, c.Debug, c.WebhookURL, c.WebhookRestrictIP, tokenDisplay, c.Timeout, c.DatabasePath, encryption)
 }
 
 func getEnv(key, defaultValue string) string {
@@ -86,3 +271,67 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// parseIDs разбирает список Telegram ID через запятую
+func parseIDs(value string) ([]int64, error) {
+	var ids []int64
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a user ID", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parsePrefixes разбирает список подсетей через запятую, одиночный адрес - подсеть из него одного
+func parsePrefixes(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			addr, err := netip.ParseAddr(part)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR", part)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(part)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR", part)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// deriveSecret выводит из токена бота стабильное значение длиной length hex-символов:
+// после перезапуска вебхук остается тем же, а сам токен нигде не светится
+func deriveSecret(purpose, token string, length int) string {
+	sum := sha256.Sum256([]byte(purpose + token))
+	return hex.EncodeToString(sum[:])[:length]
+}
+
+// validWebhookSecret проверяет ограничения Telegram на secret_token
+func validWebhookSecret(secret string) bool {
+	if secret == "" || len(secret) > 256 {
+		return false
+	}
+	for _, r := range secret {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
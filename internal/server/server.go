@@ -1,37 +1,65 @@
 package server
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"net/netip"
+	"strings"
+	"time"
 
 	"github.com/go-telegram/bot/models"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/merdernoty/stool-guru-bot/internal/bot"
+	"github.com/merdernoty/stool-guru-bot/internal/bot/services/export"
 	"github.com/merdernoty/stool-guru-bot/internal/config"
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
 )
 
 type Server struct {
-	echo   *echo.Echo
-	bot    *bot.StoolGuruBot
-	config *config.Config
+	echo    *echo.Echo
+	bot     *bot.StoolGuruBot
+	config  *config.Config
+	storage storage.Storage
+	webhook *webhookGuard
 }
 
-func NewServer(cfg *config.Config, bot *bot.StoolGuruBot) *Server {
+func NewServer(cfg *config.Config, bot *bot.StoolGuruBot, store storage.Storage) *Server {
 	e := echo.New()
 
+	// За прокси адрес клиента берется из X-Forwarded-For, иначе - из соединения,
+	// чтобы проверку адресов вебхука нельзя было обойти поддельным заголовком
+	if cfg.WebhookTrustProxy {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
+
 	// Middleware
-	e.Use(middleware.Logger())
+	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
+		// путь вебхука - секрет, не пишем его в лог
+		Skipper: func(c echo.Context) bool {
+			return c.Path() == cfg.WebhookPath
+		},
+	}))
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 	e.Use(middleware.RequestID())
 
 	e.HideBanner = true
 
+	var allowed []netip.Prefix
+	if cfg.WebhookRestrictIP {
+		allowed = cfg.WebhookAllowedIPs
+	}
+
 	return &Server{
-		echo:   e,
-		bot:    bot,
-		config: cfg,
+		echo:    e,
+		bot:     bot,
+		config:  cfg,
+		storage: store,
+		webhook: newWebhookGuard(cfg.WebhookSecret, allowed),
 	}
 }
 
@@ -39,19 +67,25 @@ func (s *Server) SetupRoutes() {
 	// Health check endpoint
 	s.echo.GET("/health", s.healthCheck)
 
-	// Webhook endpoint
-	s.echo.POST("/bot", s.handleWebhook)
+	// Webhook endpoint: запросы на другие пути и без секрета до бота не доходят
+	s.webhook.register(s.echo, s.config.WebhookPath, s.handleWebhook)
 
 	// Bot info endpoint
 	s.echo.GET("/", s.botInfo)
 
 	// Metrics endpoint
 	s.echo.GET("/metrics", s.metrics)
+
+	// Data export endpoint
+	if s.config.ExportSecret != "" {
+		s.echo.GET("/export", s.exportData)
+	}
 }
 
 func (s *Server) handleWebhook(c echo.Context) error {
 	var update models.Update
 	if err := c.Bind(&update); err != nil {
+		s.webhook.rejectedBody.Add(1)
 		return c.JSON(http.StatusBadRequest, map[string]string{
 			"error": "Invalid request format",
 		})
@@ -67,6 +101,61 @@ func (s *Server) handleWebhook(c echo.Context) error {
 	return c.NoContent(http.StatusOK)
 }
 
+// exportData отдает выгрузку пользователя по токену из команды /export
+func (s *Server) exportData(c echo.Context) error {
+	token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+	if !ok || token == "" {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Missing bearer token",
+		})
+	}
+
+	userID, err := export.VerifyToken(s.config.ExportSecret, token, time.Now())
+	if err != nil {
+		message := "Invalid token"
+		if errors.Is(err, export.ErrExpiredToken) {
+			message = "Token expired"
+		}
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": message,
+		})
+	}
+
+	data, err := export.Collect(c.Request().Context(), s.storage, userID)
+	if err != nil {
+		c.Logger().Error("Error collecting export:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+
+	filename := "stool-guru-export-" + data.ExportedAt.Format("2006-01-02")
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+
+	switch c.QueryParam("format") {
+	case "", "json":
+		body, err := export.JSON(data)
+		if err != nil {
+			c.Logger().Error("Error encoding JSON export:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`.json"`)
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, body)
+	case "csv":
+		body, err := export.CSV(data)
+		if err != nil {
+			c.Logger().Error("Error encoding CSV export:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`.csv"`)
+		return c.Blob(http.StatusOK, "text/csv; charset=utf-8", body)
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Unsupported format, use json or csv",
+		})
+	}
+}
+
 func (s *Server) healthCheck(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"status":  "healthy",
@@ -94,8 +183,10 @@ func (s *Server) botInfo(c echo.Context) error {
 
 func (s *Server) metrics(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"uptime": "running",
-		"mode":   s.config.Debug,
+		"uptime":  "running",
+		"mode":    s.config.Debug,
+		"updates": s.bot.UpdateStats(),
+		"webhook": s.webhook.Stats(),
 	})
 }
 
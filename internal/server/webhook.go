@@ -0,0 +1,136 @@
+package server
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+)
+
+// secretTokenHeader - заголовок, в котором Telegram присылает secret_token из setWebhook
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// WebhookStats - счетчики запросов к вебхуку для /metrics
+type WebhookStats struct {
+	Accepted uint64            `json:"accepted"`
+	Rejected map[string]uint64 `json:"rejected"`
+}
+
+// webhookGuard пропускает к боту только запросы Telegram: с верным секретом
+// в заголовке и, если включено, с разрешенных адресов
+type webhookGuard struct {
+	secret  []byte
+	allowed []netip.Prefix
+
+	accepted       atomic.Uint64
+	rejectedPath   atomic.Uint64
+	rejectedIP     atomic.Uint64
+	rejectedSecret atomic.Uint64
+	rejectedBody   atomic.Uint64
+}
+
+// newWebhookGuard создает проверку, allowed == nil - адрес не проверяется
+func newWebhookGuard(secret string, allowed []netip.Prefix) *webhookGuard {
+	return &webhookGuard{
+		secret:  []byte(secret),
+		allowed: allowed,
+	}
+}
+
+// register добавляет вебхук на путь path. POST-запросы на несуществующие пути
+// рядом с ним (/bot, /bot/<догадка>) - попытки найти вебхук, например по старому
+// открытому /bot: они тоже считаются отклоненными. Ошибки на других адресах сервера
+// к вебхуку отношения не имеют и не считаются.
+func (g *webhookGuard) register(e *echo.Echo, path string, handler echo.HandlerFunc) {
+	e.POST(path, handler, g.middleware)
+
+	scope := webhookScope(path)
+	e.RouteNotFound(scope, g.notFound)
+	e.RouteNotFound(strings.TrimSuffix(scope, "/")+"/*", g.notFound)
+}
+
+// webhookScope - первый сегмент пути вебхука: "/bot/<секрет>" -> "/bot"
+func webhookScope(path string) string {
+	first, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return "/" + first
+}
+
+// notFound отвечает на запросы к несуществующим путям рядом с вебхуком
+func (g *webhookGuard) notFound(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		g.rejectedPath.Add(1)
+		log.Printf("🔒 Rejected webhook request from %s: unknown path", c.RealIP())
+	}
+	return echo.ErrNotFound
+}
+
+// allowedIP проверяет адрес клиента по списку подсетей
+func (g *webhookGuard) allowedIP(ip string) bool {
+	if g.allowed == nil {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range g.allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// validSecret сравнивает секрет за постоянное время, чтобы его нельзя было подобрать по задержке
+func (g *webhookGuard) validSecret(header string) bool {
+	return subtle.ConstantTimeCompare([]byte(header), g.secret) == 1
+}
+
+// middleware отклоняет запросы не от Telegram до разбора тела
+func (g *webhookGuard) middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ip := c.RealIP()
+
+		if !g.allowedIP(ip) {
+			g.rejectedIP.Add(1)
+			log.Printf("🔒 Rejected webhook request from %s: address not allowed", ip)
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "Forbidden",
+			})
+		}
+
+		if !g.validSecret(c.Request().Header.Get(secretTokenHeader)) {
+			g.rejectedSecret.Add(1)
+			log.Printf("🔒 Rejected webhook request from %s: invalid secret token", ip)
+			return c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "Unauthorized",
+			})
+		}
+
+		if err := next(c); err != nil {
+			return err
+		}
+		if c.Response().Status < http.StatusBadRequest {
+			g.accepted.Add(1)
+		}
+		return nil
+	}
+}
+
+// Stats возвращает счетчики принятых и отклоненных запросов
+func (g *webhookGuard) Stats() WebhookStats {
+	return WebhookStats{
+		Accepted: g.accepted.Load(),
+		Rejected: map[string]uint64{
+			"path":   g.rejectedPath.Load(),
+			"ip":     g.rejectedIP.Load(),
+			"secret": g.rejectedSecret.Load(),
+			"body":   g.rejectedBody.Load(),
+		},
+	}
+}
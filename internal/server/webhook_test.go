@@ -0,0 +1,109 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	testSecret = "test-secret_1"
+	testPath   = "/bot/0123456789abcdef"
+)
+
+func newTestWebhook(t *testing.T) (*echo.Echo, *webhookGuard, *int) {
+	t.Helper()
+
+	guard := newWebhookGuard(testSecret, []netip.Prefix{netip.MustParsePrefix("149.154.160.0/20")})
+	e := echo.New()
+	e.IPExtractor = echo.ExtractIPDirect()
+
+	calls := new(int)
+	guard.register(e, testPath, func(c echo.Context) error {
+		*calls++
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/health", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	return e, guard, calls
+}
+
+func TestWebhookGuard(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		remoteAddr string
+		secret     string
+		wantStatus int
+		wantReason string
+	}{
+		{name: "success", path: testPath, remoteAddr: "149.154.167.50:443", secret: testSecret, wantStatus: http.StatusOK},
+		{name: "ipv4-mapped address", path: testPath, remoteAddr: "[::ffff:149.154.167.50]:443", secret: testSecret, wantStatus: http.StatusOK},
+		{name: "bad secret", path: testPath, remoteAddr: "149.154.167.50:443", secret: "wrong", wantStatus: http.StatusUnauthorized, wantReason: "secret"},
+		{name: "missing secret", path: testPath, remoteAddr: "149.154.167.50:443", wantStatus: http.StatusUnauthorized, wantReason: "secret"},
+		{name: "disallowed ip", path: testPath, remoteAddr: "203.0.113.7:443", secret: testSecret, wantStatus: http.StatusForbidden, wantReason: "ip"},
+		{name: "old public path", path: "/bot", remoteAddr: "149.154.167.50:443", secret: testSecret, wantStatus: http.StatusNotFound, wantReason: "path"},
+		{name: "guessed path", path: "/bot/guess", remoteAddr: "203.0.113.7:443", wantStatus: http.StatusNotFound, wantReason: "path"},
+		// ошибки на других адресах сервера к вебхуку не относятся
+		{name: "unrelated path", path: "/admin", remoteAddr: "203.0.113.7:443", wantStatus: http.StatusNotFound},
+		{name: "wrong method", path: "/health", remoteAddr: "203.0.113.7:443", wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, guard, calls := newTestWebhook(t)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.secret != "" {
+				req.Header.Set(secretTokenHeader, tt.secret)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			stats := guard.Stats()
+			if tt.wantStatus == http.StatusOK {
+				if *calls != 1 || stats.Accepted != 1 {
+					t.Errorf("handler calls = %d, accepted = %d, want 1 and 1", *calls, stats.Accepted)
+				}
+				for reason, count := range stats.Rejected {
+					if count != 0 {
+						t.Errorf("rejected[%s] = %d, want 0", reason, count)
+					}
+				}
+				return
+			}
+
+			if *calls != 0 {
+				t.Errorf("handler called %d times for a rejected request", *calls)
+			}
+			if stats.Accepted != 0 {
+				t.Errorf("accepted = %d, want 0", stats.Accepted)
+			}
+			for reason, count := range stats.Rejected {
+				want := uint64(0)
+				if reason == tt.wantReason {
+					want = 1
+				}
+				if count != want {
+					t.Errorf("rejected[%s] = %d, want %d", reason, count, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookGuardWithoutAllowlist(t *testing.T) {
+	guard := newWebhookGuard(testSecret, nil)
+	if !guard.allowedIP("203.0.113.7") {
+		t.Error("address rejected although the allowlist is disabled")
+	}
+}
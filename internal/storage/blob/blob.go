@@ -0,0 +1,49 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound возвращается, когда объекта с таким ключом нет
+var ErrNotFound = errors.New("blob not found")
+
+// Store - хранилище неизменяемых объектов, адресуемых хешем содержимого
+type Store interface {
+	// Put сохраняет данные под ключом. Повторная запись того же ключа ничего не меняет.
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete удаляет объект. Отсутствие объекта ошибкой не считается.
+	Delete(ctx context.Context, key string) error
+}
+
+// Key возвращает адрес содержимого - SHA-256 в hex
+func Key(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify проверяет, что данные соответствуют ключу
+func Verify(key string, data []byte) error {
+	if Key(data) != key {
+		return fmt.Errorf("blob %s is corrupted: content hash mismatch", key)
+	}
+	return nil
+}
+
+// ValidKey проверяет, что ключ - hex SHA-256. Это же защищает пути от обхода каталогов.
+func ValidKey(key string) bool {
+	if len(key) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(key)
+	return err == nil
+}
+
+// objectPath раскладывает объекты по подкаталогам, чтобы в одном каталоге не было миллионов файлов
+func objectPath(key string) string {
+	return key[:2] + "/" + key[2:4] + "/" + key
+}
@@ -0,0 +1,251 @@
+package encryption
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// textPrefix отмечает зашифрованные текстовые поля. Значения без него -
+// открытый текст, записанный до включения шифрования.
+const textPrefix = "enc:v1:"
+
+// ErrKeyNotFound возвращается хранилищем ключей, если у пользователя еще нет ключа
+var ErrKeyNotFound = errors.New("data key not found")
+
+// WrappedKey - ключ пользователя, зашифрованный мастер-ключом
+type WrappedKey struct {
+	UserID    int64
+	KeyID     string
+	Wrapped   []byte
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// KeyStore хранит обернутые ключи пользователей
+type KeyStore interface {
+	GetKey(ctx context.Context, userID int64) (*WrappedKey, error)
+	// CreateKey сохраняет ключ, если у пользователя его еще нет
+	CreateKey(ctx context.Context, key *WrappedKey) error
+}
+
+// contentKeyLabel - назначение ключа, которым считаются адреса содержимого.
+// Он выводится из ключа пользователя, чтобы не шифровать и не подписывать одним ключом.
+const contentKeyLabel = "content-address:v1"
+
+// Cipher шифрует данные пользователей их собственными ключами (envelope encryption).
+// Расшифрованные ключи кешируются в памяти.
+type Cipher struct {
+	keyring *Keyring
+	keys    KeyStore
+
+	// mu защищает только карты: обращения к хранилищу ключей идут без него,
+	// чтобы медленный запрос одного пользователя не задерживал остальных
+	mu      sync.Mutex
+	cache   map[int64]*userKey
+	loading map[int64]*keyLoad
+}
+
+// keyLoad - загрузка ключа пользователя, которую ждут параллельные запросы
+type keyLoad struct {
+	done chan struct{}
+	key  *userKey
+	err  error
+	// forgotten - ключ забыт во время загрузки и не должен попасть в кеш
+	forgotten bool
+}
+
+// userKey - расшифрованный ключ пользователя
+type userKey struct {
+	aead cipher.AEAD
+	// mac - ключ HMAC для адресов содержимого
+	mac []byte
+}
+
+func NewCipher(keyring *Keyring, keys KeyStore) *Cipher {
+	return &Cipher{
+		keyring: keyring,
+		keys:    keys,
+		cache:   make(map[int64]*userKey),
+		loading: make(map[int64]*keyLoad),
+	}
+}
+
+// IsEncrypted сообщает, зашифровано ли текстовое поле
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, textPrefix)
+}
+
+// EncryptString шифрует текстовое поле. Пустые строки не шифруются.
+func (c *Cipher) EncryptString(ctx context.Context, userID int64, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	data, err := c.Encrypt(ctx, userID, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return textPrefix + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// DecryptString расшифровывает текстовое поле. Открытый текст возвращается как есть.
+func (c *Cipher) DecryptString(ctx context.Context, userID int64, value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, textPrefix)
+	if !ok {
+		return value, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode encrypted field: %w", err)
+	}
+
+	plaintext, err := c.Decrypt(ctx, userID, data)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Encrypt шифрует произвольные данные ключом пользователя
+func (c *Cipher) Encrypt(ctx context.Context, userID int64, plaintext []byte) ([]byte, error) {
+	key, err := c.dataKey(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return seal(key.aead, plaintext, userAAD(userID))
+}
+
+// Decrypt расшифровывает данные, зашифрованные Encrypt
+func (c *Cipher) Decrypt(ctx context.Context, userID int64, data []byte) ([]byte, error) {
+	key, err := c.dataKey(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := open(key.aead, data, userAAD(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+	return plaintext, nil
+}
+
+// ContentKey возвращает адрес открытых данных пользователя - HMAC-SHA256 в hex.
+// Одинаковые данные одного пользователя получают один адрес, но по адресу
+// нельзя проверить догадку о содержимом и совпадение с данными других пользователей.
+func (c *Cipher) ContentKey(ctx context.Context, userID int64, plaintext []byte) (string, error) {
+	key, err := c.dataKey(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key.mac)
+	mac.Write(plaintext)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Forget убирает ключ пользователя из кеша, например после удаления его данных
+func (c *Cipher) Forget(userID int64) {
+	c.mu.Lock()
+	delete(c.cache, userID)
+	if load, ok := c.loading[userID]; ok {
+		load.forgotten = true
+		delete(c.loading, userID)
+	}
+	c.mu.Unlock()
+}
+
+// Keyring возвращает связку мастер-ключей
+func (c *Cipher) Keyring() *Keyring {
+	return c.keyring
+}
+
+// dataKey возвращает ключ пользователя, создавая его при первом обращении.
+// Параллельные запросы одного пользователя ждут одну загрузку.
+func (c *Cipher) dataKey(ctx context.Context, userID int64) (*userKey, error) {
+	c.mu.Lock()
+	if key, ok := c.cache[userID]; ok {
+		c.mu.Unlock()
+		return key, nil
+	}
+	if load, ok := c.loading[userID]; ok {
+		c.mu.Unlock()
+		select {
+		case <-load.done:
+			return load.key, load.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	load := &keyLoad{done: make(chan struct{})}
+	c.loading[userID] = load
+	c.mu.Unlock()
+
+	key, err := c.loadKey(ctx, userID)
+
+	c.mu.Lock()
+	load.key, load.err = key, err
+	if c.loading[userID] == load {
+		delete(c.loading, userID)
+	}
+	if err == nil && !load.forgotten {
+		c.cache[userID] = key
+	}
+	c.mu.Unlock()
+	close(load.done)
+
+	return key, err
+}
+
+// loadKey читает и расшифровывает ключ пользователя, создавая его при отсутствии
+func (c *Cipher) loadKey(ctx context.Context, userID int64) (*userKey, error) {
+	wrapped, err := c.keys.GetKey(ctx, userID)
+	if errors.Is(err, ErrKeyNotFound) {
+		wrapped, err = c.createKey(ctx, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dek, err := c.keyring.Unwrap(userID, wrapped.KeyID, wrapped.Wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	label := hmac.New(sha256.New, dek)
+	label.Write([]byte(contentKeyLabel))
+
+	return &userKey{aead: aead, mac: label.Sum(nil)}, nil
+}
+
+func (c *Cipher) createKey(ctx context.Context, userID int64) (*WrappedKey, error) {
+	dek := make([]byte, KeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	keyID, wrapped, err := c.keyring.Wrap(userID, dek)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.keys.CreateKey(ctx, &WrappedKey{UserID: userID, KeyID: keyID, Wrapped: wrapped}); err != nil {
+		return nil, err
+	}
+
+	// перечитываем: ключ мог создать параллельный процесс
+	return c.keys.GetKey(ctx, userID)
+}
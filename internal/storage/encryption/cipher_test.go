@@ -0,0 +1,220 @@
+package encryption
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryKeys - хранилище ключей в памяти. Если задан block, чтение ключа
+// пользователя blockUser ждет, пока канал не закроют.
+type memoryKeys struct {
+	mu      sync.Mutex
+	keys    map[int64]*WrappedKey
+	creates int
+
+	block     chan struct{}
+	blockUser int64
+}
+
+func newMemoryKeys() *memoryKeys {
+	return &memoryKeys{keys: make(map[int64]*WrappedKey)}
+}
+
+func (m *memoryKeys) GetKey(ctx context.Context, userID int64) (*WrappedKey, error) {
+	if m.block != nil && userID == m.blockUser {
+		<-m.block
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, ok := m.keys[userID]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	copied := *key
+	return &copied, nil
+}
+
+func (m *memoryKeys) CreateKey(ctx context.Context, key *WrappedKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.keys[key.UserID]; !ok {
+		m.keys[key.UserID] = key
+		m.creates++
+	}
+	return nil
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	c := NewCipher(NewKeyring(newTestMasterKey(t)), newMemoryKeys())
+	ctx := context.Background()
+
+	data, err := c.Encrypt(ctx, 1, []byte("photo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.Decrypt(ctx, 1, data); err != nil || string(got) != "photo" {
+		t.Errorf("Decrypt = %q, %v; want photo", got, err)
+	}
+
+	field, err := c.EncryptString(ctx, 1, "заметка")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(field) {
+		t.Errorf("encrypted field %q has no prefix", field)
+	}
+	if got, err := c.DecryptString(ctx, 1, field); err != nil || got != "заметка" {
+		t.Errorf("DecryptString = %q, %v; want заметка", got, err)
+	}
+
+	// поля, записанные до включения шифрования, читаются как есть
+	if got, err := c.DecryptString(ctx, 1, "plain"); err != nil || got != "plain" {
+		t.Errorf("DecryptString(plain) = %q, %v", got, err)
+	}
+	if got, err := c.EncryptString(ctx, 1, ""); err != nil || got != "" {
+		t.Errorf("EncryptString(\"\") = %q, %v; want empty", got, err)
+	}
+}
+
+func TestCipherRejectsOtherUser(t *testing.T) {
+	c := NewCipher(NewKeyring(newTestMasterKey(t)), newMemoryKeys())
+	ctx := context.Background()
+
+	data, err := c.Encrypt(ctx, 1, []byte("photo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Decrypt(ctx, 2, data); err == nil {
+		t.Error("data of user 1 decrypted as user 2")
+	}
+
+	// тот же ключ, но другой AAD: шифротекст привязан к пользователю
+	key, err := c.dataKey(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := open(key.aead, data, userAAD(2)); err == nil {
+		t.Error("data opened with another user's AAD")
+	}
+}
+
+func TestCipherRejectsWrongMasterKey(t *testing.T) {
+	keys := newMemoryKeys()
+	ctx := context.Background()
+
+	data, err := NewCipher(NewKeyring(newTestMasterKey(t)), keys).Encrypt(ctx, 1, []byte("photo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCipher(NewKeyring(newTestMasterKey(t)), keys).Decrypt(ctx, 1, data); !errors.Is(err, ErrUnknownMasterKey) {
+		t.Errorf("Decrypt with another master key: error = %v, want %v", err, ErrUnknownMasterKey)
+	}
+}
+
+func TestCipherReadsAfterRotation(t *testing.T) {
+	keys := newMemoryKeys()
+	old, current := newTestMasterKey(t), newTestMasterKey(t)
+	ctx := context.Background()
+
+	data, err := NewCipher(NewKeyring(old), keys).Encrypt(ctx, 1, []byte("photo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := NewCipher(NewKeyring(current, old), keys).Decrypt(ctx, 1, data); err != nil || string(got) != "photo" {
+		t.Errorf("Decrypt after rotation = %q, %v; want photo", got, err)
+	}
+}
+
+func TestContentKey(t *testing.T) {
+	c := NewCipher(NewKeyring(newTestMasterKey(t)), newMemoryKeys())
+	ctx := context.Background()
+
+	first, _ := c.ContentKey(ctx, 1, []byte("photo"))
+	second, _ := c.ContentKey(ctx, 1, []byte("photo"))
+	other, _ := c.ContentKey(ctx, 2, []byte("photo"))
+	if first == "" || first != second {
+		t.Errorf("the same data has different addresses: %q, %q", first, second)
+	}
+	if first == other {
+		t.Error("the same data of different users has one address")
+	}
+}
+
+func TestDataKeyCreatesOneKeyConcurrently(t *testing.T) {
+	keys := newMemoryKeys()
+	c := NewCipher(NewKeyring(newTestMasterKey(t)), keys)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Encrypt(context.Background(), 1, []byte("note")); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if keys.creates != 1 {
+		t.Errorf("created %d keys, want 1", keys.creates)
+	}
+}
+
+func TestDataKeyDoesNotBlockOtherUsers(t *testing.T) {
+	keys := newMemoryKeys()
+	keys.block, keys.blockUser = make(chan struct{}), 1
+	c := NewCipher(NewKeyring(newTestMasterKey(t)), keys)
+	defer close(keys.block)
+
+	go c.Encrypt(context.Background(), 1, []byte("slow"))
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Encrypt(context.Background(), 2, []byte("fast"))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("slow key store read of one user blocks another user")
+	}
+}
+
+func TestForgetDropsKeyBeingLoaded(t *testing.T) {
+	keys := newMemoryKeys()
+	c := NewCipher(NewKeyring(newTestMasterKey(t)), keys)
+	ctx := context.Background()
+	if _, err := c.Encrypt(ctx, 1, []byte("note")); err != nil {
+		t.Fatal(err)
+	}
+	c.Forget(1)
+
+	keys.block, keys.blockUser = make(chan struct{}), 1
+	loaded := make(chan struct{})
+	go func() {
+		c.dataKey(ctx, 1)
+		close(loaded)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	c.Forget(1)
+	close(keys.block)
+	<-loaded
+
+	c.mu.Lock()
+	_, cached := c.cache[1]
+	c.mu.Unlock()
+	if cached {
+		t.Error("key forgotten during loading is cached")
+	}
+}
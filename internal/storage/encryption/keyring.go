@@ -0,0 +1,172 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// KeySize - длина мастер-ключа и ключей пользователей (AES-256)
+const KeySize = 32
+
+// ErrUnknownMasterKey возвращается, когда ключ пользователя обернут мастер-ключом,
+// которого нет в связке
+var ErrUnknownMasterKey = errors.New("unknown master key")
+
+// MasterKey - ключ, которым оборачиваются ключи пользователей
+type MasterKey struct {
+	ID   string
+	aead cipher.AEAD
+}
+
+// NewMasterKey создает мастер-ключ из 32 байт. ID - отпечаток ключа, сам ключ не раскрывает.
+func NewMasterKey(key []byte) (*MasterKey, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, got %d", KeySize, len(key))
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(key)
+	return &MasterKey{ID: hex.EncodeToString(sum[:8]), aead: aead}, nil
+}
+
+// ParseMasterKey разбирает ключ в base64
+func ParseMasterKey(encoded string) (*MasterKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("master key must be base64: %w", err)
+	}
+	return NewMasterKey(key)
+}
+
+// Keyring - текущий мастер-ключ и предыдущие, которые нужны только для чтения
+// до перевыпуска оберток
+type Keyring struct {
+	current *MasterKey
+	keys    map[string]*MasterKey
+}
+
+// NewKeyring собирает связку ключей. previous - ключи, выведенные из ротации.
+func NewKeyring(current *MasterKey, previous ...*MasterKey) *Keyring {
+	k := &Keyring{
+		current: current,
+		keys:    map[string]*MasterKey{current.ID: current},
+	}
+	for _, key := range previous {
+		if _, ok := k.keys[key.ID]; !ok {
+			k.keys[key.ID] = key
+		}
+	}
+	return k
+}
+
+// LoadKeyring читает ключи из конфигурации: key или содержимое keyFile - текущий ключ,
+// previous - список предыдущих через запятую. Без ключа возвращает nil: шифрование выключено.
+func LoadKeyring(key, keyFile, previous string) (*Keyring, error) {
+	if key == "" && keyFile != "" {
+		content, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read master key file: %w", err)
+		}
+		key = string(content)
+	}
+	if key == "" {
+		if previous != "" {
+			return nil, errors.New("previous master keys are set without a current key")
+		}
+		return nil, nil
+	}
+
+	current, err := ParseMasterKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var old []*MasterKey
+	for i, encoded := range strings.Split(previous, ",") {
+		if strings.TrimSpace(encoded) == "" {
+			continue
+		}
+		k, err := ParseMasterKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("previous master key #%d: %w", i+1, err)
+		}
+		old = append(old, k)
+	}
+
+	return NewKeyring(current, old...), nil
+}
+
+// CurrentID возвращает отпечаток текущего мастер-ключа
+func (k *Keyring) CurrentID() string {
+	return k.current.ID
+}
+
+// Wrap шифрует ключ пользователя текущим мастер-ключом
+func (k *Keyring) Wrap(userID int64, dek []byte) (keyID string, wrapped []byte, err error) {
+	wrapped, err = seal(k.current.aead, dek, userAAD(userID))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return k.current.ID, wrapped, nil
+}
+
+// Unwrap расшифровывает ключ пользователя мастер-ключом keyID
+func (k *Keyring) Unwrap(userID int64, keyID string, wrapped []byte) ([]byte, error) {
+	master, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMasterKey, keyID)
+	}
+
+	dek, err := open(master.aead, wrapped, userAAD(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return dek, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return aead, nil
+}
+
+// seal шифрует данные и кладет случайный nonce перед шифротекстом
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, data, aad []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
+
+// userAAD привязывает шифротекст к пользователю: чужую запись не расшифровать его ключом
+func userAAD(userID int64) []byte {
+	return []byte("user:" + strconv.FormatInt(userID, 10))
+}
@@ -0,0 +1,118 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func newTestMasterKey(t *testing.T) *MasterKey {
+	t.Helper()
+
+	raw := make([]byte, KeySize)
+	if _, err := rand.Read(raw); err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewMasterKey(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestWrapUnwrapRoundTrip(t *testing.T) {
+	keyring := NewKeyring(newTestMasterKey(t))
+	dek := bytes.Repeat([]byte{7}, KeySize)
+
+	keyID, wrapped, err := keyring.Wrap(1, dek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID != keyring.CurrentID() {
+		t.Errorf("key id = %s, want current %s", keyID, keyring.CurrentID())
+	}
+
+	got, err := keyring.Unwrap(1, keyID, wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, dek) {
+		t.Error("unwrapped key differs from the original")
+	}
+}
+
+func TestUnwrapRejectsOtherUser(t *testing.T) {
+	keyring := NewKeyring(newTestMasterKey(t))
+
+	keyID, wrapped, err := keyring.Wrap(1, bytes.Repeat([]byte{7}, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// обертка привязана к пользователю: подставить чужой ключ в свою строку нельзя
+	if _, err := keyring.Unwrap(2, keyID, wrapped); err == nil {
+		t.Error("key of user 1 unwrapped for user 2")
+	}
+}
+
+func TestUnwrapRejectsWrongMasterKey(t *testing.T) {
+	keyID, wrapped, err := NewKeyring(newTestMasterKey(t)).Wrap(1, bytes.Repeat([]byte{7}, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewKeyring(newTestMasterKey(t)).Unwrap(1, keyID, wrapped); !errors.Is(err, ErrUnknownMasterKey) {
+		t.Errorf("Unwrap with another master key: error = %v, want %v", err, ErrUnknownMasterKey)
+	}
+}
+
+func TestKeyringReadsPreviousMasterKey(t *testing.T) {
+	old, current := newTestMasterKey(t), newTestMasterKey(t)
+	dek := bytes.Repeat([]byte{7}, KeySize)
+
+	oldID, wrapped, err := NewKeyring(old).Wrap(1, dek)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := NewKeyring(current, old)
+	got, err := rotated.Unwrap(1, oldID, wrapped)
+	if err != nil {
+		t.Fatalf("Unwrap with the previous master key: %v", err)
+	}
+
+	keyID, rewrapped, err := rotated.Wrap(1, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID != current.ID {
+		t.Errorf("rewrapped with %s, want current %s", keyID, current.ID)
+	}
+	if got, err := NewKeyring(current).Unwrap(1, keyID, rewrapped); err != nil || !bytes.Equal(got, dek) {
+		t.Errorf("rewrapped key does not unwrap with the current master key alone: %v", err)
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, KeySize))
+	previous := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, KeySize))
+
+	keyring, err := LoadKeyring(encoded, "", previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keyring.keys) != 2 {
+		t.Errorf("keyring has %d keys, want 2", len(keyring.keys))
+	}
+
+	if keyring, err := LoadKeyring("", "", ""); keyring != nil || err != nil {
+		t.Errorf("LoadKeyring without keys = %v, %v; want nil, nil", keyring, err)
+	}
+	if _, err := LoadKeyring("", "", previous); err == nil {
+		t.Error("previous keys without a current key are accepted")
+	}
+	if _, err := LoadKeyring(base64.StdEncoding.EncodeToString([]byte("short")), "", ""); err == nil {
+		t.Error("short master key is accepted")
+	}
+}
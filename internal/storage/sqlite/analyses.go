@@ -0,0 +1,271 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+	"github.com/merdernoty/stool-guru-bot/internal/storage/blob"
+	"github.com/merdernoty/stool-guru-bot/internal/storage/encryption"
+)
+
+const analysisColumns = `id, user_id, file_id, user_context, text, diagnosis, recommendations, bristol_type, image_hash, created_at`
+
+type analysisRepository struct {
+	db     *sql.DB
+	cipher *encryption.Cipher
+	blobs  blob.Store
+}
+
+func (r *analysisRepository) Create(ctx context.Context, analysis *storage.Analysis) error {
+	if analysis.CreatedAt.IsZero() {
+		analysis.CreatedAt = time.Now().UTC()
+	}
+
+	userContext, text, diagnosis, recommendations := analysis.UserContext, analysis.Text, analysis.Diagnosis, analysis.Recommendations
+	if err := encryptFields(ctx, r.cipher, analysis.UserID, &userContext, &text, &diagnosis, &recommendations); err != nil {
+		return fmt.Errorf("failed to encrypt analysis: %w", err)
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO analyses (user_id, file_id, user_context, text, diagnosis, recommendations, bristol_type, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		analysis.UserID, analysis.FileID, userContext, text,
+		diagnosis, recommendations, analysis.BristolType, toUnix(analysis.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to create analysis: %w", err)
+	}
+
+	analysis.ID, err = res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get analysis id: %w", err)
+	}
+	return nil
+}
+
+func (r *analysisRepository) Get(ctx context.Context, userID, id int64) (*storage.Analysis, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+analysisColumns+` FROM analyses WHERE user_id = ? AND id = ?`, userID, id)
+
+	analysis, err := scanAnalysis(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analysis: %w", err)
+	}
+	if err := r.decrypt(ctx, analysis); err != nil {
+		return nil, err
+	}
+	return analysis, nil
+}
+
+func (r *analysisRepository) List(ctx context.Context, userID int64, limit, offset int) ([]storage.Analysis, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+analysisColumns+` FROM analyses
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?`, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list analyses: %w", err)
+	}
+	defer rows.Close()
+
+	var analyses []storage.Analysis
+	for rows.Next() {
+		analysis, err := scanAnalysis(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan analysis: %w", err)
+		}
+		analyses = append(analyses, *analysis)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list analyses: %w", err)
+	}
+	rows.Close()
+
+	for i := range analyses {
+		if err := r.decrypt(ctx, &analyses[i]); err != nil {
+			return nil, err
+		}
+	}
+	return analyses, nil
+}
+
+func (r *analysisRepository) AttachImage(ctx context.Context, userID, id int64, image []byte) error {
+	if r.blobs == nil {
+		return errors.New("image storage is not configured")
+	}
+
+	// адрес считается по открытому фото: шифротекст со случайным nonce у одинаковых
+	// фото разный. С шифрованием адрес - HMAC на ключе пользователя, а не хеш.
+	data, hash := image, blob.Key(image)
+	if r.cipher != nil {
+		var err error
+		if hash, err = r.cipher.ContentKey(ctx, userID, image); err != nil {
+			return fmt.Errorf("failed to address image: %w", err)
+		}
+		if data, err = r.cipher.Encrypt(ctx, userID, image); err != nil {
+			return fmt.Errorf("failed to encrypt image: %w", err)
+		}
+	}
+
+	if err := r.blobs.Put(ctx, hash, data); err != nil {
+		return fmt.Errorf("failed to store image: %w", err)
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE analyses SET image_hash = ? WHERE user_id = ? AND id = ?`, hash, userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to link image: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		removeBlobs(ctx, r.db, r.blobs, []string{hash})
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (r *analysisRepository) Image(ctx context.Context, userID, id int64) ([]byte, error) {
+	var hash string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT image_hash FROM analyses WHERE user_id = ? AND id = ?`, userID, id).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && hash == "") {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image hash: %w", err)
+	}
+	if r.blobs == nil {
+		return nil, errors.New("image storage is not configured")
+	}
+
+	data, err := r.blobs.Get(ctx, hash)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load image: %w", err)
+	}
+	if r.cipher != nil {
+		image, err := r.cipher.Decrypt(ctx, userID, data)
+		if err == nil {
+			if err := r.verifyImage(ctx, userID, hash, data, image); err != nil {
+				return nil, err
+			}
+			return image, nil
+		}
+		if !isImage(data) {
+			return nil, fmt.Errorf("failed to decrypt image: %w", err)
+		}
+	}
+
+	if err := blob.Verify(hash, data); err != nil {
+		return nil, err
+	}
+	// фото, сохраненные до включения шифрования, лежат в открытом виде
+	if !isImage(data) {
+		return nil, errors.New("image is encrypted but no master key is configured")
+	}
+	return data, nil
+}
+
+// verifyImage сверяет расшифрованное фото с адресом. У фото, сохраненных
+// до адресации по открытым данным, адрес - хеш шифротекста.
+func (r *analysisRepository) verifyImage(ctx context.Context, userID int64, hash string, data, image []byte) error {
+	key, err := r.cipher.ContentKey(ctx, userID, image)
+	if err != nil {
+		return err
+	}
+	if key == hash {
+		return nil
+	}
+	return blob.Verify(hash, data)
+}
+
+func (r *analysisRepository) ClearFilesBefore(ctx context.Context, before time.Time) (int64, error) {
+	hashes, err := imageHashes(ctx, r.db, `created_at < ?`, toUnix(before))
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE analyses SET file_id = '', image_hash = ''
+		WHERE (file_id != '' OR image_hash != '') AND created_at < ?`, toUnix(before))
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear analysis files: %w", err)
+	}
+
+	removeBlobs(ctx, r.db, r.blobs, hashes)
+	return res.RowsAffected()
+}
+
+func (r *analysisRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	hashes, err := imageHashes(ctx, r.db, `created_at < ?`, toUnix(before))
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin analyses cleanup: %w", err)
+	}
+	defer tx.Rollback()
+
+	// записи дневника остаются, но теряют ссылку на удаленный анализ
+	_, err = tx.ExecContext(ctx, `
+		UPDATE diary_entries SET analysis_id = 0
+		WHERE analysis_id IN (SELECT id FROM analyses WHERE created_at < ?)`, toUnix(before))
+	if err != nil {
+		return 0, fmt.Errorf("failed to unlink diary entries: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM analyses WHERE created_at < ?`, toUnix(before))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete analyses: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted analyses: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit analyses cleanup: %w", err)
+	}
+
+	removeBlobs(ctx, r.db, r.blobs, hashes)
+	return deleted, nil
+}
+
+func (r *analysisRepository) decrypt(ctx context.Context, a *storage.Analysis) error {
+	err := decryptFields(ctx, r.cipher, a.UserID, &a.UserContext, &a.Text, &a.Diagnosis, &a.Recommendations)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt analysis %d: %w", a.ID, err)
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAnalysis(row scanner) (*storage.Analysis, error) {
+	var a storage.Analysis
+	var createdAt int64
+
+	err := row.Scan(&a.ID, &a.UserID, &a.FileID, &a.UserContext, &a.Text,
+		&a.Diagnosis, &a.Recommendations, &a.BristolType, &a.ImageHash, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	a.CreatedAt = fromUnix(createdAt)
+	return &a, nil
+}
@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"testing"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+	"github.com/merdernoty/stool-guru-bot/internal/storage/blob"
+	"github.com/merdernoty/stool-guru-bot/internal/storage/encryption"
+)
+
+func openTestStore(t *testing.T, keyring *encryption.Keyring) *Store {
+	t.Helper()
+
+	blobs, err := blob.NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Open(context.Background(), ":memory:", Options{Keyring: keyring, Blobs: blobs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func testMasterKey(t *testing.T) *encryption.MasterKey {
+	t.Helper()
+
+	raw := make([]byte, encryption.KeySize)
+	if _, err := rand.Read(raw); err != nil {
+		t.Fatal(err)
+	}
+	key, err := encryption.NewMasterKey(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestAttachImageDeduplicatesEncryptedPhotos(t *testing.T) {
+	s := openTestStore(t, encryption.NewKeyring(testMasterKey(t)))
+	ctx := context.Background()
+	image := []byte("\x89PNG\r\n\x1a\n photo")
+
+	hashes := make(map[int64]string)
+	for _, userID := range []int64{1, 1, 2} {
+		analysis := &storage.Analysis{UserID: userID, Text: "analysis"}
+		if err := s.Analyses().Create(ctx, analysis); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Analyses().AttachImage(ctx, userID, analysis.ID, image); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := s.Analyses().Image(ctx, userID, analysis.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, image) {
+			t.Errorf("analysis %d: image does not round-trip", analysis.ID)
+		}
+
+		var hash string
+		if err := s.db.QueryRowContext(ctx, `SELECT image_hash FROM analyses WHERE id = ?`, analysis.ID).Scan(&hash); err != nil {
+			t.Fatal(err)
+		}
+		if hash == blob.Key(image) {
+			t.Error("address of an encrypted photo is the plain hash of its content")
+		}
+		if previous, ok := hashes[userID]; ok && previous != hash {
+			t.Errorf("user %d: the same photo is stored twice (%s, %s)", userID, previous, hash)
+		}
+		hashes[userID] = hash
+	}
+
+	if hashes[1] == hashes[2] {
+		t.Error("the same photo of different users shares one object")
+	}
+}
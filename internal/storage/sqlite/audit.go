@@ -0,0 +1,43 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+type auditRepository struct {
+	db *sql.DB
+}
+
+func (r *auditRepository) Create(ctx context.Context, record *storage.AuditRecord) error {
+	return createAuditRecord(ctx, r.db, record)
+}
+
+// execer - общий интерфейс *sql.DB и *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func createAuditRecord(ctx context.Context, db execer, record *storage.AuditRecord) error {
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now().UTC()
+	}
+
+	res, err := db.ExecContext(ctx, `
+		INSERT INTO audit_log (user_id, action, details, created_at)
+		VALUES (?, ?, ?, ?)`,
+		record.UserID, record.Action, record.Details, toUnix(record.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to create audit record: %w", err)
+	}
+
+	record.ID, err = res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get audit record id: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+type consentRepository struct {
+	db *sql.DB
+}
+
+func (r *consentRepository) Latest(ctx context.Context, userID int64) (*storage.Consent, error) {
+	c := &storage.Consent{UserID: userID}
+	var acceptedAt int64
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT version, accepted_at FROM consents
+		WHERE user_id = ?
+		ORDER BY version DESC LIMIT 1`, userID).
+		Scan(&c.Version, &acceptedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get consent: %w", err)
+	}
+
+	c.AcceptedAt = fromUnix(acceptedAt)
+	return c, nil
+}
+
+func (r *consentRepository) Accept(ctx context.Context, consent *storage.Consent) error {
+	if consent.AcceptedAt.IsZero() {
+		consent.AcceptedAt = time.Now().UTC()
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin consent: %w", err)
+	}
+	defer tx.Rollback()
+
+	// повторное нажатие кнопки не меняет время первого согласия
+	_, err = tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO consents (user_id, version, accepted_at)
+		VALUES (?, ?, ?)`,
+		consent.UserID, consent.Version, toUnix(consent.AcceptedAt))
+	if err != nil {
+		return fmt.Errorf("failed to save consent: %w", err)
+	}
+
+	err = createAuditRecord(ctx, tx, &storage.AuditRecord{
+		UserID:    consent.UserID,
+		Action:    storage.AuditActionConsent,
+		Details:   fmt.Sprintf("version=%d", consent.Version),
+		CreatedAt: consent.AcceptedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit consent: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+	"github.com/merdernoty/stool-guru-bot/internal/storage/encryption"
+)
+
+const diaryColumns = `id, user_id, occurred_at, bristol_type, urgency, pain, note, analysis_id, created_at`
+
+type diaryRepository struct {
+	db     *sql.DB
+	cipher *encryption.Cipher
+}
+
+func (r *diaryRepository) Create(ctx context.Context, entry *storage.DiaryEntry) error {
+	now := time.Now().UTC()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+	if entry.OccurredAt.IsZero() {
+		entry.OccurredAt = now
+	}
+
+	note := entry.Note
+	if err := encryptFields(ctx, r.cipher, entry.UserID, &note); err != nil {
+		return fmt.Errorf("failed to encrypt diary entry: %w", err)
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO diary_entries (user_id, occurred_at, bristol_type, urgency, pain, note, analysis_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.UserID, toUnix(entry.OccurredAt), entry.BristolType, entry.Urgency, entry.Pain,
+		note, entry.AnalysisID, toUnix(entry.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to create diary entry: %w", err)
+	}
+
+	entry.ID, err = res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get diary entry id: %w", err)
+	}
+	return nil
+}
+
+func (r *diaryRepository) Get(ctx context.Context, userID, id int64) (*storage.DiaryEntry, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+diaryColumns+` FROM diary_entries WHERE user_id = ? AND id = ?`, userID, id)
+
+	entry, err := scanDiaryEntry(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diary entry: %w", err)
+	}
+	if err := r.decrypt(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (r *diaryRepository) List(ctx context.Context, userID int64, filter storage.DiaryFilter) ([]storage.DiaryEntry, error) {
+	where, args := diaryWhere(userID, filter)
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	args = append(args, limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+diaryColumns+` FROM diary_entries
+		WHERE `+where+`
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT ? OFFSET ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list diary entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []storage.DiaryEntry
+	for rows.Next() {
+		entry, err := scanDiaryEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan diary entry: %w", err)
+		}
+		entries = append(entries, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list diary entries: %w", err)
+	}
+	rows.Close()
+
+	for i := range entries {
+		if err := r.decrypt(ctx, &entries[i]); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func (r *diaryRepository) Count(ctx context.Context, userID int64, filter storage.DiaryFilter) (int, error) {
+	where, args := diaryWhere(userID, filter)
+
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM diary_entries WHERE `+where, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count diary entries: %w", err)
+	}
+	return count, nil
+}
+
+func (r *diaryRepository) decrypt(ctx context.Context, e *storage.DiaryEntry) error {
+	if err := decryptFields(ctx, r.cipher, e.UserID, &e.Note); err != nil {
+		return fmt.Errorf("failed to decrypt diary entry %d: %w", e.ID, err)
+	}
+	return nil
+}
+
+func diaryWhere(userID int64, filter storage.DiaryFilter) (string, []any) {
+	conditions := []string{"user_id = ?"}
+	args := []any{userID}
+
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "occurred_at >= ?")
+		args = append(args, toUnix(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "occurred_at < ?")
+		args = append(args, toUnix(filter.To))
+	}
+	if filter.BristolType != 0 {
+		conditions = append(conditions, "bristol_type = ?")
+		args = append(args, filter.BristolType)
+	}
+	if filter.AnalysisID != 0 {
+		conditions = append(conditions, "analysis_id = ?")
+		args = append(args, filter.AnalysisID)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+func scanDiaryEntry(row scanner) (*storage.DiaryEntry, error) {
+	var e storage.DiaryEntry
+	var occurredAt, createdAt int64
+
+	err := row.Scan(&e.ID, &e.UserID, &occurredAt, &e.BristolType, &e.Urgency, &e.Pain,
+		&e.Note, &e.AnalysisID, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	e.OccurredAt = fromUnix(occurredAt)
+	e.CreatedAt = fromUnix(createdAt)
+	return &e, nil
+}
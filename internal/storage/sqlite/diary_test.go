@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+func TestDiaryFilterByAnalysis(t *testing.T) {
+	s := openTestStore(t, nil)
+	ctx := context.Background()
+
+	for _, entry := range []storage.DiaryEntry{
+		{UserID: 1, OccurredAt: time.Now(), BristolType: 4, AnalysisID: 10},
+		{UserID: 1, OccurredAt: time.Now(), BristolType: 4},
+		{UserID: 2, OccurredAt: time.Now(), BristolType: 4, AnalysisID: 10},
+	} {
+		if err := s.Diary().Create(ctx, &entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count, err := s.Diary().Count(ctx, 1, storage.DiaryFilter{AnalysisID: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("Count by analysis = %d, want 1", count)
+	}
+
+	count, err = s.Diary().Count(ctx, 1, storage.DiaryFilter{AnalysisID: 11})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("Count by unsaved analysis = %d, want 0", count)
+	}
+}
@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+func (s *Store) DeleteUserData(ctx context.Context, userID int64) (*storage.Erasure, error) {
+	hashes, err := imageHashes(ctx, s.db, `user_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin erasure: %w", err)
+	}
+	defer tx.Rollback()
+
+	var erasure storage.Erasure
+	counters := []struct {
+		table string
+		count *int64
+	}{
+		{table: "analyses", count: &erasure.Analyses},
+		{table: "diary_entries", count: &erasure.DiaryEntries},
+		{table: "questionnaire_results", count: &erasure.Questionnaires},
+		{table: "lifestyle_entries", count: &erasure.Lifestyle},
+		{table: "medications", count: &erasure.Medications},
+		{table: "medication_intakes"},
+		{table: "settings"},
+		{table: "profiles"},
+		// после удаления данных условия нужно принять заново, факт согласия остается в журнале аудита
+		{table: "consents"},
+		{table: "reminders"},
+		// без ключа пользователя его зашифрованные копии (например, в бэкапах) не прочитать
+		{table: "user_keys"},
+	}
+
+	for _, c := range counters {
+		res, err := tx.ExecContext(ctx, `DELETE FROM `+c.table+` WHERE user_id = ?`, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete from %s: %w", c.table, err)
+		}
+		if c.count != nil {
+			if *c.count, err = res.RowsAffected(); err != nil {
+				return nil, fmt.Errorf("failed to count deleted rows in %s: %w", c.table, err)
+			}
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, userID); err != nil {
+		return nil, fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	err = createAuditRecord(ctx, tx, &storage.AuditRecord{
+		UserID: userID,
+		Action: storage.AuditActionErasure,
+		Details: fmt.Sprintf("analyses=%d diary_entries=%d questionnaires=%d lifestyle_entries=%d medications=%d images=%d",
+			erasure.Analyses, erasure.DiaryEntries, erasure.Questionnaires, erasure.Lifestyle, erasure.Medications, len(hashes)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit erasure: %w", err)
+	}
+
+	removeBlobs(ctx, s.db, s.blobs, hashes)
+
+	if s.cipher != nil {
+		s.cipher.Forget(userID)
+	}
+	return &erasure, nil
+}
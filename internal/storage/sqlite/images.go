@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage/blob"
+)
+
+// imageHashes возвращает адреса фото у анализов, подходящих под условие where
+func imageHashes(ctx context.Context, db *sql.DB, where string, args ...any) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT DISTINCT image_hash FROM analyses WHERE image_hash != '' AND `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list image hashes: %w", err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("failed to scan image hash: %w", err)
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
+
+// removeBlobs удаляет объекты, на которые больше не ссылается ни один анализ.
+// Одинаковые фото пользователя совпадают по адресу, поэтому проверка обязательна.
+// Ошибки только логируются: строки в базе к этому моменту уже изменены.
+func removeBlobs(ctx context.Context, db *sql.DB, blobs blob.Store, hashes []string) {
+	if blobs == nil {
+		return
+	}
+
+	for _, hash := range hashes {
+		var refs int
+		err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM analyses WHERE image_hash = ?`, hash).Scan(&refs)
+		if err != nil {
+			log.Printf("Error counting image references: %v", err)
+			continue
+		}
+		if refs > 0 {
+			continue
+		}
+
+		if err := blobs.Delete(ctx, hash); err != nil {
+			log.Printf("Error deleting image %s: %v", hash, err)
+		}
+	}
+}
+
+func isImage(data []byte) bool {
+	return strings.HasPrefix(http.DetectContentType(data), "image/")
+}
@@ -0,0 +1,134 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage/encryption"
+)
+
+// keyRepository хранит обернутые ключи пользователей для encryption.Cipher
+type keyRepository struct {
+	db *sql.DB
+}
+
+var _ encryption.KeyStore = (*keyRepository)(nil)
+
+func (r *keyRepository) GetKey(ctx context.Context, userID int64) (*encryption.WrappedKey, error) {
+	key := encryption.WrappedKey{UserID: userID}
+	var createdAt, updatedAt int64
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT key_id, wrapped_key, created_at, updated_at FROM user_keys WHERE user_id = ?`, userID).
+		Scan(&key.KeyID, &key.Wrapped, &createdAt, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, encryption.ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data key: %w", err)
+	}
+
+	key.CreatedAt = fromUnix(createdAt)
+	key.UpdatedAt = fromUnix(updatedAt)
+	return &key, nil
+}
+
+func (r *keyRepository) CreateKey(ctx context.Context, key *encryption.WrappedKey) error {
+	now := time.Now().UTC()
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_keys (user_id, key_id, wrapped_key, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO NOTHING`,
+		key.UserID, key.KeyID, key.Wrapped, toUnix(now), toUnix(now))
+	if err != nil {
+		return fmt.Errorf("failed to create data key: %w", err)
+	}
+	return nil
+}
+
+// rewrap перешифровывает текущим мастер-ключом все ключи пользователей,
+// обернутые предыдущими. Сами данные при этом не перешифровываются.
+func (r *keyRepository) rewrap(ctx context.Context, keyring *encryption.Keyring) (int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT user_id, key_id, wrapped_key FROM user_keys WHERE key_id != ?`, keyring.CurrentID())
+	if err != nil {
+		return 0, fmt.Errorf("failed to list data keys: %w", err)
+	}
+
+	var stale []encryption.WrappedKey
+	for rows.Next() {
+		var key encryption.WrappedKey
+		if err := rows.Scan(&key.UserID, &key.KeyID, &key.Wrapped); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan data key: %w", err)
+		}
+		stale = append(stale, key)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to list data keys: %w", err)
+	}
+
+	for _, key := range stale {
+		dek, err := keyring.Unwrap(key.UserID, key.KeyID, key.Wrapped)
+		if err != nil {
+			return 0, fmt.Errorf("user %d: %w", key.UserID, err)
+		}
+
+		keyID, wrapped, err := keyring.Wrap(key.UserID, dek)
+		if err != nil {
+			return 0, fmt.Errorf("user %d: %w", key.UserID, err)
+		}
+
+		_, err = r.db.ExecContext(ctx, `
+			UPDATE user_keys SET key_id = ?, wrapped_key = ?, updated_at = ?
+			WHERE user_id = ? AND key_id = ?`,
+			keyID, wrapped, time.Now().Unix(), key.UserID, key.KeyID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update data key: %w", err)
+		}
+	}
+
+	return len(stale), nil
+}
+
+// encryptFields шифрует поля записи пользователя на месте. Без шифра ничего не делает.
+func encryptFields(ctx context.Context, c *encryption.Cipher, userID int64, fields ...*string) error {
+	if c == nil {
+		return nil
+	}
+	for _, field := range fields {
+		value, err := c.EncryptString(ctx, userID, *field)
+		if err != nil {
+			return err
+		}
+		*field = value
+	}
+	return nil
+}
+
+// decryptFields расшифровывает поля записи пользователя на месте.
+// Незашифрованные значения остаются как есть.
+// Списки расшифровываются только после закрытия курсора: соединение с базой одно,
+// и запрос ключа пользователя иначе ждал бы его освобождения.
+func decryptFields(ctx context.Context, c *encryption.Cipher, userID int64, fields ...*string) error {
+	for _, field := range fields {
+		if c == nil && encryption.IsEncrypted(*field) {
+			return errors.New("field is encrypted but no master key is configured")
+		}
+	}
+	if c == nil {
+		return nil
+	}
+	for _, field := range fields {
+		value, err := c.DecryptString(ctx, userID, *field)
+		if err != nil {
+			return err
+		}
+		*field = value
+	}
+	return nil
+}
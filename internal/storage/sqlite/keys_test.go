@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+	"github.com/merdernoty/stool-guru-bot/internal/storage/encryption"
+)
+
+func TestRewrap(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "bot.db")
+	old, current := testMasterKey(t), testMasterKey(t)
+
+	s, err := Open(ctx, path, Options{Keyring: encryption.NewKeyring(old)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysis := &storage.Analysis{UserID: 1, Text: "secret"}
+	if err := s.Analyses().Create(ctx, analysis); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = Open(ctx, path, Options{Keyring: encryption.NewKeyring(current, old)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := s.keys.GetKey(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.KeyID != current.ID {
+		t.Errorf("key is wrapped with %s, want current %s", key.KeyID, current.ID)
+	}
+	if n, err := s.keys.rewrap(ctx, encryption.NewKeyring(current, old)); err != nil || n != 0 {
+		t.Errorf("second rewrap = %d, %v; want nothing to do", n, err)
+	}
+	s.Close()
+
+	// после перевыпуска старый мастер-ключ больше не нужен
+	s, err = Open(ctx, path, Options{Keyring: encryption.NewKeyring(current)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	got, err := s.Analyses().Get(ctx, 1, analysis.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Text != "secret" {
+		t.Errorf("text = %q, want secret", got.Text)
+	}
+}
+
+func TestRewrapFailsWithoutPreviousKey(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, encryption.NewKeyring(testMasterKey(t)))
+	if err := s.Analyses().Create(ctx, &storage.Analysis{UserID: 1, Text: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.keys.rewrap(ctx, encryption.NewKeyring(testMasterKey(t))); err == nil {
+		t.Error("rewrap succeeded without the master key the data keys are wrapped with")
+	}
+}
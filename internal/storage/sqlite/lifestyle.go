@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+	"github.com/merdernoty/stool-guru-bot/internal/storage/encryption"
+)
+
+const lifestyleColumns = `id, user_id, kind, occurred_at, tags, value, note, created_at`
+
+type lifestyleRepository struct {
+	db     *sql.DB
+	cipher *encryption.Cipher
+}
+
+func (r *lifestyleRepository) Create(ctx context.Context, entry *storage.LifestyleEntry) error {
+	now := time.Now().UTC()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+	if entry.OccurredAt.IsZero() {
+		entry.OccurredAt = now
+	}
+
+	note := entry.Note
+	if err := encryptFields(ctx, r.cipher, entry.UserID, &note); err != nil {
+		return fmt.Errorf("failed to encrypt lifestyle entry: %w", err)
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO lifestyle_entries (user_id, kind, occurred_at, tags, value, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entry.UserID, entry.Kind, toUnix(entry.OccurredAt), strings.Join(entry.Tags, ","),
+		entry.Value, note, toUnix(entry.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to create lifestyle entry: %w", err)
+	}
+
+	entry.ID, err = res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get lifestyle entry id: %w", err)
+	}
+	return nil
+}
+
+func (r *lifestyleRepository) List(ctx context.Context, userID int64, from, to time.Time) ([]storage.LifestyleEntry, error) {
+	conditions := []string{"user_id = ?"}
+	args := []any{userID}
+	if !from.IsZero() {
+		conditions = append(conditions, "occurred_at >= ?")
+		args = append(args, toUnix(from))
+	}
+	if !to.IsZero() {
+		conditions = append(conditions, "occurred_at < ?")
+		args = append(args, toUnix(to))
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+lifestyleColumns+` FROM lifestyle_entries
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY occurred_at, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lifestyle entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []storage.LifestyleEntry
+	for rows.Next() {
+		entry, err := scanLifestyleEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan lifestyle entry: %w", err)
+		}
+		entries = append(entries, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list lifestyle entries: %w", err)
+	}
+	rows.Close()
+
+	for i := range entries {
+		e := &entries[i]
+		if err := decryptFields(ctx, r.cipher, e.UserID, &e.Note); err != nil {
+			return nil, fmt.Errorf("failed to decrypt lifestyle entry %d: %w", e.ID, err)
+		}
+	}
+	return entries, nil
+}
+
+func scanLifestyleEntry(row scanner) (*storage.LifestyleEntry, error) {
+	var e storage.LifestyleEntry
+	var occurredAt, createdAt int64
+	var tags string
+
+	err := row.Scan(&e.ID, &e.UserID, &e.Kind, &occurredAt, &tags, &e.Value, &e.Note, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if tags != "" {
+		e.Tags = strings.Split(tags, ",")
+	}
+	e.OccurredAt = fromUnix(occurredAt)
+	e.CreatedAt = fromUnix(createdAt)
+	return &e, nil
+}
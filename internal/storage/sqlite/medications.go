@@ -0,0 +1,191 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+	"github.com/merdernoty/stool-guru-bot/internal/storage/encryption"
+)
+
+const medicationColumns = `id, user_id, name, category, started_at, stopped_at, created_at`
+
+type medicationRepository struct {
+	db     *sql.DB
+	cipher *encryption.Cipher
+}
+
+func (r *medicationRepository) Create(ctx context.Context, medication *storage.Medication) error {
+	now := time.Now().UTC()
+	if medication.CreatedAt.IsZero() {
+		medication.CreatedAt = now
+	}
+	if medication.StartedAt.IsZero() {
+		medication.StartedAt = now
+	}
+
+	name := medication.Name
+	if err := encryptFields(ctx, r.cipher, medication.UserID, &name); err != nil {
+		return fmt.Errorf("failed to encrypt medication: %w", err)
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO medications (user_id, name, category, started_at, stopped_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		medication.UserID, name, medication.Category, toUnix(medication.StartedAt),
+		toUnix(medication.StoppedAt), toUnix(medication.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to create medication: %w", err)
+	}
+
+	medication.ID, err = res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get medication id: %w", err)
+	}
+	return nil
+}
+
+func (r *medicationRepository) Get(ctx context.Context, userID, id int64) (*storage.Medication, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+medicationColumns+` FROM medications WHERE user_id = ? AND id = ?`, userID, id)
+
+	medication, err := scanMedication(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get medication: %w", err)
+	}
+	if err := r.decrypt(ctx, medication); err != nil {
+		return nil, err
+	}
+	return medication, nil
+}
+
+func (r *medicationRepository) List(ctx context.Context, userID int64) ([]storage.Medication, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+medicationColumns+` FROM medications
+		WHERE user_id = ?
+		ORDER BY started_at DESC, id DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list medications: %w", err)
+	}
+	defer rows.Close()
+
+	var medications []storage.Medication
+	for rows.Next() {
+		medication, err := scanMedication(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan medication: %w", err)
+		}
+		medications = append(medications, *medication)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list medications: %w", err)
+	}
+	rows.Close()
+
+	for i := range medications {
+		if err := r.decrypt(ctx, &medications[i]); err != nil {
+			return nil, err
+		}
+	}
+	return medications, nil
+}
+
+func (r *medicationRepository) Stop(ctx context.Context, userID, id int64, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE medications SET stopped_at = ?
+		WHERE user_id = ? AND id = ? AND stopped_at = 0`, toUnix(at), userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to stop medication: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to stop medication: %w", err)
+	}
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (r *medicationRepository) LogIntake(ctx context.Context, intake *storage.MedicationIntake) (bool, error) {
+	if intake.TakenAt.IsZero() {
+		intake.TakenAt = time.Now().UTC()
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT OR IGNORE INTO medication_intakes (user_id, medication_id, day, taken_at)
+		VALUES (?, ?, ?, ?)`,
+		intake.UserID, intake.MedicationID, intake.Day, toUnix(intake.TakenAt))
+	if err != nil {
+		return false, fmt.Errorf("failed to log medication intake: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to log medication intake: %w", err)
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	intake.ID, err = res.LastInsertId()
+	if err != nil {
+		return false, fmt.Errorf("failed to get medication intake id: %w", err)
+	}
+	return true, nil
+}
+
+func (r *medicationRepository) Intakes(ctx context.Context, userID int64, from, to time.Time) ([]storage.MedicationIntake, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, medication_id, day, taken_at FROM medication_intakes
+		WHERE user_id = ? AND taken_at >= ? AND taken_at < ?
+		ORDER BY taken_at, id`, userID, toUnix(from), toUnix(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list medication intakes: %w", err)
+	}
+	defer rows.Close()
+
+	var intakes []storage.MedicationIntake
+	for rows.Next() {
+		var intake storage.MedicationIntake
+		var takenAt int64
+		if err := rows.Scan(&intake.ID, &intake.UserID, &intake.MedicationID, &intake.Day, &takenAt); err != nil {
+			return nil, fmt.Errorf("failed to scan medication intake: %w", err)
+		}
+		intake.TakenAt = fromUnix(takenAt)
+		intakes = append(intakes, intake)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list medication intakes: %w", err)
+	}
+	return intakes, nil
+}
+
+func (r *medicationRepository) decrypt(ctx context.Context, m *storage.Medication) error {
+	if err := decryptFields(ctx, r.cipher, m.UserID, &m.Name); err != nil {
+		return fmt.Errorf("failed to decrypt medication %d: %w", m.ID, err)
+	}
+	return nil
+}
+
+func scanMedication(row scanner) (*storage.Medication, error) {
+	var m storage.Medication
+	var startedAt, stoppedAt, createdAt int64
+
+	err := row.Scan(&m.ID, &m.UserID, &m.Name, &m.Category, &startedAt, &stoppedAt, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	m.StartedAt = fromUnix(startedAt)
+	m.StoppedAt = fromUnix(stoppedAt)
+	m.CreatedAt = fromUnix(createdAt)
+	return &m, nil
+}
@@ -0,0 +1,54 @@
+CREATE TABLE users (
+    id            INTEGER PRIMARY KEY,
+    username      TEXT    NOT NULL DEFAULT '',
+    first_name    TEXT    NOT NULL DEFAULT '',
+    language_code TEXT    NOT NULL DEFAULT '',
+    created_at    INTEGER NOT NULL,
+    updated_at    INTEGER NOT NULL
+);
+
+CREATE TABLE analyses (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id         INTEGER NOT NULL,
+    file_id         TEXT    NOT NULL DEFAULT '',
+    user_context    TEXT    NOT NULL DEFAULT '',
+    text            TEXT    NOT NULL,
+    diagnosis       TEXT    NOT NULL DEFAULT '',
+    recommendations TEXT    NOT NULL DEFAULT '',
+    created_at      INTEGER NOT NULL
+);
+
+CREATE INDEX idx_analyses_user_created ON analyses (user_id, created_at);
+
+CREATE TABLE diary_entries (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id      INTEGER NOT NULL,
+    occurred_at  INTEGER NOT NULL,
+    bristol_type INTEGER NOT NULL DEFAULT 0,
+    urgency      INTEGER NOT NULL DEFAULT 0,
+    pain         INTEGER NOT NULL DEFAULT 0,
+    note         TEXT    NOT NULL DEFAULT '',
+    analysis_id  INTEGER NOT NULL DEFAULT 0,
+    created_at   INTEGER NOT NULL
+);
+
+CREATE INDEX idx_diary_entries_user_occurred ON diary_entries (user_id, occurred_at);
+
+CREATE TABLE settings (
+    user_id    INTEGER PRIMARY KEY,
+    language   TEXT    NOT NULL DEFAULT 'ru',
+    timezone   TEXT    NOT NULL DEFAULT 'Europe/Moscow',
+    updated_at INTEGER NOT NULL
+);
+
+CREATE TABLE questionnaire_results (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER NOT NULL,
+    kind       TEXT    NOT NULL,
+    score      INTEGER NOT NULL,
+    band       TEXT    NOT NULL DEFAULT '',
+    answers    TEXT    NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL
+);
+
+CREATE INDEX idx_questionnaire_results_user_kind ON questionnaire_results (user_id, kind, created_at);
@@ -0,0 +1 @@
+ALTER TABLE analyses ADD COLUMN bristol_type INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,9 @@
+CREATE TABLE audit_log (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER NOT NULL DEFAULT 0,
+    action     TEXT    NOT NULL,
+    details    TEXT    NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL
+);
+
+CREATE INDEX idx_audit_log_user ON audit_log (user_id, created_at);
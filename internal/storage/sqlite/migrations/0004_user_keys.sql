@@ -0,0 +1,9 @@
+CREATE TABLE user_keys (
+    user_id     INTEGER PRIMARY KEY,
+    key_id      TEXT    NOT NULL,
+    wrapped_key BLOB    NOT NULL,
+    created_at  INTEGER NOT NULL,
+    updated_at  INTEGER NOT NULL
+);
+
+CREATE INDEX idx_user_keys_key_id ON user_keys (key_id);
@@ -0,0 +1,5 @@
+ALTER TABLE analyses ADD COLUMN image_hash TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_analyses_image_hash ON analyses (image_hash) WHERE image_hash != '';
+
+ALTER TABLE settings ADD COLUMN keep_images INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+CREATE TABLE reminders (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER NOT NULL,
+    chat_id    INTEGER NOT NULL,
+    minute     INTEGER NOT NULL,
+    enabled    INTEGER NOT NULL DEFAULT 1,
+    next_at    INTEGER NOT NULL,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+CREATE INDEX idx_reminders_user ON reminders (user_id, minute);
+CREATE INDEX idx_reminders_due ON reminders (enabled, next_at);
+
+ALTER TABLE settings ADD COLUMN quiet_from INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE settings ADD COLUMN quiet_to INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,12 @@
+CREATE TABLE lifestyle_entries (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id     INTEGER NOT NULL,
+    kind        TEXT    NOT NULL,
+    occurred_at INTEGER NOT NULL,
+    tags        TEXT    NOT NULL DEFAULT '',
+    value       INTEGER NOT NULL DEFAULT 0,
+    note        TEXT    NOT NULL DEFAULT '',
+    created_at  INTEGER NOT NULL
+);
+
+CREATE INDEX idx_lifestyle_entries_user_occurred ON lifestyle_entries (user_id, occurred_at);
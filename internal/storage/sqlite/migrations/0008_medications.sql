@@ -0,0 +1,22 @@
+CREATE TABLE medications (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER NOT NULL,
+    name       TEXT    NOT NULL,
+    category   TEXT    NOT NULL DEFAULT '',
+    started_at INTEGER NOT NULL,
+    stopped_at INTEGER NOT NULL DEFAULT 0,
+    created_at INTEGER NOT NULL
+);
+
+CREATE INDEX idx_medications_user ON medications (user_id, started_at);
+
+CREATE TABLE medication_intakes (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id       INTEGER NOT NULL,
+    medication_id INTEGER NOT NULL,
+    day           TEXT    NOT NULL,
+    taken_at      INTEGER NOT NULL,
+    UNIQUE (medication_id, day)
+);
+
+CREATE INDEX idx_medication_intakes_user ON medication_intakes (user_id, taken_at);
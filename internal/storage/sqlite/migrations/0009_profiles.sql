@@ -0,0 +1,10 @@
+CREATE TABLE profiles (
+    user_id      INTEGER PRIMARY KEY,
+    age_range    TEXT    NOT NULL DEFAULT '',
+    sex          TEXT    NOT NULL DEFAULT '',
+    conditions   TEXT    NOT NULL DEFAULT '',
+    diet         TEXT    NOT NULL DEFAULT '',
+    detail_level TEXT    NOT NULL DEFAULT '',
+    created_at   INTEGER NOT NULL,
+    updated_at   INTEGER NOT NULL
+);
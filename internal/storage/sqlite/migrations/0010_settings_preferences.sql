@@ -0,0 +1,2 @@
+ALTER TABLE settings ADD COLUMN incognito INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE settings ADD COLUMN notifications TEXT NOT NULL DEFAULT 'sound';
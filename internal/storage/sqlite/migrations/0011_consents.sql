@@ -0,0 +1,6 @@
+CREATE TABLE consents (
+    user_id     INTEGER NOT NULL,
+    version     INTEGER NOT NULL,
+    accepted_at INTEGER NOT NULL,
+    PRIMARY KEY (user_id, version)
+);
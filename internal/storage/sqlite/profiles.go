@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+	"github.com/merdernoty/stool-guru-bot/internal/storage/encryption"
+)
+
+type profileRepository struct {
+	db     *sql.DB
+	cipher *encryption.Cipher
+}
+
+func (r *profileRepository) Get(ctx context.Context, userID int64) (*storage.Profile, error) {
+	p := &storage.Profile{UserID: userID}
+	var conditions string
+	var createdAt, updatedAt int64
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT age_range, sex, conditions, diet, detail_level, created_at, updated_at
+		FROM profiles WHERE user_id = ?`, userID).
+		Scan(&p.AgeRange, &p.Sex, &conditions, &p.Diet, &p.DetailLevel, &createdAt, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+
+	if err := decryptFields(ctx, r.cipher, userID, &conditions); err != nil {
+		return nil, fmt.Errorf("failed to decrypt profile: %w", err)
+	}
+	if conditions != "" {
+		p.Conditions = strings.Split(conditions, ",")
+	}
+	p.CreatedAt = fromUnix(createdAt)
+	p.UpdatedAt = fromUnix(updatedAt)
+	return p, nil
+}
+
+func (r *profileRepository) Save(ctx context.Context, p *storage.Profile) error {
+	now := time.Now().UTC()
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = now
+	}
+	p.UpdatedAt = now
+
+	// диагнозы - самые чувствительные поля анкеты, они шифруются вместе с заметками
+	conditions := strings.Join(p.Conditions, ",")
+	if err := encryptFields(ctx, r.cipher, p.UserID, &conditions); err != nil {
+		return fmt.Errorf("failed to encrypt profile: %w", err)
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO profiles (user_id, age_range, sex, conditions, diet, detail_level, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			age_range = excluded.age_range,
+			sex = excluded.sex,
+			conditions = excluded.conditions,
+			diet = excluded.diet,
+			detail_level = excluded.detail_level,
+			updated_at = excluded.updated_at`,
+		p.UserID, p.AgeRange, p.Sex, conditions, p.Diet, p.DetailLevel, toUnix(p.CreatedAt), toUnix(p.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+type questionnaireRepository struct {
+	db *sql.DB
+}
+
+func (r *questionnaireRepository) Create(ctx context.Context, result *storage.QuestionnaireResult) error {
+	if result.CreatedAt.IsZero() {
+		result.CreatedAt = time.Now().UTC()
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO questionnaire_results (user_id, kind, score, band, answers, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		result.UserID, result.Kind, result.Score, result.Band, result.Answers, toUnix(result.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to create questionnaire result: %w", err)
+	}
+
+	result.ID, err = res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get questionnaire result id: %w", err)
+	}
+	return nil
+}
+
+func (r *questionnaireRepository) Recent(ctx context.Context, userID int64, kind string, limit int) ([]storage.QuestionnaireResult, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, kind, score, band, answers, created_at
+		FROM questionnaire_results
+		WHERE user_id = ? AND kind = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?`, userID, kind, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list questionnaire results: %w", err)
+	}
+	return scanQuestionnaireResults(rows)
+}
+
+func (r *questionnaireRepository) List(ctx context.Context, userID int64) ([]storage.QuestionnaireResult, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, kind, score, band, answers, created_at
+		FROM questionnaire_results
+		WHERE user_id = ?
+		ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list questionnaire results: %w", err)
+	}
+	return scanQuestionnaireResults(rows)
+}
+
+func scanQuestionnaireResults(rows *sql.Rows) ([]storage.QuestionnaireResult, error) {
+	defer rows.Close()
+
+	var results []storage.QuestionnaireResult
+	for rows.Next() {
+		var r storage.QuestionnaireResult
+		var createdAt int64
+		if err := rows.Scan(&r.ID, &r.UserID, &r.Kind, &r.Score, &r.Band, &r.Answers, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan questionnaire result: %w", err)
+		}
+		r.CreatedAt = fromUnix(createdAt)
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+const reminderColumns = `id, user_id, chat_id, minute, enabled, next_at, created_at, updated_at`
+
+type reminderRepository struct {
+	db *sql.DB
+}
+
+func (r *reminderRepository) Create(ctx context.Context, reminder *storage.Reminder) error {
+	now := time.Now().UTC()
+	if reminder.CreatedAt.IsZero() {
+		reminder.CreatedAt = now
+	}
+	reminder.UpdatedAt = now
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO reminders (user_id, chat_id, minute, enabled, next_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		reminder.UserID, reminder.ChatID, reminder.Minute, reminder.Enabled,
+		toUnix(reminder.NextAt), toUnix(reminder.CreatedAt), toUnix(reminder.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to create reminder: %w", err)
+	}
+
+	reminder.ID, err = res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get reminder id: %w", err)
+	}
+	return nil
+}
+
+func (r *reminderRepository) Get(ctx context.Context, userID, id int64) (*storage.Reminder, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+reminderColumns+` FROM reminders WHERE user_id = ? AND id = ?`, userID, id)
+
+	reminder, err := scanReminder(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminder: %w", err)
+	}
+	return reminder, nil
+}
+
+func (r *reminderRepository) List(ctx context.Context, userID int64) ([]storage.Reminder, error) {
+	return r.query(ctx, `
+		SELECT `+reminderColumns+` FROM reminders
+		WHERE user_id = ?
+		ORDER BY minute, id`, userID)
+}
+
+func (r *reminderRepository) Update(ctx context.Context, reminder *storage.Reminder) error {
+	reminder.UpdatedAt = time.Now().UTC()
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE reminders SET chat_id = ?, minute = ?, enabled = ?, next_at = ?, updated_at = ?
+		WHERE user_id = ? AND id = ?`,
+		reminder.ChatID, reminder.Minute, reminder.Enabled, toUnix(reminder.NextAt), toUnix(reminder.UpdatedAt),
+		reminder.UserID, reminder.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update reminder: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (r *reminderRepository) Delete(ctx context.Context, userID, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM reminders WHERE user_id = ? AND id = ?`, userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+	return nil
+}
+
+func (r *reminderRepository) Due(ctx context.Context, now time.Time, limit int) ([]storage.Reminder, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+
+	return r.query(ctx, `
+		SELECT `+reminderColumns+` FROM reminders
+		WHERE enabled = 1 AND next_at <= ?
+		ORDER BY next_at, id
+		LIMIT ?`, toUnix(now), limit)
+}
+
+func (r *reminderRepository) query(ctx context.Context, query string, args ...any) ([]storage.Reminder, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminders: %w", err)
+	}
+	defer rows.Close()
+
+	var reminders []storage.Reminder
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		reminders = append(reminders, *reminder)
+	}
+	return reminders, rows.Err()
+}
+
+func scanReminder(row scanner) (*storage.Reminder, error) {
+	var rem storage.Reminder
+	var nextAt, createdAt, updatedAt int64
+
+	err := row.Scan(&rem.ID, &rem.UserID, &rem.ChatID, &rem.Minute, &rem.Enabled, &nextAt, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rem.NextAt = fromUnix(nextAt)
+	rem.CreatedAt = fromUnix(createdAt)
+	rem.UpdatedAt = fromUnix(updatedAt)
+	return &rem, nil
+}
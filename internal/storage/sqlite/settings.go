@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+type settingsRepository struct {
+	db *sql.DB
+}
+
+func (r *settingsRepository) Get(ctx context.Context, userID int64) (*storage.Settings, error) {
+	s := storage.DefaultSettings(userID)
+	var updatedAt int64
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT language, timezone, keep_images, quiet_from, quiet_to, incognito, notifications, updated_at
+		FROM settings WHERE user_id = ?`, userID).
+		Scan(&s.Language, &s.Timezone, &s.KeepImages, &s.QuietFrom, &s.QuietTo, &s.Incognito, &s.Notifications, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+
+	s.UpdatedAt = fromUnix(updatedAt)
+	return s, nil
+}
+
+func (r *settingsRepository) Save(ctx context.Context, s *storage.Settings) error {
+	s.UpdatedAt = time.Now().UTC()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO settings (user_id, language, timezone, keep_images, quiet_from, quiet_to, incognito, notifications, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			language = excluded.language,
+			timezone = excluded.timezone,
+			keep_images = excluded.keep_images,
+			quiet_from = excluded.quiet_from,
+			quiet_to = excluded.quiet_to,
+			incognito = excluded.incognito,
+			notifications = excluded.notifications,
+			updated_at = excluded.updated_at`,
+		s.UserID, s.Language, s.Timezone, s.KeepImages, s.QuietFrom, s.QuietTo, s.Incognito, s.Notifications, toUnix(s.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+	"github.com/merdernoty/stool-guru-bot/internal/storage/blob"
+	"github.com/merdernoty/stool-guru-bot/internal/storage/encryption"
+
+	_ "modernc.org/sqlite"
+)
+
+// Store - реализация storage.Storage поверх встроенной SQLite
+type Store struct {
+	db *sql.DB
+
+	users          *userRepository
+	analyses       *analysisRepository
+	diary          *diaryRepository
+	settings       *settingsRepository
+	questionnaires *questionnaireRepository
+	reminders      *reminderRepository
+	lifestyle      *lifestyleRepository
+	medications    *medicationRepository
+	profiles       *profileRepository
+	consents       *consentRepository
+	audit          *auditRepository
+	keys           *keyRepository
+
+	// cipher - nil, если шифрование не настроено
+	cipher *encryption.Cipher
+	blobs  blob.Store
+}
+
+// Options - необязательные зависимости хранилища
+type Options struct {
+	// Keyring включает шифрование заметок, текстов анализов и фото
+	Keyring *encryption.Keyring
+	// Blobs - хранилище оригиналов фото. Без него фото не сохраняются.
+	Blobs blob.Store
+}
+
+var _ storage.Storage = (*Store)(nil)
+
+// Open открывает (или создает) базу по пути path и применяет миграции.
+// Если задан opts.Keyring, данные шифруются ключами пользователей,
+// а ключи, обернутые предыдущими мастер-ключами, перешифровываются текущим.
+func Open(ctx context.Context, path string, opts Options) (*Store, error) {
+	keyring := opts.Keyring
+
+	if path != ":memory:" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	dsn := path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// SQLite допускает одного писателя, одно соединение избавляет от SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	keys := &keyRepository{db: db}
+
+	var dataCipher *encryption.Cipher
+	if keyring != nil {
+		rewrapped, err := keys.rewrap(ctx, keyring)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to rotate data keys: %w", err)
+		}
+		if rewrapped > 0 {
+			log.Printf("🔐 Re-wrapped %d data keys with master key %s", rewrapped, keyring.CurrentID())
+		}
+		dataCipher = encryption.NewCipher(keyring, keys)
+		log.Printf("🔐 Encryption at rest enabled (master key %s)", keyring.CurrentID())
+	} else {
+		log.Println("⚠️ Encryption at rest is DISABLED: diary notes, analyses, lifestyle logs, medications, profiles and photos are stored in plaintext. Set ENCRYPTION_KEY or ENCRYPTION_KEY_FILE to enable it")
+	}
+
+	log.Printf("🗄 Database opened: %s", path)
+
+	return &Store{
+		db:             db,
+		users:          &userRepository{db: db},
+		analyses:       &analysisRepository{db: db, cipher: dataCipher, blobs: opts.Blobs},
+		diary:          &diaryRepository{db: db, cipher: dataCipher},
+		settings:       &settingsRepository{db: db},
+		questionnaires: &questionnaireRepository{db: db},
+		reminders:      &reminderRepository{db: db},
+		lifestyle:      &lifestyleRepository{db: db, cipher: dataCipher},
+		medications:    &medicationRepository{db: db, cipher: dataCipher},
+		profiles:       &profileRepository{db: db, cipher: dataCipher},
+		consents:       &consentRepository{db: db},
+		audit:          &auditRepository{db: db},
+		keys:           keys,
+		cipher:         dataCipher,
+		blobs:          opts.Blobs,
+	}, nil
+}
+
+func (s *Store) Users() storage.UserRepository {
+	return s.users
+}
+
+func (s *Store) Analyses() storage.AnalysisRepository {
+	return s.analyses
+}
+
+func (s *Store) Diary() storage.DiaryRepository {
+	return s.diary
+}
+
+func (s *Store) Settings() storage.SettingsRepository {
+	return s.settings
+}
+
+func (s *Store) Questionnaires() storage.QuestionnaireRepository {
+	return s.questionnaires
+}
+
+func (s *Store) Reminders() storage.ReminderRepository {
+	return s.reminders
+}
+
+func (s *Store) Lifestyle() storage.LifestyleRepository {
+	return s.lifestyle
+}
+
+func (s *Store) Medications() storage.MedicationRepository {
+	return s.medications
+}
+
+func (s *Store) Profiles() storage.ProfileRepository {
+	return s.profiles
+}
+
+func (s *Store) Consents() storage.ConsentRepository {
+	return s.consents
+}
+
+func (s *Store) Audit() storage.AuditRepository {
+	return s.audit
+}
+
+func (s *Store) Encrypted() bool {
+	return s.cipher != nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func toUnix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func fromUnix(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
+}
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/merdernoty/stool-guru-bot/internal/storage"
+)
+
+type userRepository struct {
+	db *sql.DB
+}
+
+func (r *userRepository) Upsert(ctx context.Context, user *storage.User) error {
+	now := time.Now().UTC()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	user.UpdatedAt = now
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO users (id, username, first_name, language_code, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			username = excluded.username,
+			first_name = excluded.first_name,
+			language_code = excluded.language_code,
+			updated_at = excluded.updated_at`,
+		user.ID, user.Username, user.FirstName, user.LanguageCode,
+		toUnix(user.CreatedAt), toUnix(user.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to upsert user: %w", err)
+	}
+	return nil
+}
+
+func (r *userRepository) Get(ctx context.Context, id int64) (*storage.User, error) {
+	var user storage.User
+	var createdAt, updatedAt int64
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, username, first_name, language_code, created_at, updated_at
+		FROM users WHERE id = ?`, id).
+		Scan(&user.ID, &user.Username, &user.FirstName, &user.LanguageCode, &createdAt, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	user.CreatedAt = fromUnix(createdAt)
+	user.UpdatedAt = fromUnix(updatedAt)
+	return &user, nil
+}
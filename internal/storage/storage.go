@@ -0,0 +1,337 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound возвращается, когда запись не найдена
+var ErrNotFound = errors.New("not found")
+
+// Storage - единая точка доступа к сохраненным данным пользователей
+type Storage interface {
+	Users() UserRepository
+	Analyses() AnalysisRepository
+	Diary() DiaryRepository
+	Settings() SettingsRepository
+	Questionnaires() QuestionnaireRepository
+	Reminders() ReminderRepository
+	Lifestyle() LifestyleRepository
+	Medications() MedicationRepository
+	Profiles() ProfileRepository
+	Consents() ConsentRepository
+	Audit() AuditRepository
+
+	// Encrypted сообщает, шифруются ли заметки, тексты анализов и фото
+	Encrypted() bool
+
+	// DeleteUserData удаляет все данные пользователя в одной транзакции
+	// и оставляет в журнале аудита запись об удалении
+	DeleteUserData(ctx context.Context, userID int64) (*Erasure, error)
+
+	Close() error
+}
+
+// User - пользователь Telegram, который писал боту
+type User struct {
+	ID           int64
+	Username     string
+	FirstName    string
+	LanguageCode string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type UserRepository interface {
+	// Upsert создает пользователя или обновляет его данные из Telegram
+	Upsert(ctx context.Context, user *User) error
+	Get(ctx context.Context, id int64) (*User, error)
+}
+
+// Analysis - результат анализа фото
+type Analysis struct {
+	ID              int64
+	UserID          int64
+	FileID          string
+	UserContext     string
+	Text            string
+	Diagnosis       string
+	Recommendations string
+	BristolType     int
+	// ImageHash - адрес сохраненного оригинала фото в хранилище, пусто - фото не сохранено
+	ImageHash string
+	CreatedAt time.Time
+}
+
+type AnalysisRepository interface {
+	Create(ctx context.Context, analysis *Analysis) error
+	Get(ctx context.Context, userID, id int64) (*Analysis, error)
+	// List возвращает анализы пользователя, начиная с самых новых
+	List(ctx context.Context, userID int64, limit, offset int) ([]Analysis, error)
+	// AttachImage сохраняет оригинал фото к анализу
+	AttachImage(ctx context.Context, userID, id int64, image []byte) error
+	// Image возвращает сохраненный оригинал фото или ErrNotFound
+	Image(ctx context.Context, userID, id int64) ([]byte, error)
+	// ClearFilesBefore удаляет фото и ссылки на них у анализов старше before
+	ClearFilesBefore(ctx context.Context, before time.Time) (int64, error)
+	// DeleteBefore удаляет анализы старше before
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// DiaryEntry - запись в дневнике стула
+type DiaryEntry struct {
+	ID          int64
+	UserID      int64
+	OccurredAt  time.Time
+	BristolType int
+	Urgency     int
+	Pain        int
+	Note        string
+	AnalysisID  int64
+	CreatedAt   time.Time
+}
+
+// DiaryFilter - условия выборки записей дневника. Нулевые поля не ограничивают выборку.
+type DiaryFilter struct {
+	From        time.Time
+	To          time.Time
+	BristolType int
+	AnalysisID  int64
+	Limit       int
+	Offset      int
+}
+
+type DiaryRepository interface {
+	Create(ctx context.Context, entry *DiaryEntry) error
+	Get(ctx context.Context, userID, id int64) (*DiaryEntry, error)
+	// List возвращает записи пользователя, начиная с самых новых
+	List(ctx context.Context, userID int64, filter DiaryFilter) ([]DiaryEntry, error)
+	Count(ctx context.Context, userID int64, filter DiaryFilter) (int, error)
+}
+
+// Profile - анкета пользователя, по которой анализ подстраивается под человека.
+// Поля хранят ключи вариантов ответа, пустое значение - пользователь не ответил.
+type Profile struct {
+	UserID      int64
+	AgeRange    string
+	Sex         string
+	Conditions  []string
+	Diet        string
+	DetailLevel string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type ProfileRepository interface {
+	// Get возвращает анкету или ErrNotFound, если пользователь ее не заполнял
+	Get(ctx context.Context, userID int64) (*Profile, error)
+	Save(ctx context.Context, profile *Profile) error
+}
+
+// Consent - принятая пользователем версия условий: правила сервиса,
+// обработка данных и то, что бот не заменяет врача
+type Consent struct {
+	UserID     int64
+	Version    int
+	AcceptedAt time.Time
+}
+
+type ConsentRepository interface {
+	// Latest возвращает последнее принятое согласие или ErrNotFound
+	Latest(ctx context.Context, userID int64) (*Consent, error)
+	// Accept сохраняет согласие и оставляет запись в журнале аудита
+	Accept(ctx context.Context, consent *Consent) error
+}
+
+// Settings - пользовательские настройки
+type Settings struct {
+	UserID int64
+	// Language - язык бота, пусто - язык из настроек Telegram
+	Language string
+	Timezone string
+	// KeepImages - пользователь согласился хранить оригиналы фото
+	KeepImages bool
+	// QuietFrom и QuietTo - тихие часы в минутах от полуночи по местному времени.
+	// Если они совпадают, тихих часов нет.
+	QuietFrom int
+	QuietTo   int
+	// Incognito - фото анализируются, но ни анализ, ни оригинал не сохраняются
+	Incognito bool
+	// Notifications - как присылать напоминания: NotifySound, NotifySilent или NotifyOff
+	Notifications string
+	UpdatedAt     time.Time
+}
+
+// Режимы уведомлений
+const (
+	NotifySound  = "sound"
+	NotifySilent = "silent"
+	NotifyOff    = "off"
+)
+
+type SettingsRepository interface {
+	// Get возвращает настройки пользователя или значения по умолчанию, если их еще нет
+	Get(ctx context.Context, userID int64) (*Settings, error)
+	Save(ctx context.Context, settings *Settings) error
+}
+
+// QuestionnaireResult - результат прохождения опросника
+type QuestionnaireResult struct {
+	ID        int64
+	UserID    int64
+	Kind      string
+	Score     int
+	Band      string
+	Answers   string
+	CreatedAt time.Time
+}
+
+type QuestionnaireRepository interface {
+	Create(ctx context.Context, result *QuestionnaireResult) error
+	// Recent возвращает последние результаты опросника kind, начиная с самого нового
+	Recent(ctx context.Context, userID int64, kind string, limit int) ([]QuestionnaireResult, error)
+	// List возвращает все результаты пользователя, начиная с самых старых
+	List(ctx context.Context, userID int64) ([]QuestionnaireResult, error)
+}
+
+// Виды записей образа жизни
+const (
+	LifestyleMeal   = "meal"
+	LifestyleSleep  = "sleep"
+	LifestyleStress = "stress"
+)
+
+// LifestyleEntry - запись о еде, сне или стрессе
+type LifestyleEntry struct {
+	ID         int64
+	UserID     int64
+	Kind       string
+	OccurredAt time.Time
+	// Tags - метки приема пищи (молочное, кофе, ...), только для LifestyleMeal
+	Tags []string
+	// Value - часы сна для LifestyleSleep или уровень стресса 0-10 для LifestyleStress
+	Value     int
+	Note      string
+	CreatedAt time.Time
+}
+
+type LifestyleRepository interface {
+	Create(ctx context.Context, entry *LifestyleEntry) error
+	// List возвращает записи пользователя за [from, to), начиная с самых старых.
+	// Нулевые границы не ограничивают выборку.
+	List(ctx context.Context, userID int64, from, to time.Time) ([]LifestyleEntry, error)
+}
+
+// Medication - курс лекарства или добавки
+type Medication struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	Category  string
+	StartedAt time.Time
+	// StoppedAt - когда курс завершен, нулевое значение - курс продолжается
+	StoppedAt time.Time
+	CreatedAt time.Time
+}
+
+// ActiveAt сообщает, принимался ли препарат в момент t
+func (m *Medication) ActiveAt(t time.Time) bool {
+	return !m.StartedAt.After(t) && (m.StoppedAt.IsZero() || m.StoppedAt.After(t))
+}
+
+// MedicationIntake - отметка о приеме препарата за день
+type MedicationIntake struct {
+	ID           int64
+	UserID       int64
+	MedicationID int64
+	// Day - дата приема по местному времени пользователя в формате 2006-01-02
+	Day     string
+	TakenAt time.Time
+}
+
+type MedicationRepository interface {
+	Create(ctx context.Context, medication *Medication) error
+	Get(ctx context.Context, userID, id int64) (*Medication, error)
+	// List возвращает все курсы пользователя, начиная с самых новых
+	List(ctx context.Context, userID int64) ([]Medication, error)
+	Stop(ctx context.Context, userID, id int64, at time.Time) error
+	// LogIntake отмечает прием за день. Возвращает false, если за этот день прием уже отмечен.
+	LogIntake(ctx context.Context, intake *MedicationIntake) (bool, error)
+	// Intakes возвращает отметки о приеме за [from, to), начиная с самых старых
+	Intakes(ctx context.Context, userID int64, from, to time.Time) ([]MedicationIntake, error)
+}
+
+// Reminder - ежедневное напоминание заполнить дневник
+type Reminder struct {
+	ID     int64
+	UserID int64
+	ChatID int64
+	// Minute - время напоминания в минутах от полуночи по местному времени пользователя
+	Minute  int
+	Enabled bool
+	// NextAt - когда напоминание сработает в следующий раз, с учетом отсрочки
+	NextAt    time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type ReminderRepository interface {
+	Create(ctx context.Context, reminder *Reminder) error
+	Get(ctx context.Context, userID, id int64) (*Reminder, error)
+	// List возвращает напоминания пользователя по времени суток
+	List(ctx context.Context, userID int64) ([]Reminder, error)
+	Update(ctx context.Context, reminder *Reminder) error
+	Delete(ctx context.Context, userID, id int64) error
+	// Due возвращает включенные напоминания, которым пора сработать
+	Due(ctx context.Context, now time.Time, limit int) ([]Reminder, error)
+}
+
+// Действия, которые попадают в журнал аудита
+const (
+	AuditActionErasure   = "user_erasure"
+	AuditActionRetention = "retention"
+	AuditActionConsent   = "consent"
+)
+
+// AuditRecord - запись журнала аудита. Содержит только факт действия и счетчики,
+// без медицинских данных.
+type AuditRecord struct {
+	ID        int64
+	UserID    int64
+	Action    string
+	Details   string
+	CreatedAt time.Time
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, record *AuditRecord) error
+}
+
+// Erasure - сколько записей удалено по запросу пользователя
+type Erasure struct {
+	Analyses       int64
+	DiaryEntries   int64
+	Questionnaires int64
+	Lifestyle      int64
+	Medications    int64
+}
+
+// DefaultSettings возвращает настройки нового пользователя
+func DefaultSettings(userID int64) *Settings {
+	return &Settings{
+		UserID:        userID,
+		Language:      "",
+		Timezone:      "Europe/Moscow",
+		Notifications: NotifySound,
+	}
+}
+
+// Location возвращает часовой пояс пользователя, UTC - если пояс не распознан
+func (s *Settings) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}